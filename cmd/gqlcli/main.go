@@ -15,9 +15,14 @@ func main() {
 		URL:     "http://localhost:8080/graphql",
 		Format:  "toon",
 		Timeout: 30,
+
+		ReleaseRepo: "wricardo/gqlcli",
 	}
 
 	builder := gqlcli.NewCLIBuilder(cfg)
+	if path := os.Getenv("GQLCLI_HISTORY_FILE"); path != "" {
+		builder.WithHistoryFile(path)
+	}
 
 	app := &cli.App{
 		Name:    "gqlcli",
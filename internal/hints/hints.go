@@ -0,0 +1,70 @@
+// Package hints finds the type a GraphQL validation error refers to and
+// attaches its SDL to the error as a schemaHint extension. The HTTP client and
+// the inline error presenter both use it, so the two report the same hints for
+// the same message.
+package hints
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Source describes a type as SDL.
+type Source interface {
+	Describe(ctx context.Context, typeName string) (string, error)
+}
+
+// typePatterns match validation messages that name the type the user should
+// look at. The first submatch is the type name, possibly wrapped in list and
+// non-null markers. Quotes may be " (gqlgen, graphql-js) or ` (Apollo Router).
+var typePatterns = []*regexp.Regexp{
+	// Cannot query field "x" on type "Book".
+	regexp.MustCompile("Cannot query field [\"`][^\"`]+[\"`] on type [\"`]([^\"`]+)[\"`]"),
+	// Field "x" is not defined by type "BookInput".
+	regexp.MustCompile("Field [\"`][^\"`]+[\"`] is not defined by type [\"`]([^\"`]+)[\"`]"),
+	// Unknown argument "x" on field "Query.books".
+	regexp.MustCompile("Unknown argument [\"`][^\"`]+[\"`] on field [\"`]([^.\"`]+)\\.[^\"`]+[\"`]"),
+	// Unknown argument "x" on field "books" of type "Query". (graphql-js before v16)
+	regexp.MustCompile("Unknown argument [\"`][^\"`]+[\"`] on field [\"`][^\"`]+[\"`] of type [\"`]([^\"`]+)[\"`]"),
+	// Field "books" of type "[Book!]!" must have a selection of subfields.
+	regexp.MustCompile("Field [\"`][^\"`]+[\"`] of type [\"`]([^\"`]+)[\"`] must have a selection of subfields"),
+	// Field "BookInput.title" of required type "String!" was not provided.
+	regexp.MustCompile("Field [\"`]([^.\"`]+)\\.[^\"`]+[\"`] of required type [\"`][^\"`]+[\"`] was not provided"),
+	// type `Query` does not have a field `x` (Apollo Router)
+	regexp.MustCompile("type [\"`]([^\"`]+)[\"`] does not have a field"),
+}
+
+// ExtractType returns the name of the type a GraphQL validation error refers
+// to, or "" if msg is not a recognized validation error.
+func ExtractType(msg string) string {
+	for _, re := range typePatterns {
+		if m := re.FindStringSubmatch(msg); len(m) == 2 {
+			return strings.Trim(m[1], "[]!")
+		}
+	}
+	return ""
+}
+
+// Attach adds a schemaHint extension describing the type referenced by msg
+// and returns the updated extensions. extensions may be nil; a new map is only
+// allocated when a hint is attached. An existing schemaHint (e.g. one set by the
+// server) is left alone, and nothing is looked up once ctx is done.
+func Attach(ctx context.Context, src Source, msg string, extensions map[string]interface{}) map[string]interface{} {
+	if _, ok := extensions["schemaHint"]; ok || ctx.Err() != nil {
+		return extensions
+	}
+	typeName := ExtractType(msg)
+	if typeName == "" {
+		return extensions
+	}
+	hint, err := src.Describe(ctx, typeName)
+	if err != nil || hint == "" {
+		return extensions
+	}
+	if extensions == nil {
+		extensions = map[string]interface{}{}
+	}
+	extensions["schemaHint"] = hint
+	return extensions
+}
@@ -0,0 +1,85 @@
+package hints
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExtractType(t *testing.T) {
+	tests := []struct {
+		server string
+		msg    string
+		want   string
+	}{
+		// gqlgen (gqlparser validator)
+		{"gqlgen", `Cannot query field "titel" on type "Book". Did you mean "title"?`, "Book"},
+		{"gqlgen", `Unknown argument "limit" on field "Query.books".`, "Query"},
+		{"gqlgen", `Field "books" of type "[Book!]!" must have a selection of subfields. Did you mean "books { ... }"?`, "Book"},
+		{"gqlgen", `Field "AddBookInput.title" of required type "String!" was not provided.`, "AddBookInput"},
+		{"gqlgen", `Field "subtitle" is not defined by type "AddBookInput".`, "AddBookInput"},
+		{"gqlgen", `Cannot query field "title" on type "SearchResult". Did you mean to use an inline fragment on "Author" or "Book"?`, "SearchResult"},
+		{"gqlgen", `Variable "$id" is declared by anonymous query but not used.`, ""},
+		{"gqlgen", `Expected Name, found <EOF>`, ""},
+
+		// graphql-js v16
+		{"graphql-js", `Cannot query field "titel" on type "Book". Did you mean "title"?`, "Book"},
+		{"graphql-js", `Unknown argument "limit" on field "Query.books". Did you mean "first"?`, "Query"},
+		{"graphql-js", `Field "author" of type "Author!" must have a selection of subfields. Did you mean "author { ... }"?`, "Author"},
+		{"graphql-js", `Field "AddBookInput.authorName" of required type "String!" was not provided.`, "AddBookInput"},
+		{"graphql-js", `Field "subtitle" is not defined by type "AddBookInput". Did you mean "title"?`, "AddBookInput"},
+		{"graphql-js", `Field "title" must not have a selection since type "String!" has no subfields.`, ""},
+		{"graphql-js", `Syntax Error: Expected Name, found "}".`, ""},
+		// graphql-js before v16
+		{"graphql-js 15", `Unknown argument "limit" on field "books" of type "Query". Did you mean "first"?`, "Query"},
+
+		// Apollo Router
+		{"apollo router", "type `Query` does not have a field `bookz`", "Query"},
+		{"apollo router", "Cannot query field `titel` on type `Book`.", "Book"},
+		{"apollo router", "Unknown argument `limit` on field `Query.books`.", "Query"},
+		{"apollo router", "Field `books` of type `[Book!]!` must have a selection of subfields.", "Book"},
+		{"apollo router", "cannot find operation `Missing`", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractType(tt.msg); got != tt.want {
+			t.Errorf("%s: ExtractType(%q) = %q, want %q", tt.server, tt.msg, got, tt.want)
+		}
+	}
+}
+
+type fakeSource map[string]string
+
+func (f fakeSource) Describe(_ context.Context, typeName string) (string, error) {
+	if sdl, ok := f[typeName]; ok {
+		return sdl, nil
+	}
+	return "", errors.New("unknown type")
+}
+
+func TestAttach(t *testing.T) {
+	src := fakeSource{"Book": "type Book { id: ID! }"}
+	const msg = `Cannot query field "titel" on type "Book".`
+
+	ext := Attach(context.Background(), src, msg, nil)
+	if ext["schemaHint"] != "type Book { id: ID! }" {
+		t.Errorf("got %v", ext)
+	}
+
+	kept := map[string]interface{}{"code": "GRAPHQL_VALIDATION_FAILED", "schemaHint": "from the server"}
+	if ext := Attach(context.Background(), src, msg, kept); ext["schemaHint"] != "from the server" || ext["code"] != "GRAPHQL_VALIDATION_FAILED" {
+		t.Errorf("server hint or code was replaced: %v", ext)
+	}
+
+	if ext := Attach(context.Background(), src, `Cannot query field "x" on type "Author".`, nil); ext != nil {
+		t.Errorf("unknown type got a hint: %v", ext)
+	}
+	if ext := Attach(context.Background(), src, "Expected Name, found <EOF>", nil); ext != nil {
+		t.Errorf("non-validation error got a hint: %v", ext)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if ext := Attach(ctx, src, msg, nil); ext != nil {
+		t.Errorf("looked up a hint after ctx was done: %v", ext)
+	}
+}
@@ -0,0 +1,332 @@
+package testschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/introspection"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// object is a resolved GraphQL object: field name to value or resolver.
+// Objects of abstract types carry their concrete type under "__typename".
+type object map[string]interface{}
+
+// resolver computes a field value from its arguments.
+type resolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// Schema returns the parsed schema.
+func (s *Schema) Schema() *ast.Schema {
+	return s.schema
+}
+
+// Complexity reports no custom complexity.
+func (s *Schema) Complexity(ctx context.Context, typeName, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
+	return 0, false
+}
+
+// Exec runs the operation in ctx. Like generated gqlgen code, the work
+// happens in the returned handler, whose context collects field errors.
+func (s *Schema) Exec(ctx context.Context) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	var root object
+	switch oc.Operation.Operation {
+	case ast.Query:
+		root = s.queryRoot()
+		ischema := introspection.WrapSchema(s.schema)
+		root["__schema"] = schemaObject(ischema)
+		root["__type"] = resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			def := s.schema.Types[fmt.Sprint(args["name"])]
+			if def == nil {
+				return nil, nil
+			}
+			return typeObject(introspection.WrapTypeFromDef(s.schema, def)), nil
+		})
+	case ast.Mutation:
+		root = s.mutationRoot()
+	default:
+		return graphql.OneShot(graphql.ErrorResponse(ctx, "subscriptions are not supported"))
+	}
+	rootType := s.schema.Query
+	if oc.Operation.Operation == ast.Mutation {
+		rootType = s.schema.Mutation
+	}
+
+	done := false
+	return func(ctx context.Context) *graphql.Response {
+		if done {
+			return nil
+		}
+		done = true
+		data, _ := s.execSelection(ctx, rootType.Name, root, oc.Operation.SelectionSet)
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return graphql.ErrorResponse(ctx, "marshal response: %v", err)
+		}
+		return &graphql.Response{Data: raw}
+	}
+}
+
+// execSelection resolves sel on obj, which has type typeName. It returns
+// false when a non-null field came back null, so the null propagates.
+func (s *Schema) execSelection(ctx context.Context, typeName string, obj object, sel ast.SelectionSet) (*orderedMap, bool) {
+	oc := graphql.GetOperationContext(ctx)
+	satisfies := []string{typeName}
+	for _, def := range s.schema.GetImplements(s.schema.Types[typeName]) {
+		satisfies = append(satisfies, def.Name)
+	}
+
+	out := &orderedMap{values: map[string]interface{}{}}
+	for _, f := range graphql.CollectFields(oc, sel, satisfies) {
+		if f.Name == "__typename" {
+			out.set(f.Alias, typeName)
+			continue
+		}
+		def := s.schema.Types[typeName].Fields.ForName(f.Name)
+		args := f.ArgumentMap(oc.Variables)
+		fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{Object: typeName, Field: f, Args: args, IsResolver: true})
+
+		value := obj[f.Name]
+		if r, ok := value.(resolver); ok {
+			var err error
+			if value, err = r(fctx, args); err != nil {
+				graphql.AddError(fctx, err)
+				value = nil
+			}
+		}
+		completed, ok := s.complete(fctx, def.Type, value, f.Selections)
+		if !ok {
+			return nil, false
+		}
+		out.set(f.Alias, completed)
+	}
+	return out, true
+}
+
+// complete shapes value as typ. It returns false when a non-null position
+// holds null.
+func (s *Schema) complete(ctx context.Context, typ *ast.Type, value interface{}, sel ast.SelectionSet) (interface{}, bool) {
+	if value == nil {
+		if typ.NonNull {
+			if !hasErrorAt(ctx) {
+				graphql.AddError(ctx, gqlerror.Errorf("must not be null"))
+			}
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			graphql.AddError(ctx, fmt.Errorf("expected a list, got %T", value))
+			return nil, !typ.NonNull
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			i := i
+			ictx := graphql.WithFieldContext(ctx, &graphql.FieldContext{Index: &i, Result: item})
+			v, ok := s.complete(ictx, typ.Elem, item, sel)
+			if !ok {
+				return nil, !typ.NonNull
+			}
+			out[i] = v
+		}
+		return out, true
+	}
+
+	def := s.schema.Types[typ.Name()]
+	switch def.Kind {
+	case ast.Scalar, ast.Enum:
+		return value, true
+	}
+	obj, ok := value.(object)
+	if !ok {
+		graphql.AddError(ctx, fmt.Errorf("expected an object, got %T", value))
+		return nil, !typ.NonNull
+	}
+	typeName := def.Name
+	if def.IsAbstractType() {
+		typeName, _ = obj["__typename"].(string)
+	}
+	res, ok := s.execSelection(ctx, typeName, obj, sel)
+	if !ok {
+		return nil, !typ.NonNull
+	}
+	return res, true
+}
+
+// hasErrorAt reports whether an error was already added for ctx's path, so a
+// failed resolver on a non-null field isn't reported twice.
+func hasErrorAt(ctx context.Context) bool {
+	path := graphql.GetPath(ctx)
+	for _, e := range graphql.GetErrors(ctx) {
+		if e.Path.String() == path.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMap marshals its keys in insertion order, as GraphQL requires.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(k string, v interface{}) {
+	if _, ok := m.values[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.values[k] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// --- introspection ---
+
+func schemaObject(sc *introspection.Schema) object {
+	directives := []interface{}{}
+	for _, d := range sc.Directives() {
+		args := []interface{}{}
+		for _, a := range d.Args {
+			args = append(args, inputValueObject(a))
+		}
+		locations := []interface{}{}
+		for _, l := range d.Locations {
+			locations = append(locations, l)
+		}
+		directives = append(directives, object{
+			"name":         d.Name,
+			"description":  str(d.Description()),
+			"locations":    locations,
+			"args":         args,
+			"isRepeatable": d.IsRepeatable,
+		})
+	}
+	types := []interface{}{}
+	for _, t := range sc.Types() {
+		t := t
+		types = append(types, typeObject(&t))
+	}
+	return object{
+		"description":      str(sc.Description()),
+		"types":            types,
+		"queryType":        typeObject(sc.QueryType()),
+		"mutationType":     typeObject(sc.MutationType()),
+		"subscriptionType": typeObject(sc.SubscriptionType()),
+		"directives":       directives,
+	}
+}
+
+// typeObject describes t. Nested types are resolved lazily, since type
+// references are cyclic.
+func typeObject(t *introspection.Type) interface{} {
+	if t == nil {
+		return nil
+	}
+	types := func(ts []introspection.Type) []interface{} {
+		out := []interface{}{}
+		for _, x := range ts {
+			x := x
+			out = append(out, typeObject(&x))
+		}
+		return out
+	}
+	return object{
+		"kind":           t.Kind(),
+		"name":           str(t.Name()),
+		"description":    str(t.Description()),
+		"specifiedByURL": str(t.SpecifiedByURL()),
+		"isOneOf":        t.IsOneOf(),
+		"fields": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			includeDeprecated, _ := args["includeDeprecated"].(bool)
+			out := []interface{}{}
+			for _, f := range t.Fields(includeDeprecated) {
+				fargs := []interface{}{}
+				for _, a := range f.Args {
+					fargs = append(fargs, inputValueObject(a))
+				}
+				out = append(out, object{
+					"name":              f.Name,
+					"description":       str(f.Description()),
+					"args":              fargs,
+					"type":              typeObject(f.Type),
+					"isDeprecated":      f.IsDeprecated(),
+					"deprecationReason": str(f.DeprecationReason()),
+				})
+			}
+			return out, nil
+		}),
+		"inputFields": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			out := []interface{}{}
+			for _, f := range t.InputFields() {
+				out = append(out, inputValueObject(f))
+			}
+			return out, nil
+		}),
+		"interfaces": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return types(t.Interfaces()), nil
+		}),
+		"possibleTypes": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return types(t.PossibleTypes()), nil
+		}),
+		"enumValues": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			includeDeprecated, _ := args["includeDeprecated"].(bool)
+			out := []interface{}{}
+			for _, v := range t.EnumValues(includeDeprecated) {
+				out = append(out, object{
+					"name":              v.Name,
+					"description":       str(v.Description()),
+					"isDeprecated":      v.IsDeprecated(),
+					"deprecationReason": str(v.DeprecationReason()),
+				})
+			}
+			return out, nil
+		}),
+		"ofType": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return typeObject(t.OfType()), nil
+		}),
+	}
+}
+
+func inputValueObject(v introspection.InputValue) object {
+	return object{
+		"name":              v.Name,
+		"description":       str(v.Description()),
+		"type":              typeObject(v.Type),
+		"defaultValue":      str(v.DefaultValue),
+		"isDeprecated":      v.IsDeprecated(),
+		"deprecationReason": str(v.DeprecationReason()),
+	}
+}
+
+// str turns an optional string into a value; a nil pointer is null.
+func str(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
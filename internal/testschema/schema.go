@@ -0,0 +1,323 @@
+// Package testschema is a small in-memory GraphQL schema for tests: books and
+// authors with an enum, a union, nested input objects, an Upload argument, a
+// 64-bit scalar, a slow resolver, and a failing one. Every New starts from the
+// same seed data and touches neither disk nor network.
+package testschema
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+//go:embed schema.graphqls
+var SDL string
+
+// BigNumber is the value of Query.bigNumber: 2^53+1, which a float64 can't hold.
+const BigNumber = "9007199254740993"
+
+// RateLimitedEmail makes the login mutation fail with a RATE_LIMITED error.
+const RateLimitedEmail = "limited@example.com"
+
+type book struct {
+	id, title, genre, authorID string
+	tags                       []string
+	pages                      int
+	isbn                       json.Number
+	legacyCode                 string
+	coverUploaded              bool
+}
+
+type author struct {
+	id, name string
+}
+
+// Schema is a graphql.ExecutableSchema over in-memory data.
+type Schema struct {
+	schema *ast.Schema
+
+	mu      sync.Mutex
+	books   []*book
+	authors []*author
+	nextID  int
+	logins  []map[string]interface{}
+}
+
+// New returns a schema with the seed data: four books by three authors.
+func New() *Schema {
+	return &Schema{
+		schema: gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphqls", Input: SDL}),
+		authors: []*author{
+			{id: "a1", name: "Ursula K. Le Guin"},
+			{id: "a2", name: "Carl Sagan"},
+			{id: "a3", name: "Mary Beard"},
+		},
+		books: []*book{
+			{id: "b1", title: "The Dispossessed", genre: "FICTION", authorID: "a1", tags: []string{"classic", "sf"}, pages: 387, isbn: "9780061054884"},
+			{id: "b2", title: "A Wizard of Earthsea", genre: "FICTION", authorID: "a1", tags: []string{"fantasy"}, pages: 183, isbn: "9780547773742"},
+			{id: "b3", title: "Cosmos", genre: "SCIENCE", authorID: "a2", tags: []string{"space"}, pages: 396},
+			{id: "b4", title: "SPQR", genre: "HISTORY", authorID: "a3", tags: []string{}, pages: 608, legacyCode: "H-4"},
+		},
+		nextID: 5,
+	}
+}
+
+// Logins returns the variables of every login mutation run so far.
+func (s *Schema) Logins() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.logins...)
+}
+
+func (s *Schema) queryRoot() object {
+	return object{
+		"books": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			genre, _ := args["genre"].(string)
+			first, limited := intArg(args["first"])
+			var out []interface{}
+			for _, b := range s.books {
+				if genre != "" && b.genre != genre {
+					continue
+				}
+				if limited && len(out) >= first {
+					break
+				}
+				out = append(out, s.bookObject(b))
+			}
+			return list(out), nil
+		}),
+		"book": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if b := s.findBook(fmt.Sprint(args["id"])); b != nil {
+				return s.bookObject(b), nil
+			}
+			return nil, nil
+		}),
+		"authors": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			out := []interface{}{}
+			for _, a := range s.authors {
+				out = append(out, s.authorObject(a))
+			}
+			return out, nil
+		}),
+		"search": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			text := strings.ToLower(args["text"].(string))
+			out := []interface{}{}
+			for _, b := range s.books {
+				if strings.Contains(strings.ToLower(b.title), text) {
+					out = append(out, s.bookObject(b))
+				}
+			}
+			for _, a := range s.authors {
+				if strings.Contains(strings.ToLower(a.name), text) {
+					out = append(out, s.authorObject(a))
+				}
+			}
+			return out, nil
+		}),
+		"slow": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			ms, _ := intArg(args["ms"])
+			select {
+			case <-time.After(time.Duration(ms) * time.Millisecond):
+				return fmt.Sprintf("slept %dms", ms), nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}),
+		"failing": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return nil, &gqlerror.Error{Message: "failing always fails", Extensions: map[string]interface{}{"code": "INTERNAL"}}
+		}),
+		"bigNumber": json.Number(BigNumber),
+	}
+}
+
+func (s *Schema) mutationRoot() object {
+	return object{
+		"addBook": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			in := args["input"].(map[string]interface{})
+			b := &book{
+				id:       fmt.Sprintf("b%d", s.nextID),
+				title:    in["title"].(string),
+				genre:    "FICTION",
+				authorID: s.authorNamed(in["authorName"].(string)).id,
+				tags:     []string{},
+			}
+			if g, ok := in["genre"].(string); ok {
+				b.genre = g
+			}
+			if tags, ok := in["tags"].([]interface{}); ok {
+				for _, t := range tags {
+					b.tags = append(b.tags, t.(string))
+				}
+			}
+			if details, ok := in["details"].(map[string]interface{}); ok {
+				b.pages, _ = intArg(details["pages"])
+				if isbn := details["isbn"]; isbn != nil {
+					b.isbn = json.Number(fmt.Sprint(isbn))
+				}
+			}
+			if dry, _ := in["dryRun"].(bool); !dry {
+				s.books = append(s.books, b)
+				s.nextID++
+			}
+			return s.bookObject(b), nil
+		}),
+		"archiveBooks": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			ids, _ := args["ids"].([]interface{})
+			archived := 0
+			for _, id := range ids {
+				for i, b := range s.books {
+					if b.id == fmt.Sprint(id) {
+						s.books = append(s.books[:i:i], s.books[i+1:]...)
+						archived++
+						break
+					}
+				}
+			}
+			return archived, nil
+		}),
+		"uploadCover": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			b := s.findBook(fmt.Sprint(args["bookId"]))
+			if b == nil {
+				return nil, fmt.Errorf("book %s not found", args["bookId"])
+			}
+			b.coverUploaded = true
+			return s.bookObject(b), nil
+		}),
+		"login": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			s.logins = append(s.logins, args)
+			s.mu.Unlock()
+			email := args["email"].(string)
+			if email == RateLimitedEmail {
+				return nil, &gqlerror.Error{
+					Message:    "too many login attempts",
+					Extensions: map[string]interface{}{"code": "RATE_LIMITED", "retryAfter": 0.01},
+				}
+			}
+			if args["password"] != "secret" {
+				return nil, &gqlerror.Error{Message: "invalid credentials", Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"}}
+			}
+			return "token-" + email, nil
+		}),
+	}
+}
+
+func (s *Schema) findBook(id string) *book {
+	for _, b := range s.books {
+		if b.id == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// authorNamed returns the author called name, adding one if needed.
+func (s *Schema) authorNamed(name string) *author {
+	for _, a := range s.authors {
+		if a.name == name {
+			return a
+		}
+	}
+	a := &author{id: fmt.Sprintf("a%d", s.nextID), name: name}
+	s.authors = append(s.authors, a)
+	s.nextID++
+	return a
+}
+
+// bookObject snapshots b; callers hold s.mu.
+func (s *Schema) bookObject(b *book) object {
+	tags := make([]interface{}, len(b.tags))
+	for i, t := range b.tags {
+		tags[i] = t
+	}
+	var a *author
+	for _, x := range s.authors {
+		if x.id == b.authorID {
+			a = x
+		}
+	}
+	o := object{
+		"__typename":    "Book",
+		"id":            b.id,
+		"title":         b.title,
+		"genre":         b.genre,
+		"author":        s.authorObject(a),
+		"tags":          tags,
+		"coverUploaded": b.coverUploaded,
+	}
+	if b.pages != 0 {
+		o["pages"] = b.pages
+	}
+	if b.isbn != "" {
+		o["isbn"] = b.isbn
+	}
+	if b.legacyCode != "" {
+		o["legacyCode"] = b.legacyCode
+	}
+	return o
+}
+
+// authorObject snapshots a; its books are resolved lazily. Callers hold s.mu.
+func (s *Schema) authorObject(a *author) object {
+	return object{
+		"__typename": "Author",
+		"id":         a.id,
+		"name":       a.name,
+		"books": resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			out := []interface{}{}
+			for _, b := range s.books {
+				if b.authorID == a.id {
+					out = append(out, s.bookObject(b))
+				}
+			}
+			return out, nil
+		}),
+	}
+}
+
+func intArg(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
+
+// list turns a nil result into an empty list.
+func list(items []interface{}) []interface{} {
+	if items == nil {
+		return []interface{}{}
+	}
+	return items
+}
@@ -0,0 +1,84 @@
+package gqlcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wricardo/gqlcli/internal/testschema"
+)
+
+// Query.bigNumber and the isbn variables below are 2^53+1, which a float64
+// rounds to ...992. Every path must keep the digits intact.
+
+func bigIntApps(t *testing.T) map[string]*cli.App {
+	t.Helper()
+	ts := newTestServer(t)
+	inline, _ := inlineApp(t)
+	return map[string]*cli.App{"http": httpApp(t, ts.URL), "inline": inline}
+}
+
+func TestBigIntFormatters(t *testing.T) {
+	for label, app := range bigIntApps(t) {
+		for _, format := range NewFormatterRegistry().List() {
+			stdout, _, err := runApp(t, app, "query", "--format", format, "{ bigNumber }")
+			if err != nil {
+				t.Fatalf("%s %s: %v", label, format, err)
+			}
+			assertContains(t, stdout, testschema.BigNumber)
+		}
+	}
+}
+
+func TestBigIntConsole(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	srv := httptest.NewServer(NewConsoleHandler(e))
+	defer srv.Close()
+
+	for _, format := range NewFormatterRegistry().List() {
+		body, _ := json.Marshal(map[string]interface{}{"query": "{ bigNumber }", "format": format})
+		resp, err := http.Post(srv.URL+"/api/query", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		var out struct {
+			Output string          `json:"output"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, data)
+		}
+		assertContains(t, out.Output, testschema.BigNumber)
+		assertContains(t, string(out.Result), `"bigNumber":`+testschema.BigNumber)
+	}
+}
+
+func TestBigIntVariables(t *testing.T) {
+	const mutation = `mutation($isbn: BigInt) { addBook(input: {title: "Big", authorName: "Ada", details: {isbn: $isbn}}) { isbn } }`
+	varFile := filepath.Join(t.TempDir(), "vars.json")
+	if err := os.WriteFile(varFile, []byte(`{"isbn": `+testschema.BigNumber+`}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for label, app := range bigIntApps(t) {
+		for _, args := range [][]string{
+			{"--variables", `{"isbn": ` + testschema.BigNumber + `}`},
+			{"--var-file", varFile},
+			{"--var", "isbn=" + testschema.BigNumber},
+		} {
+			stdout, _, err := runApp(t, app, append(append([]string{"mutation"}, args...), mutation)...)
+			if err != nil {
+				t.Fatalf("%s %v: %v", label, args, err)
+			}
+			assertContains(t, stdout, `"isbn":`+testschema.BigNumber)
+		}
+	}
+}
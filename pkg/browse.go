@@ -0,0 +1,969 @@
+package gqlcli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+)
+
+// schemaIndex is a name-indexed view of an introspection result, shared by the
+// browse and search commands.
+type schemaIndex struct {
+	types map[string]map[string]interface{}
+	// roots maps root type names to their operation keyword (query, mutation, subscription).
+	roots map[string]string
+	// rootOrder lists root type names in query, mutation, subscription order.
+	rootOrder []string
+}
+
+func newSchemaIndex(result map[string]interface{}) (*schemaIndex, error) {
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid introspection response")
+	}
+	types, ok := schema["types"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid types in schema")
+	}
+
+	idx := &schemaIndex{types: map[string]map[string]interface{}{}, roots: map[string]string{}}
+	for _, t := range types {
+		if tm, ok := t.(map[string]interface{}); ok {
+			if name, _ := tm["name"].(string); name != "" {
+				idx.types[name] = tm
+			}
+		}
+	}
+	for _, r := range []struct{ key, op string }{{"queryType", "query"}, {"mutationType", "mutation"}, {"subscriptionType", "subscription"}} {
+		if rt, ok := schema[r.key].(map[string]interface{}); ok {
+			if name, _ := rt["name"].(string); name != "" {
+				idx.roots[name] = r.op
+				idx.rootOrder = append(idx.rootOrder, name)
+			}
+		}
+	}
+	return idx, nil
+}
+
+// fields returns the fields of an object or interface, or the input fields of an input object.
+func (idx *schemaIndex) fields(typeName string) []map[string]interface{} {
+	tm := idx.types[typeName]
+	list, _ := tm["fields"].([]interface{})
+	if len(list) == 0 {
+		list, _ = tm["inputFields"].([]interface{})
+	}
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, f := range list {
+		if fm, ok := f.(map[string]interface{}); ok {
+			out = append(out, fm)
+		}
+	}
+	return out
+}
+
+// namedType unwraps NON_NULL and LIST wrappers and returns the underlying type name.
+func namedType(typeRef interface{}) string {
+	tm, ok := typeRef.(map[string]interface{})
+	for ok {
+		if name, _ := tm["name"].(string); name != "" {
+			return name
+		}
+		tm, ok = tm["ofType"].(map[string]interface{})
+	}
+	return ""
+}
+
+// --- search ---
+
+// searchHit is a type or field matched by searchSchema.
+type searchHit struct {
+	Kind  string // "type" or "field"
+	Owner string // parent type for fields
+	Name  string
+	Data  map[string]interface{}
+	score int
+}
+
+// Label renders the hit as a single line.
+func (h searchHit) Label() string {
+	if h.Kind == "type" {
+		kind, _ := h.Data["kind"].(string)
+		return fmt.Sprintf("%s (%s)", h.Name, strings.ToLower(kind))
+	}
+	return h.Owner + "." + strings.TrimSpace(formatSDLField(h.Data, true))
+}
+
+// searchSchema fuzzy-matches term against type names and Type.field paths.
+// Results are ordered by match quality, then name.
+func searchSchema(idx *schemaIndex, term string) []searchHit {
+	var hits []searchHit
+	for name, tm := range idx.types {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if score, ok := fuzzyScore(term, name); ok {
+			hits = append(hits, searchHit{Kind: "type", Name: name, Data: tm, score: score})
+		}
+		for _, f := range idx.fields(name) {
+			fname, _ := f["name"].(string)
+			// Field names alone match first; the owner only breaks ties.
+			score, ok := fuzzyScore(term, fname)
+			if !ok {
+				if score, ok = fuzzyScore(term, name+"."+fname); !ok {
+					continue
+				}
+				score += 100
+			}
+			hits = append(hits, searchHit{Kind: "field", Owner: name, Name: fname, Data: f, score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score < hits[j].score
+		}
+		return hits[i].Label() < hits[j].Label()
+	})
+	return hits
+}
+
+// fuzzyScore reports whether pattern's characters appear in s in order
+// (case-insensitive). Lower scores are better: exact and prefix matches rank
+// first, then substrings, then scattered matches by total gap length.
+func fuzzyScore(pattern, s string) (int, bool) {
+	p, t := strings.ToLower(pattern), strings.ToLower(s)
+	switch {
+	case p == "":
+		return 0, true
+	case p == t:
+		return 0, true
+	case strings.HasPrefix(t, p):
+		return 1 + len(t) - len(p), true
+	case strings.Contains(t, p):
+		return 20 + strings.Index(t, p), true
+	}
+	score, last := 40, -1
+	for _, r := range p {
+		i := strings.IndexRune(t[last+1:], r)
+		if i < 0 {
+			return 0, false
+		}
+		score += i
+		last += i + 1
+	}
+	return score, true
+}
+
+// printSearchHits writes at most limit hits, one per line. A limit of zero prints all hits.
+func printSearchHits(w io.Writer, hits []searchHit, limit int) {
+	if len(hits) == 0 {
+		fmt.Fprintln(w, "no matches")
+		return
+	}
+	for i, h := range hits {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(w, "… %d more (use --limit to show more)\n", len(hits)-limit)
+			break
+		}
+		fmt.Fprintf(w, "%-5s  %s\n", h.Kind, h.Label())
+	}
+}
+
+// --- browse ---
+
+// browseNode is a row in the browse tree. Children are built on first expansion
+// so recursive schemas can be browsed without building an infinite tree.
+type browseNode struct {
+	kind     string // "type", "field", or "arg"
+	typeName string // the node's own type for "type", the owning type for "field"
+	data     map[string]interface{}
+	depth    int
+	expanded bool
+	children []*browseNode
+}
+
+func (n *browseNode) label(idx *schemaIndex) string {
+	switch n.kind {
+	case "type":
+		if op, ok := idx.roots[n.typeName]; ok {
+			return fmt.Sprintf("%s (%s)", n.typeName, op)
+		}
+		kind, _ := idx.types[n.typeName]["kind"].(string)
+		return fmt.Sprintf("%s (%s)", n.typeName, strings.ToLower(kind))
+	case "field":
+		line := strings.TrimSpace(formatSDLField(n.data, true))
+		if n.depth == 0 {
+			// Search results are shown without their parent type node.
+			line = n.typeName + "." + line
+		}
+		if dep, _ := n.data["isDeprecated"].(bool); dep {
+			line += "  [deprecated]"
+		}
+		return line
+	default:
+		name, _ := n.data["name"].(string)
+		return fmt.Sprintf("%s: %s", name, formatTypeRef(n.data["type"]))
+	}
+}
+
+// expandable reports whether expanding n would show anything.
+func (n *browseNode) expandable(idx *schemaIndex) bool {
+	switch n.kind {
+	case "type":
+		return len(idx.fields(n.typeName)) > 0
+	case "field":
+		return true
+	default:
+		return len(idx.fields(namedType(n.data["type"]))) > 0
+	}
+}
+
+// expand builds n's children: a type shows its fields; a field shows its
+// arguments and return type; an argument shows its input type.
+func (n *browseNode) expand(idx *schemaIndex) {
+	if n.children != nil {
+		return
+	}
+	child := func(kind, typeName string, data map[string]interface{}) *browseNode {
+		return &browseNode{kind: kind, typeName: typeName, data: data, depth: n.depth + 1}
+	}
+	switch n.kind {
+	case "type":
+		for _, f := range idx.fields(n.typeName) {
+			n.children = append(n.children, child("field", n.typeName, f))
+		}
+	case "field":
+		args, _ := n.data["args"].([]interface{})
+		for _, a := range args {
+			if am, ok := a.(map[string]interface{}); ok {
+				n.children = append(n.children, child("arg", n.typeName, am))
+			}
+		}
+		ret := namedType(n.data["type"])
+		n.children = append(n.children, child("type", ret, idx.types[ret]))
+	case "arg":
+		in := namedType(n.data["type"])
+		n.children = append(n.children, child("type", in, idx.types[in]))
+	}
+}
+
+// detail renders the detail pane for n: description, deprecation, and SDL.
+func (n *browseNode) detail(idx *schemaIndex) string {
+	var b strings.Builder
+	desc, _ := n.data["description"].(string)
+	switch n.kind {
+	case "type":
+		fmt.Fprintf(&b, "%s\n", n.label(idx))
+		if desc != "" {
+			fmt.Fprintf(&b, "\n%s\n", desc)
+		}
+		if tm := idx.types[n.typeName]; tm != nil {
+			fmt.Fprintf(&b, "\n%s", FormatTypeSDL(tm, true, false))
+		}
+	case "field":
+		fmt.Fprintf(&b, "%s.%s", n.typeName, strings.TrimSpace(formatSDLField(n.data, true)))
+		b.WriteString("\n")
+		if desc != "" {
+			fmt.Fprintf(&b, "\n%s\n", desc)
+		}
+		if dep, _ := n.data["isDeprecated"].(bool); dep {
+			reason, _ := n.data["deprecationReason"].(string)
+			fmt.Fprintf(&b, "\ndeprecated: %s\n", reason)
+		}
+	default:
+		fmt.Fprintf(&b, "%s\n", n.label(idx))
+		if desc != "" {
+			fmt.Fprintf(&b, "\n%s\n", desc)
+		}
+		if def, ok := n.data["defaultValue"].(string); ok {
+			fmt.Fprintf(&b, "\ndefault: %s\n", def)
+		}
+	}
+	return b.String()
+}
+
+// sdl returns the SDL for n: the type definition for types, the field or
+// argument definition otherwise.
+func (n *browseNode) sdl(idx *schemaIndex) string {
+	switch n.kind {
+	case "type":
+		return FormatTypeSDL(idx.types[n.typeName], true, false)
+	case "field":
+		return strings.TrimSpace(formatSDLField(n.data, true)) + "\n"
+	default:
+		return n.label(idx) + "\n"
+	}
+}
+
+// querySkeleton generates an operation calling a root field, declaring a
+// variable per argument and selecting the scalar fields of the return type.
+func querySkeleton(idx *schemaIndex, owner string, field map[string]interface{}) (string, error) {
+	op, ok := idx.roots[owner]
+	if !ok {
+		return "", fmt.Errorf("%s is not a root type; query skeletons are generated for root fields only", owner)
+	}
+	name, _ := field["name"].(string)
+
+	var vars, args []string
+	list, _ := field["args"].([]interface{})
+	for _, a := range list {
+		if am, ok := a.(map[string]interface{}); ok {
+			aname, _ := am["name"].(string)
+			vars = append(vars, fmt.Sprintf("$%s: %s", aname, formatTypeRef(am["type"])))
+			args = append(args, fmt.Sprintf("%s: $%s", aname, aname))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(op + " " + strings.ToUpper(name[:1]) + name[1:])
+	if len(vars) > 0 {
+		fmt.Fprintf(&b, "(%s)", strings.Join(vars, ", "))
+	}
+	b.WriteString(" {\n  " + name)
+	if len(args) > 0 {
+		fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+	}
+
+	ret := idx.types[namedType(field["type"])]
+	switch kind, _ := ret["kind"].(string); kind {
+	case "OBJECT", "INTERFACE":
+		var sel []string
+		for _, f := range idx.fields(namedType(field["type"])) {
+			fname, _ := f["name"].(string)
+			fkind, _ := idx.types[namedType(f["type"])]["kind"].(string)
+			fargs, _ := f["args"].([]interface{})
+			if (fkind == "SCALAR" || fkind == "ENUM") && len(fargs) == 0 {
+				sel = append(sel, fname)
+			}
+		}
+		if len(sel) == 0 {
+			sel = []string{"__typename"}
+		}
+		b.WriteString(" {\n    " + strings.Join(sel, "\n    ") + "\n  }")
+	case "UNION":
+		b.WriteString(" {\n    __typename\n  }")
+	}
+	b.WriteString("\n}\n")
+	return b.String(), nil
+}
+
+// browser is the interactive state for one browse session.
+type browser struct {
+	idx      *schemaIndex
+	tree     []*browseNode // root operation types
+	view     []*browseNode // tree or search results currently shown
+	selected *browseNode
+	in       *bufio.Reader
+	out      io.Writer
+	// onExit collects copied text printed to stdout when the session ends.
+	onExit     []string
+	clipboard  bool
+	searchTerm string
+	// keys reads single key presses (the terminal must be in raw mode, see
+	// rawMode) instead of numbered commands, redrawing the screen after each.
+	keys bool
+	// height is the terminal height used to scroll the tree in key mode; zero shows every row.
+	height int
+	status string
+}
+
+const browseHelp = `Commands (press Enter after each):
+  N         select row N and expand/collapse it
+  /TEXT     fuzzy search types and fields; "/" alone returns to the tree
+  c         copy the SDL of the selected node
+  s         copy a query skeleton for the selected root field
+  ?         show this help
+  q         quit
+`
+
+const browseKeyHelp = `Keys:
+  ↑ ↓ j k     move the selection
+  → l Enter   expand the selected row (Enter toggles)
+  ← h         collapse the selected row, or go to its parent
+  /           fuzzy search types and fields; an empty search returns to the tree
+  c           copy the SDL of the selected node
+  s           copy a query skeleton for the selected root field
+  ?           show this help
+  q           quit`
+
+func newBrowser(idx *schemaIndex, in io.Reader, out io.Writer, clipboard bool) *browser {
+	br := &browser{idx: idx, in: bufio.NewReader(in), out: out, clipboard: clipboard}
+	for _, name := range idx.rootOrder {
+		br.tree = append(br.tree, &browseNode{kind: "type", typeName: name, data: idx.types[name]})
+	}
+	br.view = br.tree
+	return br
+}
+
+// rows returns the visible nodes of the current view in display order.
+func (br *browser) rows() []*browseNode {
+	var out []*browseNode
+	var walk func([]*browseNode)
+	walk = func(nodes []*browseNode) {
+		for _, n := range nodes {
+			out = append(out, n)
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+	walk(br.view)
+	return out
+}
+
+func (br *browser) render() {
+	if br.searchTerm != "" {
+		fmt.Fprintf(br.out, "\nsearch: %s\n", br.searchTerm)
+	} else {
+		fmt.Fprintln(br.out)
+	}
+	rows := br.rows()
+	first, last := 0, len(rows)
+	if br.keys && br.height > 0 {
+		// Keep the selection on screen, leaving room for the detail pane.
+		window := br.height - 14
+		if window < 5 {
+			window = 5
+		}
+		if cur := br.cursor(); cur >= window {
+			first = cur - window + 1
+		}
+		if last > first+window {
+			last = first + window
+		}
+	}
+	if first > 0 {
+		fmt.Fprintf(br.out, "      … %d above\n", first)
+	}
+	for i := first; i < last; i++ {
+		n := rows[i]
+		marker := "  "
+		if n.expandable(br.idx) {
+			marker = "▸ "
+			if n.expanded {
+				marker = "▾ "
+			}
+		}
+		cursor := " "
+		if n == br.selected {
+			cursor = ">"
+		}
+		fmt.Fprintf(br.out, "%s%3d %s%s%s\n", cursor, i+1, strings.Repeat("  ", n.depth), marker, n.label(br.idx))
+	}
+	if last < len(rows) {
+		fmt.Fprintf(br.out, "      … %d below\n", len(rows)-last)
+	}
+	if br.selected != nil {
+		fmt.Fprintf(br.out, "\n%s\n", indentLines(br.selected.detail(br.idx), "  │ "))
+	}
+}
+
+// notef reports the outcome of a command: at once in line mode, or below the
+// redrawn screen in key mode.
+func (br *browser) notef(format string, args ...interface{}) {
+	if br.keys {
+		br.status = fmt.Sprintf(format, args...)
+		return
+	}
+	fmt.Fprintf(br.out, format+"\n", args...)
+}
+
+// run processes commands until q or end of input, then returns the text to print on exit.
+func (br *browser) run() []string {
+	if br.keys {
+		return br.runKeys()
+	}
+	br.render()
+	for {
+		fmt.Fprint(br.out, "browse> ")
+		line, err := br.in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil && line == "" {
+			fmt.Fprintln(br.out)
+			return br.onExit
+		}
+		switch {
+		case line == "":
+			continue
+		case line == "q" || line == "quit":
+			return br.onExit
+		case line == "?" || line == "h" || line == "help":
+			fmt.Fprint(br.out, browseHelp)
+			continue
+		case strings.HasPrefix(line, "/"):
+			br.search(strings.TrimSpace(line[1:]))
+		case line == "c":
+			br.copySDL()
+			continue
+		case line == "s":
+			br.copySkeleton()
+			continue
+		default:
+			n, err := strconv.Atoi(line)
+			rows := br.rows()
+			if err != nil || n < 1 || n > len(rows) {
+				fmt.Fprintf(br.out, "unknown command %q (? for help)\n", line)
+				continue
+			}
+			br.selected = rows[n-1]
+			br.toggle(br.selected)
+		}
+		br.render()
+	}
+}
+
+// runKeys handles one key press at a time, redrawing the screen after each.
+func (br *browser) runKeys() []string {
+	if br.selected == nil {
+		br.moveTo(0)
+	}
+	for {
+		fmt.Fprint(br.out, "\x1b[H\x1b[2J")
+		br.render()
+		if br.status != "" {
+			fmt.Fprintf(br.out, "\n%s\n", br.status)
+			br.status = ""
+		}
+		fmt.Fprint(br.out, "\n↑↓ move  →← expand/collapse  / search  c copy SDL  s copy skeleton  ? help  q quit\n")
+
+		key, err := readKey(br.in)
+		if err != nil {
+			return br.onExit
+		}
+		switch key {
+		case "q", "ctrl-c", "ctrl-d":
+			return br.onExit
+		case "up", "k":
+			br.moveTo(br.cursor() - 1)
+		case "down", "j":
+			br.moveTo(br.cursor() + 1)
+		case "enter":
+			br.toggle(br.selected)
+		case "right", "l":
+			if n := br.selected; n != nil && !n.expanded {
+				br.toggle(n)
+			}
+		case "left", "h":
+			if n := br.selected; n != nil && n.expanded {
+				br.toggle(n)
+			} else {
+				br.moveToParent()
+			}
+		case "/":
+			if term, ok := br.prompt("/"); ok {
+				br.search(strings.TrimSpace(term))
+				br.moveTo(0)
+			}
+		case "c":
+			br.copySDL()
+		case "s":
+			br.copySkeleton()
+		case "?":
+			br.status = browseKeyHelp
+		}
+	}
+}
+
+// cursor returns the row index of the selected node, or -1.
+func (br *browser) cursor() int {
+	for i, n := range br.rows() {
+		if n == br.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveTo selects row i, clamped to the visible rows.
+func (br *browser) moveTo(i int) {
+	rows := br.rows()
+	if len(rows) == 0 {
+		br.selected = nil
+		return
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(rows) {
+		i = len(rows) - 1
+	}
+	br.selected = rows[i]
+}
+
+// moveToParent selects the nearest row above the selection with less depth.
+func (br *browser) moveToParent() {
+	rows := br.rows()
+	cur := br.cursor()
+	for i := cur - 1; i >= 0; i-- {
+		if rows[i].depth < rows[cur].depth {
+			br.selected = rows[i]
+			return
+		}
+	}
+}
+
+// toggle expands or collapses n when it has anything to show.
+func (br *browser) toggle(n *browseNode) {
+	if n == nil || !n.expandable(br.idx) {
+		return
+	}
+	n.expand(br.idx)
+	n.expanded = !n.expanded
+}
+
+func (br *browser) copySDL() {
+	if br.selected == nil {
+		br.notef("nothing selected")
+		return
+	}
+	br.copy("SDL", br.selected.sdl(br.idx))
+}
+
+func (br *browser) copySkeleton() {
+	if br.selected == nil || br.selected.kind != "field" {
+		br.notef("select a root field first")
+		return
+	}
+	skeleton, err := querySkeleton(br.idx, br.selected.typeName, br.selected.data)
+	if err != nil {
+		br.notef("%v", err)
+		return
+	}
+	br.copy("query skeleton", skeleton)
+}
+
+// prompt reads a line of text in key mode, echoing it after label. It reports
+// false when the input is abandoned with Esc or Ctrl-C.
+func (br *browser) prompt(label string) (string, bool) {
+	var text []rune
+	for {
+		fmt.Fprintf(br.out, "\r\x1b[K%s%s", label, string(text))
+		key, err := readKey(br.in)
+		if err != nil {
+			return "", false
+		}
+		switch key {
+		case "enter":
+			return string(text), true
+		case "esc", "ctrl-c", "ctrl-d":
+			return "", false
+		case "backspace":
+			if len(text) > 0 {
+				text = text[:len(text)-1]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				text = append(text, []rune(key)...)
+			}
+		}
+	}
+}
+
+// readKey reads one key press from a terminal in raw mode. Arrow keys are
+// returned as "up", "down", "left", and "right", control keys by name, and
+// anything else as the character typed.
+func readKey(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0x1b:
+		// Arrow keys arrive as ESC [ X (or ESC O X) in a single read.
+		if r.Buffered() < 2 {
+			return "esc", nil
+		}
+		if next, _ := r.Peek(1); next[0] != '[' && next[0] != 'O' {
+			return "esc", nil
+		}
+		r.ReadByte()
+		code, _ := r.ReadByte()
+		if name, ok := map[byte]string{'A': "up", 'B': "down", 'C': "right", 'D': "left"}[code]; ok {
+			return name, nil
+		}
+		return "esc", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	case 0x04:
+		return "ctrl-d", nil
+	}
+	if b < utf8.RuneSelf {
+		return string(rune(b)), nil
+	}
+	r.UnreadByte()
+	ch, _, err := r.ReadRune()
+	return string(ch), err
+}
+
+func (br *browser) search(term string) {
+	br.searchTerm = term
+	br.selected = nil
+	if term == "" {
+		br.view = br.tree
+		return
+	}
+	br.view = nil
+	for i, h := range searchSchema(br.idx, term) {
+		if i == 50 {
+			break
+		}
+		node := &browseNode{kind: h.Kind, typeName: h.Owner, data: h.Data}
+		if h.Kind == "type" {
+			node.typeName = h.Name
+		}
+		br.view = append(br.view, node)
+	}
+}
+
+// copy puts text on the system clipboard, or queues it for stdout on exit when
+// no clipboard is available (or --stdout was given).
+func (br *browser) copy(what, text string) {
+	if br.clipboard {
+		if err := copyToClipboard(text); err == nil {
+			br.notef("copied %s to clipboard", what)
+			return
+		}
+	}
+	br.onExit = append(br.onExit, text)
+	br.notef("%s will be printed to stdout on exit", what)
+}
+
+// rawMode puts the terminal on f into unbuffered, unechoed input using stty,
+// since this module does not depend on x/term. Signals are disabled too, so
+// Ctrl-C reaches the browser and the settings are always restored. It returns
+// a function that restores the previous settings.
+func rawMode(f *os.File) (func(), error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "-icanon", "-echo", "-isig", "min", "1", "time", "0"); err != nil {
+		return nil, err
+	}
+	return func() { stty(f, saved) }, nil
+}
+
+// terminalHeight returns the number of rows of the terminal on f, or 0 if unknown.
+func terminalHeight(f *os.File) int {
+	size, err := stty(f, "size")
+	if err != nil {
+		return 0
+	}
+	var rows, cols int
+	if _, err := fmt.Sscan(size, &rows, &cols); err != nil {
+		return 0
+	}
+	return rows
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// copyToClipboard pipes text to the first available clipboard tool.
+func copyToClipboard(text string) error {
+	tools := [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"},
+	}
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return fmt.Errorf("no clipboard tool found")
+}
+
+func indentLines(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(prefix+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// browseFlags are the flags shared by the HTTP and inline browse commands.
+func browseFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "search",
+			Aliases: []string{"s"},
+			Usage:   "Start with this search (and print its results when there is no terminal)",
+		},
+		&cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "Print copied SDL and query skeletons to stdout on exit instead of the clipboard",
+		},
+	}
+}
+
+// runBrowse starts an interactive session over result. When stdin or stderr is
+// not a terminal it prints the results of --search instead, and fails without
+// one rather than dumping the whole schema. The session UI is drawn on stderr
+// so stdout only carries copied text, e.g. q=$(gqlcli browse --stdout).
+func runBrowse(c *cli.Context, result map[string]interface{}) error {
+	idx, err := newSchemaIndex(result)
+	if err != nil {
+		return err
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+		term := c.String("search")
+		if term == "" {
+			return fmt.Errorf("browse needs a terminal; pass --search TERM to print matching types and fields instead")
+		}
+		printSearchHits(os.Stdout, searchSchema(idx, term), 0)
+		return nil
+	}
+
+	br := newBrowser(idx, os.Stdin, os.Stderr, !c.Bool("stdout"))
+	if term := c.String("search"); term != "" {
+		br.search(term)
+	}
+	var copied []string
+	if restore, err := rawMode(os.Stdin); err == nil {
+		// Draw on the alternate screen so the session leaves scrollback alone.
+		br.keys, br.height = true, terminalHeight(os.Stdin)
+		fmt.Fprint(os.Stderr, "\x1b[?1049h")
+		copied = br.run()
+		fmt.Fprint(os.Stderr, "\x1b[?1049l")
+		restore()
+	} else {
+		// Without stty, fall back to numbered commands read a line at a time.
+		fmt.Fprint(os.Stderr, browseHelp)
+		copied = br.run()
+	}
+	for _, text := range copied {
+		fmt.Print(text)
+	}
+	return nil
+}
+
+// loadSchema introspects the configured endpoint and caches the result. With
+// cached set it returns the cached introspection result instead, introspecting
+// only when the cache is empty. Failing to write the cache is only a warning.
+func (b *CLIBuilder) loadSchema(ctx context.Context, cached bool) (map[string]interface{}, error) {
+	cache := NewSchemaCache(b.config.CacheDir)
+	if cached {
+		if result, err := cache.LoadSchema(b.config.URL); err == nil {
+			return result, nil
+		}
+	}
+	result, err := b.client.Introspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.SaveSchema(b.config.URL, result); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to cache schema: %v\n", err)
+	}
+	return result, nil
+}
+
+// schemaSourceFlags are the url, debug, cache-dir, and cached flags for
+// commands that read the schema.
+func (b *CLIBuilder) schemaSourceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "url",
+			Aliases: []string{"u"},
+			Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
+			Value:   b.config.URL,
+			EnvVars: []string{"GRAPHQL_URL"},
+		},
+		&cli.BoolFlag{
+			Name:    "debug",
+			Aliases: []string{"d"},
+			Usage:   "Enable debug mode (logs HTTP requests/responses)",
+			Value:   b.config.Debug,
+		},
+		&cli.StringFlag{
+			Name:    "cache-dir",
+			Usage:   "Cache directory (default: ~/.gqlcli/cache)",
+			Value:   b.config.CacheDir,
+			EnvVars: []string{"GQLCLI_CACHE_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:  "cached",
+			Usage: "Use the schema cached by an earlier run or warm instead of introspecting (introspects if the cache is empty)",
+		},
+	}
+}
+
+func (b *CLIBuilder) applySchemaSourceFlags(c *cli.Context) {
+	b.config.URL = c.String("url")
+	b.config.Debug = c.Bool("debug")
+	b.config.CacheDir = c.String("cache-dir")
+	b.client = NewHTTPClient(b.config)
+}
+
+// GetBrowseCommand returns the interactive schema browser command.
+func (b *CLIBuilder) GetBrowseCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "browse",
+		Usage: "Explore the schema as an expandable tree",
+		Description: "Shows root operation types as a tree: expanding a type lists its fields, expanding a field " +
+			"lists its arguments and return type. Move with the arrow keys (or j/k), expand with Enter or →, search " +
+			"with /, and copy the SDL (c) or a query skeleton (s) of the selection; without stty, rows are selected " +
+			"by number instead. --cached works from the schema cache (see warm) instead of introspecting. " +
+			"Without a terminal it prints the results of --search instead.",
+		Flags: append(b.schemaSourceFlags(), browseFlags()...),
+		Action: func(c *cli.Context) error {
+			b.applySchemaSourceFlags(c)
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
+			if err != nil {
+				return err
+			}
+			return runBrowse(c, result)
+		},
+	}
+}
+
+// GetSearchCommand returns the non-interactive schema search command.
+func (b *CLIBuilder) GetSearchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "search",
+		Usage:     "Fuzzy-search type and field names in the schema",
+		ArgsUsage: "TERM",
+		Flags: append(b.schemaSourceFlags(), &cli.IntFlag{
+			Name:  "limit",
+			Usage: "Maximum results to print (0 for all)",
+			Value: 20,
+		}),
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("TERM argument is required")
+			}
+			b.applySchemaSourceFlags(c)
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
+			if err != nil {
+				return err
+			}
+			idx, err := newSchemaIndex(result)
+			if err != nil {
+				return err
+			}
+			printSearchHits(os.Stdout, searchSchema(idx, c.Args().First()), c.Int("limit"))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,161 @@
+package gqlcli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func testSchemaIndex(t *testing.T) *schemaIndex {
+	t.Helper()
+	ts := newTestServer(t)
+	result, err := NewHTTPClient(&Config{URL: ts.URL}).Introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := newSchemaIndex(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestBrowserKeys(t *testing.T) {
+	idx := testSchemaIndex(t)
+	keys := strings.Join([]string{
+		"\r",              // expand Query
+		"j",               // Query.books
+		"s",               // copy its skeleton
+		"\x1b[B",          // Query.book
+		"\x1b[C",          // expand it
+		"\x1b[D",          // collapse it
+		"\x1b[D",          // go to Query
+		"/addBx\x7fook\r", // search, with a typo fixed
+		"c",               // copy the SDL of the first hit
+		"q",
+	}, "")
+	var out bytes.Buffer
+	br := newBrowser(idx, strings.NewReader(keys), &out, false)
+	br.keys = true
+	copied := br.run()
+
+	if len(copied) != 2 {
+		t.Fatalf("copied %d texts, want 2: %q", len(copied), copied)
+	}
+	assertContains(t, copied[0], "query", "books")
+	assertContains(t, copied[1], "addBook(input: AddBookInput!)")
+	assertContains(t, out.String(), "\x1b[2J", "search: addBook", "query skeleton will be printed to stdout on exit")
+	if name, _ := br.selected.data["name"].(string); br.selected.kind != "field" || name != "addBook" {
+		t.Errorf("selected %s %q, want field addBook", br.selected.kind, name)
+	}
+
+	// ← on a collapsed row selects its parent; ↑ stops at the first row.
+	br = newBrowser(idx, strings.NewReader("\rjj\x1b[D\x1b[A\x1b[Aq"), &out, false)
+	br.keys = true
+	br.run()
+	if br.selected != br.tree[0] {
+		t.Errorf("selected %s, want the Query root", br.selected.label(idx))
+	}
+}
+
+func TestBrowserScrollsInKeyMode(t *testing.T) {
+	idx := testSchemaIndex(t)
+	var out bytes.Buffer
+	br := newBrowser(idx, strings.NewReader("/e\r"+strings.Repeat("j", 10)+"q"), &out, false)
+	br.keys, br.height = true, 20
+	br.run()
+
+	out.Reset()
+	br.render()
+	screen := out.String()
+	assertContains(t, screen, "… 5 above", "below", "> 11 ")
+	if rows := strings.Count(screen, "\n  ") + strings.Count(screen, "\n>"); rows > 20 {
+		t.Errorf("drew %d rows on a 20-row terminal", rows)
+	}
+}
+
+func TestBrowserLines(t *testing.T) {
+	idx := testSchemaIndex(t)
+	var out bytes.Buffer
+	br := newBrowser(idx, strings.NewReader("1\n2\ns\n/Genre\n1\nc\n99\nq\n"), &out, false)
+	copied := br.run()
+	if len(copied) != 2 {
+		t.Fatalf("copied %d texts, want 2: %q", len(copied), copied)
+	}
+	assertContains(t, copied[0], "books")
+	assertContains(t, copied[1], "Genre")
+	assertContains(t, out.String(), "browse> ", `unknown command "99"`)
+}
+
+func TestReadKey(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"\x1b[A", "up"},
+		{"\x1b[B", "down"},
+		{"\x1bOC", "right"},
+		{"\x1b[D", "left"},
+		{"\x1b", "esc"},
+		{"\r", "enter"},
+		{"\n", "enter"},
+		{"\x7f", "backspace"},
+		{"\x03", "ctrl-c"},
+		{"j", "j"},
+		{"é", "é"},
+	}
+	for _, tt := range tests {
+		got, err := readKey(bufio.NewReader(strings.NewReader(tt.in)))
+		if err != nil || got != tt.want {
+			t.Errorf("readKey(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestBrowseWithoutTerminal(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+
+	stdout, _, err := runApp(t, app, "browse")
+	if err == nil || !strings.Contains(err.Error(), "--search") {
+		t.Errorf("browse without a terminal or --search: %v", err)
+	}
+	if stdout != "" {
+		t.Errorf("printed the schema anyway:\n%s", stdout)
+	}
+
+	stdout, _, err = runApp(t, app, "browse", "--search", "genre")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "Genre (enum)", "Book.genre: Genre!")
+}
+
+func TestInlineBrowseUsesCache(t *testing.T) {
+	t.Setenv("GQLCLI_CACHE_DIR", t.TempDir())
+	app, _ := inlineApp(t)
+
+	stdout, _, err := runApp(t, app, "browse", "--search", "Book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "Book (object)")
+
+	// Later runs read the cached schema: a type only the cache has is found.
+	cache := NewSchemaCache("")
+	result, err := cache.LoadSchema(inlineCacheKey("books"))
+	if err != nil {
+		t.Fatalf("browse did not cache the schema: %v", err)
+	}
+	schema := unwrapSchema(result).(map[string]interface{})
+	schema["types"] = append(schema["types"].([]interface{}), map[string]interface{}{"kind": "OBJECT", "name": "Shelf"})
+	if err := cache.SaveSchema(inlineCacheKey("books"), result); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, _ = runApp(t, app, "browse", "--search", "Shelf")
+	assertContains(t, stdout, "Shelf (object)")
+
+	stdout, _, _ = runApp(t, app, "browse", "--refresh", "--search", "Shelf")
+	if strings.Contains(stdout, "Shelf (object)") {
+		t.Error("--refresh still read the cached schema")
+	}
+}
@@ -0,0 +1,274 @@
+package gqlcli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaCache persists introspection results on disk so later commands can run
+// without reaching the endpoint. Each endpoint gets its own directory under
+// {dir}/{hash of URL}/ containing:
+//
+//	schema.json     full introspection response
+//	roots.json      root operation type names
+//	scalars.json    example values for every scalar (see RegisterValueGenerator)
+//	types/*.json    Describer entries, one per type
+//	results/*.json  the last result of each query run with --diff-prev
+type SchemaCache struct {
+	dir string
+}
+
+// CacheEntry describes one file in an endpoint's cache.
+type CacheEntry struct {
+	Name string
+	Size int64
+}
+
+// DefaultCacheTTL is how long type lookups trust the schema cache before they
+// introspect the endpoint again. See Config.CacheTTL.
+const DefaultCacheTTL = 24 * time.Hour
+
+// DefaultCacheDir returns $GQLCLI_CACHE_DIR if set, otherwise ~/.gqlcli/cache.
+func DefaultCacheDir() string {
+	if dir := os.Getenv("GQLCLI_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gqlcli", "cache")
+}
+
+// NewSchemaCache creates a SchemaCache rooted at dir.
+// An empty dir uses DefaultCacheDir.
+func NewSchemaCache(dir string) *SchemaCache {
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	return &SchemaCache{dir: dir}
+}
+
+// EndpointDir returns the directory holding the cache for url.
+func (s *SchemaCache) EndpointDir(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8]))
+}
+
+// SaveSchema stores the introspection response for url along with the derived
+// root type names and scalar examples.
+func (s *SchemaCache) SaveSchema(url string, result map[string]interface{}) error {
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid introspection response")
+	}
+
+	roots := map[string]string{}
+	for key, label := range map[string]string{"queryType": "query", "mutationType": "mutation", "subscriptionType": "subscription"} {
+		if t, ok := schema[key].(map[string]interface{}); ok {
+			if name, _ := t["name"].(string); name != "" {
+				roots[label] = name
+			}
+		}
+	}
+
+	scalars := map[string]interface{}{}
+	// A fixed seed keeps scalars.json stable across warms of the same schema.
+	rnd := rand.New(rand.NewSource(1))
+	types, _ := schema["types"].([]interface{})
+	for _, t := range types {
+		tm, ok := t.(map[string]interface{})
+		if !ok || tm["kind"] != "SCALAR" {
+			continue
+		}
+		name, _ := tm["name"].(string)
+		scalars[name] = exampleValue(FieldInfo{Type: name}, rnd)
+	}
+
+	if err := s.writeJSON(url, "schema.json", result); err != nil {
+		return err
+	}
+	if err := s.writeJSON(url, "roots.json", roots); err != nil {
+		return err
+	}
+	return s.writeJSON(url, "scalars.json", scalars)
+}
+
+// LoadSchema returns the cached introspection response for url.
+func (s *SchemaCache) LoadSchema(url string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := s.readJSON(url, "schema.json", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadRoots returns the cached root operation type names keyed by operation kind.
+func (s *SchemaCache) LoadRoots(url string) (map[string]string, error) {
+	var roots map[string]string
+	if err := s.readJSON(url, "roots.json", &roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// LoadScalarExamples returns the cached example value for each scalar.
+func (s *SchemaCache) LoadScalarExamples(url string) (map[string]interface{}, error) {
+	var scalars map[string]interface{}
+	if err := s.readJSON(url, "scalars.json", &scalars); err != nil {
+		return nil, err
+	}
+	return scalars, nil
+}
+
+// graphQLName matches a GraphQL name. Type names come from server responses
+// and error text, so they are checked against it before they become paths.
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// typeEntry returns the cache entry name for typeName's Describer entry.
+func typeEntry(typeName string) (string, error) {
+	if !graphQLName.MatchString(typeName) {
+		return "", fmt.Errorf("invalid type name %q", typeName)
+	}
+	return filepath.Join("types", typeName+".json"), nil
+}
+
+// SaveType stores a Describer entry for typeName.
+func (s *SchemaCache) SaveType(url, typeName string, typeInfo map[string]interface{}) error {
+	name, err := typeEntry(typeName)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(url, name, typeInfo)
+}
+
+// LoadType returns the cached Describer entry for typeName.
+func (s *SchemaCache) LoadType(url, typeName string) (map[string]interface{}, error) {
+	name, err := typeEntry(typeName)
+	if err != nil {
+		return nil, err
+	}
+	var typeInfo map[string]interface{}
+	if err := s.readJSON(url, name, &typeInfo); err != nil {
+		return nil, err
+	}
+	return typeInfo, nil
+}
+
+// fresh reports whether the cache entry name for url was written less than
+// ttl ago. A ttl of 0 or less means entries never expire.
+func (s *SchemaCache) fresh(url, name string, ttl time.Duration) bool {
+	info, err := os.Stat(filepath.Join(s.EndpointDir(url), name))
+	if err != nil {
+		return false
+	}
+	return ttl <= 0 || time.Since(info.ModTime()) < ttl
+}
+
+// SaveResult stores the result of the operation identified by key.
+func (s *SchemaCache) SaveResult(url, key string, result map[string]interface{}) error {
+	return s.writeJSON(url, filepath.Join("results", key+".json"), result)
+}
+
+// LoadResult returns the result stored for key by SaveResult.
+func (s *SchemaCache) LoadResult(url, key string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := s.readJSON(url, filepath.Join("results", key+".json"), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Entries lists the cached files for url sorted by name.
+func (s *SchemaCache) Entries(url string) ([]CacheEntry, error) {
+	root := s.EndpointDir(url)
+	var entries []CacheEntry
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		entries = append(entries, CacheEntry{Name: filepath.ToSlash(rel), Size: info.Size()})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list cache: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (s *SchemaCache) writeJSON(url, name string, v interface{}) error {
+	path := filepath.Join(s.EndpointDir(url), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %s: %w", name, err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path with mode 0600 through a uniquely named
+// temp file in the same directory and a rename, so readers never see a
+// partial file and concurrent writers never share a temp file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *SchemaCache) readJSON(url, name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.EndpointDir(url), name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("cache entry %s not found for %s", filepath.ToSlash(name), url)
+		}
+		return fmt.Errorf("failed to read cache entry %s: %w", name, err)
+	}
+	if err := decodeJSON(data, v); err != nil {
+		return fmt.Errorf("corrupt cache entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// scalarExample returns a placeholder value for a scalar type.
+func scalarExample(name string) interface{} {
+	switch name {
+	case "Int":
+		return 0
+	case "Float":
+		return 0.0
+	case "Boolean":
+		return true
+	case "String":
+		return "string"
+	case "ID":
+		return "id"
+	default:
+		return "<" + strings.ToLower(name) + ">"
+	}
+}
@@ -0,0 +1,148 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Catalog describes the commands and flags a binary actually registers. It is
+// built from the live cli.App, so it always matches the running build.
+type Catalog struct {
+	Version  string           `json:"version"`
+	Commands []CatalogCommand `json:"commands"`
+}
+
+// CatalogCommand is one command in a Catalog.
+type CatalogCommand struct {
+	Path  string   `json:"path"` // e.g. "endpoints list"
+	Usage string   `json:"usage,omitempty"`
+	Flags []string `json:"flags,omitempty"` // primary names, without dashes
+}
+
+// BuildCatalog walks app's commands (skipping help and hidden ones).
+func BuildCatalog(app *cli.App) Catalog {
+	cat := Catalog{Version: app.Version}
+	var walk func(prefix string, cmds []*cli.Command)
+	walk = func(prefix string, cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			if cmd.Hidden || cmd.Name == "help" {
+				continue
+			}
+			path := strings.TrimSpace(prefix + " " + cmd.Name)
+			entry := CatalogCommand{Path: path, Usage: cmd.Usage}
+			for _, f := range cmd.Flags {
+				if name := f.Names()[0]; name != "help" {
+					entry.Flags = append(entry.Flags, name)
+				}
+			}
+			cat.Commands = append(cat.Commands, entry)
+			walk(path, cmd.Subcommands)
+		}
+	}
+	walk("", app.Commands)
+	sort.Slice(cat.Commands, func(i, j int) bool { return cat.Commands[i].Path < cat.Commands[j].Path })
+	return cat
+}
+
+// Command returns the entry for path, or nil.
+func (cat Catalog) Command(path string) *CatalogCommand {
+	for i := range cat.Commands {
+		if cat.Commands[i].Path == path {
+			return &cat.Commands[i]
+		}
+	}
+	return nil
+}
+
+// HasFlag reports whether any command defines the flag name.
+func (cat Catalog) HasFlag(name string) bool {
+	for _, cmd := range cat.Commands {
+		for _, f := range cmd.Flags {
+			if f == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// capabilityFeatures are the optional features reported by the capabilities
+// command. Each is detected from the catalog, so a build (or an embedder that
+// mounts only some commands) reports what it really has.
+var capabilityFeatures = []struct {
+	name    string
+	present func(Catalog) bool
+}{
+	{"subscriptions", func(cat Catalog) bool { return cat.Command("subscribe") != nil || cat.HasFlag("subscription") }},
+	{"apq", func(cat Catalog) bool { return cat.HasFlag("apq") || cat.HasFlag("persisted-query") }},
+	{"pagination", func(cat Catalog) bool { return cat.HasFlag("paginate") }},
+	{"variableFlags", func(cat Catalog) bool { return cat.HasFlag("var") }},
+	{"strictVariables", func(cat Catalog) bool { return cat.HasFlag("strict-variables") }},
+	{"postResult", func(cat Catalog) bool { return cat.HasFlag("post-result") }},
+	{"schemaCache", func(cat Catalog) bool { return cat.Command("warm") != nil }},
+	{"schemaDiff", func(cat Catalog) bool { return cat.Command("schema-diff") != nil }},
+	{"resultDiff", func(cat Catalog) bool { return cat.HasFlag("diff-prev") || cat.HasFlag("compare-url") }},
+	{"browse", func(cat Catalog) bool { return cat.Command("browse") != nil }},
+	{"savedOperations", func(cat Catalog) bool { return cat.Command("ops") != nil }},
+	{"pipe", func(cat Catalog) bool { return cat.Command("pipe") != nil }},
+	{"followRedirects", func(cat Catalog) bool { return cat.HasFlag("follow-redirects") }},
+	{"serverDryRun", func(cat Catalog) bool { return cat.HasFlag("server-dry-run") }},
+	{"selfUpdate", func(cat Catalog) bool { return cat.Command("self-update") != nil }},
+	{"historyTail", func(cat Catalog) bool { return cat.Command("history tail") != nil }},
+}
+
+// capabilities returns the machine-readable feature map for app.
+func capabilities(app *cli.App, formats FormatterRegistry) map[string]interface{} {
+	cat := BuildCatalog(app)
+	features := make(map[string]bool, len(capabilityFeatures))
+	for _, f := range capabilityFeatures {
+		features[f.name] = f.present(cat)
+	}
+	commands := make(map[string][]string, len(cat.Commands))
+	for _, cmd := range cat.Commands {
+		flags := cmd.Flags
+		if flags == nil {
+			flags = []string{}
+		}
+		commands[cmd.Path] = flags
+	}
+	return map[string]interface{}{
+		"version":  cat.Version,
+		"formats":  formats.List(),
+		"features": features,
+		"commands": commands,
+	}
+}
+
+// GetCapabilitiesCommand returns the capabilities command, which prints the
+// features, formats, and flags of this build as JSON.
+func (b *CLIBuilder) GetCapabilitiesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "capabilities",
+		Usage: "Print the features, formats, and flags this build supports as JSON",
+		Description: "Agents and scripts can check a flag or feature here before using it, " +
+			"instead of assuming the newest gqlcli.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "pretty", Aliases: []string{"p"}, Usage: "Indent the JSON output"},
+		},
+		Action: func(c *cli.Context) error {
+			caps := capabilities(c.App, b.formatReg)
+			var out []byte
+			var err error
+			if c.Bool("pretty") {
+				out, err = json.MarshalIndent(caps, "", "  ")
+			} else {
+				out, err = json.Marshal(caps)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encode capabilities: %w", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+}
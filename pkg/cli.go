@@ -2,7 +2,6 @@ package gqlcli
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -16,6 +15,8 @@ type CLIBuilder struct {
 	client    Client
 	config    *Config
 	formatReg FormatterRegistry
+	usage     func(UsageEvent)
+	history   string
 }
 
 // NewCLIBuilder creates a new CLI command builder
@@ -37,26 +38,46 @@ func (b *CLIBuilder) GetQueryCommand() *cli.Command {
 		Aliases: []string{"q"},
 		Usage:   "Execute a GraphQL query",
 		Description: "Execute a read-only GraphQL query against the endpoint. " +
-			"Query can come from --query flag, --query-file, or as the first argument. " +
-			"Variables can be provided via --variables (inline JSON) or --variables-file.",
-		Flags: b.getOperationFlags(),
+			"Query can come from --query flag, --query-file, a saved operation (--op), or as the first argument. " +
+			"Variables can be provided via --variables (inline JSON) or --variables-file. " +
+			"--diff-prev and --compare-url print how the result differs instead of the result.",
+		Flags: append(b.getOperationFlags(), resultDiffFlags()...),
 		Action: func(c *cli.Context) error {
+			if err := checkFormats(c, b.formatReg); err != nil {
+				return err
+			}
+			if err := checkResultDiffFlags(c); err != nil {
+				return err
+			}
+
 			// Update config with command-line flags
 			b.config.URL = c.String("url")
 			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
+			b.config.PersistedQueries = c.Bool("apq")
+			b.applyReadOnly(c)
+			httpClient := NewHTTPClient(b.config)
+			b.client = httpClient
+
+			if err := b.checkEndpointIdentity(context.Background(), httpClient, false, c.Bool("accept-endpoint-change")); err != nil {
+				return err
+			}
 
 			// Get query from various sources
 			query, err := b.getQueryString(c)
 			if err != nil {
 				return err
 			}
+			noteUsageOperation(c, query, c.String("operation"))
+			noteUsageEndpoint(c, b.config.URL)
 
 			// Parse variables
-			variables, err := b.getVariables(c)
+			variables, err := b.getVariables(c, query)
 			if err != nil {
 				return err
 			}
+			if err := checkVariables(c, variables, query, c.String("operation")); err != nil {
+				return err
+			}
 
 			// Execute query
 			opts := QueryOptions{
@@ -66,12 +87,26 @@ func (b *CLIBuilder) GetQueryCommand() *cli.Command {
 			}
 
 			result, err := b.client.Execute(context.Background(), ExecutionModeHTTP, opts)
+			if diffingResults(c) {
+				var gqlErr *GraphQLResponseError
+				if err != nil && !errors.As(err, &gqlErr) {
+					return err
+				}
+				return b.printResultDiff(c, opts, result)
+			}
 			if err != nil {
 				return b.handleError(c, err)
 			}
 
-			// Format and output
-			return b.outputResult(c, result)
+			// Format and output, then deliver to any --post-result sinks
+			output, err := b.renderResult(c, result)
+			if err != nil {
+				return err
+			}
+			if err := b.writeOutput(c, output); err != nil {
+				return err
+			}
+			return postResult(c, output, result)
 		},
 	}
 }
@@ -83,7 +118,7 @@ func (b *CLIBuilder) GetMutationCommand() *cli.Command {
 		Aliases: []string{"m"},
 		Usage:   "Execute a GraphQL mutation",
 		Description: "Execute a write operation (mutation) against the endpoint. " +
-			"Mutation can come from --mutation flag, --mutation-file, or as the first argument. " +
+			"Mutation can come from --mutation flag, --mutation-file, a saved operation (--op), or as the first argument. " +
 			"Variables can be provided via --variables or --variables-file. " +
 			"Use --input to auto-wrap input as {\"input\": {...}}.",
 		Flags: append(b.getOperationFlags(),
@@ -91,21 +126,54 @@ func (b *CLIBuilder) GetMutationCommand() *cli.Command {
 				Name:  "input",
 				Usage: "Input object as JSON - automatically wrapped as {\"input\":{...}} variable",
 			},
+			&cli.BoolFlag{
+				Name:  "retry-mutations",
+				Usage: "Let rate-limit and auth-refresh retries re-send the mutation even when the failed response carried data (it may have run)",
+				Value: b.config.RetryMutations,
+			},
+			&cli.BoolFlag{
+				Name:  "follow-redirects",
+				Usage: "Re-send the mutation if the endpoint redirects (queries are always re-sent)",
+			},
+			&cli.BoolFlag{
+				Name: "server-dry-run",
+				Usage: "Set the server's dry-run flag (a $" + dryRunField(b.config) + " variable, or that field on the " +
+					"mutation's input) so nothing is persisted; refuses to run if the schema has no such field",
+			},
 		),
+		Hidden: b.config.ReadOnly,
 		Action: func(c *cli.Context) error {
+			b.applyReadOnly(c)
+			if b.config.ReadOnly {
+				return &ReadOnlyError{Source: readOnlySource(b.config), Reason: "the mutation command is disabled"}
+			}
+			if err := checkFormats(c, b.formatReg); err != nil {
+				return err
+			}
+
 			// Update config with command-line flags
 			b.config.URL = c.String("url")
 			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
+			b.config.FollowRedirects = c.Bool("follow-redirects")
+			b.config.RetryMutations = c.Bool("retry-mutations")
+			b.config.PersistedQueries = c.Bool("apq")
+			httpClient := NewHTTPClient(b.config)
+			b.client = httpClient
+
+			if err := b.checkEndpointIdentity(context.Background(), httpClient, true, c.Bool("accept-endpoint-change")); err != nil {
+				return err
+			}
 
 			// Get mutation from various sources
 			mutation, err := b.getMutationString(c)
 			if err != nil {
 				return err
 			}
+			noteUsageOperation(c, mutation, c.String("operation"))
+			noteUsageEndpoint(c, b.config.URL)
 
 			// Parse variables
-			variables, err := b.getVariables(c)
+			variables, err := b.getVariables(c, mutation)
 			if err != nil {
 				return err
 			}
@@ -113,10 +181,32 @@ func (b *CLIBuilder) GetMutationCommand() *cli.Command {
 			// Parse input if provided
 			var input interface{}
 			if inputStr := c.String("input"); inputStr != "" {
-				if err := json.Unmarshal([]byte(inputStr), &input); err != nil {
+				if err := decodeJSON([]byte(inputStr), &input); err != nil {
 					return fmt.Errorf("invalid input JSON: %w", err)
 				}
 			}
+			if input != nil {
+				if variables == nil {
+					variables = map[string]interface{}{}
+				}
+				variables["input"], input = input, nil
+			}
+			if err := checkVariables(c, variables, mutation, c.String("operation")); err != nil {
+				return err
+			}
+
+			var dryRun []string
+			if c.Bool("server-dry-run") {
+				if variables == nil {
+					variables = map[string]interface{}{}
+				}
+				dryRun, err = applyServerDryRun(context.Background(), NewDescriberFromHTTPClient(httpClient),
+					mutation, c.String("operation"), dryRunField(b.config), variables)
+				if err != nil {
+					return err
+				}
+				noteUsageDryRun(c)
+			}
 
 			// Execute mutation
 			opts := MutationOptions{
@@ -127,12 +217,23 @@ func (b *CLIBuilder) GetMutationCommand() *cli.Command {
 			}
 
 			result, err := b.client.ExecuteMutation(context.Background(), ExecutionModeHTTP, opts)
+			if dryRun != nil {
+				fmt.Fprintf(os.Stderr, "DRY RUN: %s set to true; the server was asked not to persist anything\n", strings.Join(dryRun, ", "))
+				markDryRun(result, dryRun)
+			}
 			if err != nil {
 				return b.handleError(c, err)
 			}
 
-			// Format and output
-			return b.outputResult(c, result)
+			// Format and output, then deliver to any --post-result sinks
+			output, err := b.renderResult(c, result)
+			if err != nil {
+				return err
+			}
+			if err := b.writeOutput(c, output); err != nil {
+				return err
+			}
+			return postResult(c, output, result)
 		},
 	}
 }
@@ -145,25 +246,13 @@ func (b *CLIBuilder) GetIntrospectCommand() *cli.Command {
 		Usage:   "Generate GraphQL schema",
 		Description: "Output the GraphQL schema in various formats. " +
 			"Default format is 'llm' (human and LLM-friendly). " +
-			"Use 'json' for full introspection data, or 'compact' for minimal output.",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "url",
-				Aliases: []string{"u"},
-				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
-				Value:   b.config.URL,
-				EnvVars: []string{"GRAPHQL_URL"},
-			},
-			&cli.BoolFlag{
-				Name:    "debug",
-				Aliases: []string{"d"},
-				Usage:   "Enable debug mode (logs HTTP requests/responses)",
-				Value:   b.config.Debug,
-			},
+			"Use 'json' for full introspection data, or 'compact' for minimal output. " +
+			"--cached reads the schema cache (see warm) instead of introspecting.",
+		Flags: append(b.schemaSourceFlags(),
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Usage:   "Output format: llm (default), json, compact",
+				Usage:   "Output format: llm (default), sdl, json, compact, csv",
 				Value:   "llm",
 			},
 			&cli.StringFlag{
@@ -171,24 +260,65 @@ func (b *CLIBuilder) GetIntrospectCommand() *cli.Command {
 				Aliases: []string{"o"},
 				Usage:   "Output file path (default: stdout)",
 			},
+			&cli.StringFlag{
+				Name:  "output-dir",
+				Usage: "Write csv output to this directory as types.csv, fields.csv, and args.csv",
+			},
 			&cli.BoolFlag{
 				Name:    "pretty",
 				Aliases: []string{"p"},
 				Usage:   "Pretty print JSON output (only for json format)",
 				Value:   false,
 			},
-		},
+			&cli.StringFlag{
+				Name:  "split-output",
+				Usage: "Write llm or sdl output to this directory as chunk files plus manifest.json and index.md",
+			},
+			&cli.IntFlag{
+				Name:  "chunk-size",
+				Usage: "Maximum characters of type definitions per chunk (with --split-output)",
+				Value: 80000,
+			},
+		),
 		Action: func(c *cli.Context) error {
-			// Update config with command-line flags
-			b.config.URL = c.String("url")
-			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
+			b.applySchemaSourceFlags(c)
 
-			result, err := b.client.Introspect(context.Background())
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
 			if err != nil {
 				return err
 			}
 
+			format := c.String("format")
+			if format == "csv" {
+				return b.writeSchemaCSV(c, result)
+			}
+			if c.String("output-dir") != "" {
+				return fmt.Errorf("--output-dir supports the csv format, not %q", format)
+			}
+			if dir := c.String("split-output"); dir != "" {
+				if format != "llm" && format != "sdl" {
+					return fmt.Errorf("--split-output supports the llm and sdl formats, not %q", format)
+				}
+				blocks, err := renderSchemaBlocks(result, format)
+				if err != nil {
+					return err
+				}
+				manifest, err := writeSchemaChunks(dir, blocks, format, c.Int("chunk-size"))
+				if err != nil {
+					return err
+				}
+				fmt.Printf("wrote %d chunks (%d types) to %s\n", len(manifest.Chunks), len(blocks), dir)
+				return nil
+			}
+
+			if format == "sdl" {
+				blocks, err := renderSchemaBlocks(result, format)
+				if err != nil {
+					return err
+				}
+				return b.writeOutput(c, joinSchemaBlocks(blocks))
+			}
+
 			// Extract schema from response (unwrap the data field)
 			var schema interface{} = result
 			if data, ok := result["data"]; ok {
@@ -196,7 +326,7 @@ func (b *CLIBuilder) GetIntrospectCommand() *cli.Command {
 			}
 
 			// Format result
-			formatter, err := b.formatReg.Get(c.String("format"))
+			formatter, err := b.formatReg.Get(format)
 			if err != nil {
 				return err
 			}
@@ -206,13 +336,7 @@ func (b *CLIBuilder) GetIntrospectCommand() *cli.Command {
 				return err
 			}
 
-			// Write output
-			if outputFile := c.String("output"); outputFile != "" {
-				return os.WriteFile(outputFile, []byte(output), 0644)
-			}
-
-			fmt.Println(output)
-			return nil
+			return b.writeOutput(c, output)
 		},
 	}
 }
@@ -223,21 +347,9 @@ func (b *CLIBuilder) GetTypesCommand() *cli.Command {
 		Name:  "types",
 		Usage: "List all GraphQL types",
 		Description: "Display all available GraphQL types in the schema. " +
-			"Optionally filter by name or type kind.",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "url",
-				Aliases: []string{"u"},
-				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
-				Value:   b.config.URL,
-				EnvVars: []string{"GRAPHQL_URL"},
-			},
-			&cli.BoolFlag{
-				Name:    "debug",
-				Aliases: []string{"d"},
-				Usage:   "Enable debug mode (logs HTTP requests/responses)",
-				Value:   b.config.Debug,
-			},
+			"Optionally filter by name or type kind. " +
+			"--cached reads the schema cache (see warm) instead of introspecting.",
+		Flags: append(b.schemaSourceFlags(),
 			&cli.StringFlag{
 				Name:  "filter",
 				Usage: "Filter types by name (case-insensitive substring match)",
@@ -253,14 +365,11 @@ func (b *CLIBuilder) GetTypesCommand() *cli.Command {
 				Usage:   "Output format: compact (default), json, table",
 				Value:   "compact",
 			},
-		},
+		),
 		Action: func(c *cli.Context) error {
-			// Update config with command-line flags
-			b.config.URL = c.String("url")
-			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
+			b.applySchemaSourceFlags(c)
 
-			result, err := b.client.Introspect(context.Background())
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
 			if err != nil {
 				return err
 			}
@@ -308,21 +417,9 @@ func (b *CLIBuilder) GetQueriesCommand() *cli.Command {
 		Usage:   "List all available Query fields",
 		Description: "Display all available GraphQL Query fields. " +
 			"Optionally include descriptions and arguments. " +
-			"Use --filter to search by field name.",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "url",
-				Aliases: []string{"u"},
-				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
-				Value:   b.config.URL,
-				EnvVars: []string{"GRAPHQL_URL"},
-			},
-			&cli.BoolFlag{
-				Name:    "debug",
-				Aliases: []string{"d"},
-				Usage:   "Enable debug mode (logs HTTP requests/responses)",
-				Value:   b.config.Debug,
-			},
+			"Use --filter to search by field name. " +
+			"--cached reads the schema cache (see warm) instead of introspecting.",
+		Flags: append(b.schemaSourceFlags(),
 			&cli.BoolFlag{
 				Name:  "desc",
 				Usage: "Include field descriptions",
@@ -341,24 +438,17 @@ func (b *CLIBuilder) GetQueriesCommand() *cli.Command {
 				Usage:   "Output format: toon (default), json, json-pretty, table, compact, llm",
 				Value:   "toon",
 			},
-		},
+		),
 		Action: func(c *cli.Context) error {
-			// Update config with command-line flags
-			b.config.URL = c.String("url")
-			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
+			b.applySchemaSourceFlags(c)
 
-			// Build and execute introspection query
-			query := buildOperationListQuery("Query", c.Bool("desc"), c.Bool("args"))
-			opts := QueryOptions{Query: query}
-
-			result, err := b.client.Execute(context.Background(), ExecutionModeHTTP, opts)
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
 			if err != nil {
 				return err
 			}
 
-			// Extract fields from response
-			fields, err := extractOperationFields(result)
+			// Extract fields from the root type
+			fields, err := extractOperationFields(result, "query", c.Bool("desc"), c.Bool("args"))
 			if err != nil {
 				return err
 			}
@@ -395,21 +485,9 @@ func (b *CLIBuilder) GetMutationsCommand() *cli.Command {
 		Usage:   "List all available Mutation fields",
 		Description: "Display all available GraphQL Mutation fields. " +
 			"Optionally include descriptions and arguments. " +
-			"Use --filter to search by field name.",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "url",
-				Aliases: []string{"u"},
-				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
-				Value:   b.config.URL,
-				EnvVars: []string{"GRAPHQL_URL"},
-			},
-			&cli.BoolFlag{
-				Name:    "debug",
-				Aliases: []string{"d"},
-				Usage:   "Enable debug mode (logs HTTP requests/responses)",
-				Value:   b.config.Debug,
-			},
+			"Use --filter to search by field name. " +
+			"--cached reads the schema cache (see warm) instead of introspecting.",
+		Flags: append(b.schemaSourceFlags(),
 			&cli.BoolFlag{
 				Name:  "desc",
 				Usage: "Include field descriptions",
@@ -428,24 +506,17 @@ func (b *CLIBuilder) GetMutationsCommand() *cli.Command {
 				Usage:   "Output format: toon (default), json, json-pretty, table, compact, llm",
 				Value:   "toon",
 			},
-		},
+		),
 		Action: func(c *cli.Context) error {
-			// Update config with command-line flags
-			b.config.URL = c.String("url")
-			b.config.Debug = c.Bool("debug")
-			b.client = NewHTTPClient(b.config)
-
-			// Build and execute introspection query
-			query := buildOperationListQuery("Mutation", c.Bool("desc"), c.Bool("args"))
-			opts := QueryOptions{Query: query}
+			b.applySchemaSourceFlags(c)
 
-			result, err := b.client.Execute(context.Background(), ExecutionModeHTTP, opts)
+			result, err := b.loadSchema(context.Background(), c.Bool("cached"))
 			if err != nil {
 				return err
 			}
 
-			// Extract fields from response
-			fields, err := extractOperationFields(result)
+			// Extract fields from the root type
+			fields, err := extractOperationFields(result, "mutation", c.Bool("desc"), c.Bool("args"))
 			if err != nil {
 				return err
 			}
@@ -474,23 +545,137 @@ func (b *CLIBuilder) GetMutationsCommand() *cli.Command {
 	}
 }
 
+// GetSchemaDiffCommand returns the schema-diff command
+func (b *CLIBuilder) GetSchemaDiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "schema-diff",
+		Usage: "Compare the schema of two GraphQL endpoints",
+		Description: "Introspect --url and compare it against --against (another endpoint) or " +
+			"--against-file (a saved 'introspect --format json' output). " +
+			"Types, fields, and arguments are matched by name so reordering is not reported.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "url",
+				Aliases: []string{"u"},
+				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
+				Value:   b.config.URL,
+				EnvVars: []string{"GRAPHQL_URL"},
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				Aliases: []string{"d"},
+				Usage:   "Enable debug mode (logs HTTP requests/responses)",
+				Value:   b.config.Debug,
+			},
+			&cli.StringFlag{
+				Name:  "against",
+				Usage: "GraphQL endpoint URL to compare against",
+			},
+			&cli.StringFlag{
+				Name:  "against-file",
+				Usage: "Introspection JSON file to compare against",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Diff format: text (default), markdown, json-patch",
+				Value:   "text",
+			},
+			&cli.IntFlag{
+				Name:  "context",
+				Usage: "Unchanged siblings shown around each change (-1 shows all)",
+				Value: 2,
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Disable colored output",
+			},
+			&cli.BoolFlag{
+				Name:  "accessible",
+				Usage: "Screen-reader-friendly output (implies --no-color)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			b.config.URL = c.String("url")
+			b.config.Debug = c.Bool("debug")
+			b.client = NewHTTPClient(b.config)
+
+			newSchema, err := b.client.Introspect(context.Background())
+			if err != nil {
+				return err
+			}
+
+			var oldSchema map[string]interface{}
+			switch {
+			case c.String("against-file") != "":
+				data, err := os.ReadFile(c.String("against-file"))
+				if err != nil {
+					return fmt.Errorf("failed to read schema file: %w", err)
+				}
+				if err := decodeJSON(data, &oldSchema); err != nil {
+					return fmt.Errorf("invalid schema JSON in file: %w", err)
+				}
+			case c.String("against") != "":
+				cfg := *b.config
+				cfg.URL = c.String("against")
+				oldSchema, err = NewHTTPClient(&cfg).Introspect(context.Background())
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("nothing to compare against (use --against or --against-file)")
+			}
+
+			out, err := RenderDiff(unwrapSchema(oldSchema), unwrapSchema(newSchema), DiffOptions{
+				Format:      c.String("format"),
+				Color:       !c.Bool("no-color") && !c.Bool("accessible") && isTerminal(os.Stdout),
+				Context:     c.Int("context"),
+				KeyArraysBy: "name",
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	}
+}
+
 // RegisterCommands returns all CLI commands for the app
+// and adds the global --read-only flag.
 func (b *CLIBuilder) RegisterCommands(app *cli.App) {
-	app.Commands = append(app.Commands,
+	app.Flags = append(app.Flags, readOnlyFlag)
+	usage := usageRecorders(b.usage, b.history)
+	app.Commands = append(app.Commands, withUsage([]*cli.Command{
 		b.GetQueryCommand(),
 		b.GetMutationCommand(),
 		b.GetIntrospectCommand(),
 		b.GetTypesCommand(),
 		b.GetQueriesCommand(),
 		b.GetMutationsCommand(),
+		b.GetSchemaDiffCommand(),
+		b.GetWarmCommand(),
+		b.GetEndpointsCommand(),
+		b.GetOpsCommand(),
+		b.GetPipeCommand(),
+		b.GetBrowseCommand(),
+		b.GetSearchCommand(),
+		b.GetExamplesCommand(),
+		b.GetCapabilitiesCommand(),
 		b.GetInstallSkillCommand(),
-	)
+	}, usage)...)
+	if b.config.ReleaseRepo != "" {
+		app.Commands = append(app.Commands, withUsage([]*cli.Command{b.GetSelfUpdateCommand()}, usage)...)
+	}
+	if b.history != "" {
+		app.Commands = append(app.Commands, historyCommand(b.history))
+	}
 }
 
 // Helper methods
 
 func (b *CLIBuilder) getOperationFlags() []cli.Flag {
-	return []cli.Flag{
+	flags := []cli.Flag{
 		&cli.StringFlag{
 			Name:    "url",
 			Aliases: []string{"u"},
@@ -525,6 +710,11 @@ func (b *CLIBuilder) getOperationFlags() []cli.Flag {
 			Name:    "mutation-file",
 			Usage:   "Path to .graphql file containing mutation",
 		},
+		&cli.StringFlag{
+			Name:  "op",
+			Usage: "Run the operation saved under this name (see ops save)",
+		},
+		opsFileFlag(),
 		&cli.StringFlag{
 			Name:    "variables",
 			Aliases: []string{"v"},
@@ -556,10 +746,36 @@ func (b *CLIBuilder) getOperationFlags() []cli.Flag {
 			Name:  "output",
 			Usage: "Output file path (default: stdout)",
 		},
+		&cli.BoolFlag{
+			Name:  "accessible",
+			Usage: "Screen-reader-friendly output: same as --format plain with no color or decorations",
+		},
+		&cli.StringFlag{
+			Name:    "format-fallback",
+			Usage:   "Format to use if the chosen formatter fails (default: fail)",
+			EnvVars: []string{"GQLCLI_FORMAT_FALLBACK"},
+		},
+		&cli.BoolFlag{
+			Name:    "apq",
+			Usage:   "Send the operation as an automatic persisted query hash, with the full text only if the server doesn't know it",
+			Value:   b.config.PersistedQueries,
+			EnvVars: []string{"GQLCLI_APQ"},
+		},
+		&cli.BoolFlag{
+			Name:  "accept-endpoint-change",
+			Usage: "Proceed (and record the new identity) when the endpoint's schema changed drastically since last use",
+		},
 	}
+	flags = append(flags, varFlags()...)
+	flags = append(flags, sampleFlags()...)
+	return append(flags, postResultFlags()...)
 }
 
 func (b *CLIBuilder) getQueryString(c *cli.Context) (string, error) {
+	if saved, err := savedOperationText(c); saved != "" || err != nil {
+		return saved, err
+	}
+
 	if queryFile := c.String("query-file"); queryFile != "" {
 		data, err := os.ReadFile(queryFile)
 		if err != nil {
@@ -576,10 +792,14 @@ func (b *CLIBuilder) getQueryString(c *cli.Context) (string, error) {
 		return c.Args().First(), nil
 	}
 
-	return "", fmt.Errorf("query is required (use --query, --query-file, or provide as argument)")
+	return "", fmt.Errorf("query is required (use --query, --query-file, --op, or provide as argument)")
 }
 
 func (b *CLIBuilder) getMutationString(c *cli.Context) (string, error) {
+	if saved, err := savedOperationText(c); saved != "" || err != nil {
+		return saved, err
+	}
+
 	if mutationFile := c.String("mutation-file"); mutationFile != "" {
 		data, err := os.ReadFile(mutationFile)
 		if err != nil {
@@ -596,10 +816,12 @@ func (b *CLIBuilder) getMutationString(c *cli.Context) (string, error) {
 		return c.Args().First(), nil
 	}
 
-	return "", fmt.Errorf("mutation is required (use --mutation, --mutation-file, or provide as argument)")
+	return "", fmt.Errorf("mutation is required (use --mutation, --mutation-file, --op, or provide as argument)")
 }
 
-func (b *CLIBuilder) getVariables(c *cli.Context) (map[string]interface{}, error) {
+// getVariables reads --variables-file or --variables, then applies --var and
+// --var-file-lines on top using the variable types declared in query.
+func (b *CLIBuilder) getVariables(c *cli.Context, query string) (map[string]interface{}, error) {
 	var variables map[string]interface{}
 
 	if varFile := c.String("variables-file"); varFile != "" {
@@ -607,20 +829,16 @@ func (b *CLIBuilder) getVariables(c *cli.Context) (map[string]interface{}, error
 		if err != nil {
 			return nil, fmt.Errorf("failed to read variables file: %w", err)
 		}
-		if err := json.Unmarshal(data, &variables); err != nil {
+		if err := decodeJSON(data, &variables); err != nil {
 			return nil, fmt.Errorf("invalid variables JSON in file: %w", err)
 		}
-		return variables, nil
-	}
-
-	if varStr := c.String("variables"); varStr != "" {
-		if err := json.Unmarshal([]byte(varStr), &variables); err != nil {
+	} else if varStr := c.String("variables"); varStr != "" {
+		if err := decodeJSON([]byte(varStr), &variables); err != nil {
 			return nil, fmt.Errorf("invalid variables JSON: %w", err)
 		}
-		return variables, nil
 	}
 
-	return nil, nil
+	return applyVarFlags(c, variables, query, c.String("operation"))
 }
 
 // handleError checks whether err is a *GraphQLResponseError and, if so, formats
@@ -631,27 +849,115 @@ func (b *CLIBuilder) handleError(c *cli.Context, err error) error {
 	if !errors.As(err, &gqlErr) {
 		return err
 	}
-	fmt.Fprintf(os.Stderr, "Query:\n%s\n\n", formatQueryForError(gqlErr.Query))
+	noteUsageError(c, "graphql")
+	if c.Bool("accessible") {
+		fmt.Fprintln(os.Stderr, plainLine("query", strings.TrimSpace(gqlErr.Query)))
+	} else {
+		fmt.Fprintf(os.Stderr, "Query:\n%s\n\n", formatQueryForError(gqlErr.Query))
+	}
 	_ = b.outputResult(c, gqlErr.Response)
 	return cli.Exit("", 1)
 }
 
 func (b *CLIBuilder) outputResult(c *cli.Context, result map[string]interface{}) error {
-	// Get formatter
-	formatName := c.String("format")
-	formatter, err := b.formatReg.Get(formatName)
+	output, err := b.renderResult(c, result)
 	if err != nil {
-		// Fallback to JSON if format not found
-		formatter, _ = b.formatReg.Get("json")
+		return err
 	}
+	return b.writeOutput(c, output)
+}
 
-	// Format result
-	output, err := formatter.Format(result)
+// renderResult formats result with the selected --format.
+func (b *CLIBuilder) renderResult(c *cli.Context, result map[string]interface{}) (string, error) {
+	formatName := selectedFormat(c)
+	notes, err := applySample(c, result)
+	if err != nil {
+		return "", err
+	}
+	output, err := formatResult(b.formatReg, formatName, c.String("format-fallback"), result)
 	if err != nil {
+		return "", err
+	}
+	return withSampleNotes(output, formatName, notes), nil
+}
+
+// selectedFormat returns the --format name, or plain under --accessible.
+func selectedFormat(c *cli.Context) string {
+	if c.Bool("accessible") {
+		return "plain"
+	}
+	return c.String("format")
+}
+
+// checkFormats looks up the selected format and --format-fallback in reg, so
+// a typo fails before the operation is sent rather than after it has run.
+func checkFormats(c *cli.Context, reg FormatterRegistry) error {
+	if _, err := reg.Get(selectedFormat(c)); err != nil {
 		return err
 	}
+	if fallback := c.String("format-fallback"); fallback != "" {
+		if _, err := reg.Get(fallback); err != nil {
+			return fmt.Errorf("--format-fallback: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Write to file or stdout
+// formatResult formats result with the named formatter. An unknown name is an
+// error listing the valid names. When the formatter itself fails, the failure is
+// reported on stderr and the result is formatted with fallback instead — but
+// only if a fallback was configured; otherwise the failure is returned.
+func formatResult(reg FormatterRegistry, name, fallback string, result map[string]interface{}) (string, error) {
+	formatter, err := reg.Get(name)
+	if err != nil {
+		return "", err
+	}
+	var fallbackFormatter Formatter
+	if fallback != "" {
+		if fallbackFormatter, err = reg.Get(fallback); err != nil {
+			return "", fmt.Errorf("--format-fallback: %w", err)
+		}
+	}
+
+	out, err := formatter.Format(result)
+	if err == nil {
+		return out, nil
+	}
+	if fallbackFormatter == nil || fallback == name {
+		return "", fmt.Errorf("%s formatter failed: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "warning: %s formatter failed: %v; falling back to %s\n", name, err, fallback)
+	return fallbackFormatter.Format(result)
+}
+
+// writeSchemaCSV writes the csv schema export: three files under --output-dir,
+// or one combined CSV to --output or stdout.
+func (b *CLIBuilder) writeSchemaCSV(c *cli.Context, result map[string]interface{}) error {
+	if c.String("split-output") != "" {
+		return fmt.Errorf("--split-output supports the llm and sdl formats, not \"csv\"; use --output-dir")
+	}
+	export, err := buildSchemaCSV(result)
+	if err != nil {
+		return err
+	}
+	dir := c.String("output-dir")
+	if dir == "" {
+		return b.writeOutput(c, export.combined())
+	}
+	if c.String("output") != "" {
+		return fmt.Errorf("use either --output (one combined CSV) or --output-dir (three CSVs), not both")
+	}
+	paths, err := export.writeDir(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (%d types, %d fields, %d args)\n", strings.Join(paths, ", "),
+		len(export.types), len(export.fields), len(export.args))
+	return nil
+}
+
+// writeOutput writes output to the --output file, or stdout when none is given.
+func (b *CLIBuilder) writeOutput(c *cli.Context, output string) error {
 	if outputFile := c.String("output"); outputFile != "" {
 		return os.WriteFile(outputFile, []byte(output), 0644)
 	}
@@ -660,68 +966,76 @@ func (b *CLIBuilder) outputResult(c *cli.Context, result map[string]interface{})
 	return nil
 }
 
-// buildOperationListQuery constructs an introspection query for Query or Mutation type
-func buildOperationListQuery(typeName string, includeDesc, includeArgs bool) string {
-	query := fmt.Sprintf(`
-	{
-		__type(name: "%s") {
-			fields {
-				name`, typeName)
-
-	if includeDesc {
-		query += `
-				description`
-	}
-
-	if includeArgs {
-		query += `
-				args {
-					name
-					type {
-						kind
-						name
-						ofType {
-							kind
-							name
-							ofType {
-								kind
-								name
-								ofType {
-									kind
-									name
-								}
-							}
-						}
-					}
-				}`
+// unwrapSchema returns the __schema object of an introspection response,
+// accepting both the full response and its data field.
+func unwrapSchema(result map[string]interface{}) interface{} {
+	if data, ok := result["data"].(map[string]interface{}); ok {
+		result = data
 	}
-
-	query += `
-			}
-		}
-	}`
-
-	return query
+	if schema, ok := result["__schema"]; ok {
+		return schema
+	}
+	return result
 }
 
-// extractOperationFields extracts the fields array from the introspection response
-func extractOperationFields(result map[string]interface{}) ([]interface{}, error) {
-	data, ok := result["data"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response structure: missing 'data' field")
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
 	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
 
-	typeInfo, ok := data["__type"].(map[string]interface{})
+// extractOperationFields returns the fields of the root type for kind (query or
+// mutation) from an introspection result, keeping the name plus, when asked,
+// the description and arguments of each.
+func extractOperationFields(result map[string]interface{}, kind string, includeDesc, includeArgs bool) ([]interface{}, error) {
+	schema, ok := unwrapSchema(result).(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid response structure: missing '__type' field")
+		return nil, fmt.Errorf("invalid introspection response")
 	}
-
-	fields, ok := typeInfo["fields"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response structure: missing 'fields' array")
+	root, _ := schema[kind+"Type"].(map[string]interface{})
+	rootName, _ := root["name"].(string)
+	if rootName == "" {
+		return nil, fmt.Errorf("schema has no %s type", kind)
 	}
 
-	return fields, nil
+	types, _ := schema["types"].([]interface{})
+	for _, t := range types {
+		tm, ok := t.(map[string]interface{})
+		if !ok || tm["name"] != rootName {
+			continue
+		}
+		rawFields, _ := tm["fields"].([]interface{})
+		fields := make([]interface{}, 0, len(rawFields))
+		for _, f := range rawFields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := map[string]interface{}{"name": fm["name"]}
+			if includeDesc {
+				field["description"] = fm["description"]
+			}
+			if includeArgs {
+				var args []interface{}
+				list, _ := fm["args"].([]interface{})
+				for _, a := range list {
+					if am, ok := a.(map[string]interface{}); ok {
+						args = append(args, map[string]interface{}{"name": am["name"], "type": am["type"]})
+					}
+				}
+				if args == nil {
+					args = []interface{}{}
+				}
+				field["args"] = args
+			}
+			fields = append(fields, field)
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("root type %q missing from schema", rootName)
 }
 
 // filterOperations filters operations by name using case-insensitive substring matching
@@ -0,0 +1,46 @@
+package gqlcli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTTPQueryCommand(t *testing.T) {
+	srv := newTestServer(t)
+	app := httpApp(t, srv.URL)
+	stdout, _, err := runApp(t, app, "query", "--url", srv.URL, "--format", "json", "{ bigNumber }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(stdout) != `{"data":{"bigNumber":9007199254740993}}` {
+		t.Errorf("got %s", stdout)
+	}
+}
+
+func TestUnknownFormatFailsBeforeSending(t *testing.T) {
+	for _, args := range [][]string{
+		{"--format", "bogus"},
+		{"--format", "json", "--format-fallback", "bogus"},
+	} {
+		srv := newTestServer(t)
+		app := httpApp(t, srv.URL)
+		mutation := append(append([]string{"mutation", "--url", srv.URL}, args...), `mutation { archiveBooks(ids: ["b1"]) }`)
+		_, _, err := runApp(t, app, mutation...)
+		if err == nil || !strings.Contains(err.Error(), `unknown format "bogus"`) {
+			t.Errorf("%v: got %v, want an unknown format error", args, err)
+		}
+		if n := srv.Requests(); n != 0 {
+			t.Errorf("%v: endpoint received %d requests; the mutation must not be sent", args, n)
+		}
+
+		inline, schema := inlineApp(t)
+		_, _, err = runApp(t, inline, append(append([]string{"mutation"}, args...), `mutation { archiveBooks(ids: ["b1"]) }`)...)
+		if err == nil || !strings.Contains(err.Error(), `unknown format "bogus"`) {
+			t.Errorf("inline %v: got %v, want an unknown format error", args, err)
+		}
+		after := execute(t, NewInlineExecutor(schema), `{ books { id } }`, nil)
+		if n := len(after["data"].(map[string]interface{})["books"].([]interface{})); n != 4 {
+			t.Errorf("inline %v: the mutation ran (%d books left)", args, n)
+		}
+	}
+}
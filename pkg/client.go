@@ -1,43 +1,38 @@
 package gqlcli
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"io"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
-var (
-	reErrOutputField   = regexp.MustCompile(`Cannot query field "[^"]+" on type "([^"]+)"`)
-	reErrInputField    = regexp.MustCompile(`Field "[^"]+" is not defined by type "([^"]+)"`)
-	reErrUnknownArg    = regexp.MustCompile(`Unknown argument "[^"]+" on field "([^.]+)\.[^"]+"`)
-	reErrNeedsSubfield = regexp.MustCompile(`Field "[^"]+" of type "([^"]+)" must have a selection of subfields`)
-)
-
-func extractTypeFromErrorMsg(msg string) string {
-	for _, re := range []*regexp.Regexp{reErrOutputField, reErrInputField, reErrUnknownArg} {
-		if m := re.FindStringSubmatch(msg); len(m) == 2 {
-			return m[1]
-		}
-	}
-	if m := reErrNeedsSubfield.FindStringSubmatch(msg); len(m) == 2 {
-		return strings.Trim(m[1], "[]!")
-	}
-	return ""
-}
-
 // HTTPClient is a GraphQL client that executes operations via HTTP
 type HTTPClient struct {
-	config    *Config
-	client    *resty.Client
-	describer *Describer
+	config *Config
+	client *resty.Client
+
+	// mu guards describer, warnedRedirect, and config.Token (which error-code
+	// handlers may refresh), so one client can run operations from several
+	// goroutines (e.g. pipe --concurrency).
+	mu             sync.Mutex
+	describer      *Describer
+	warnedRedirect string // last redirect target warned about
 }
 
 func (c *HTTPClient) getDescriber() *Describer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.describer == nil {
 		c.describer = NewDescriberFromHTTPClient(c)
 	}
@@ -51,18 +46,13 @@ func NewHTTPClient(cfg *Config) *HTTPClient {
 		timeout = 30 * time.Second
 	}
 
-	restClient := resty.New().SetTimeout(timeout)
+	restClient := resty.New().SetTimeout(timeout).SetRedirectPolicy(noRedirectPolicy)
 
 	// Enable debug mode if configured
 	if cfg.Debug {
 		restClient.SetDebug(true)
 	}
 
-	// Add auth if configured
-	if cfg.Token != "" {
-		restClient.SetHeader("Authorization", fmt.Sprintf("Bearer %s", cfg.Token))
-	}
-
 	return &HTTPClient{
 		config: cfg,
 		client: restClient,
@@ -96,61 +86,64 @@ func (c *HTTPClient) ExecuteMutation(ctx context.Context, mode ExecutionMode, op
 	return c.executeOperation(ctx, opts.Mutation, variables, opts.OperationName)
 }
 
-// Introspect queries the GraphQL schema
-func (c *HTTPClient) Introspect(ctx context.Context) (map[string]interface{}, error) {
-	query := `
-		query IntrospectionQuery {
-			__schema {
-				queryType { name }
-				mutationType { name }
-				subscriptionType { name }
-				types {
-					...FullType
-				}
+// introspectionQuery fetches the full schema. It is shared by the HTTP client
+// and the inline commands so both produce the same cached shape.
+const introspectionQuery = `
+	query IntrospectionQuery {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			subscriptionType { name }
+			types {
+				...FullType
 			}
 		}
+	}
 
-		fragment FullType on __Type {
-			kind
+	fragment FullType on __Type {
+		kind
+		name
+		description
+		fields(includeDeprecated: true) {
 			name
 			description
-			fields(includeDeprecated: true) {
-				name
-				description
-				args {
-					...InputValue
-				}
-				type {
-					...TypeRef
-				}
-				isDeprecated
-				deprecationReason
-			}
-			inputFields {
+			args {
 				...InputValue
 			}
-			interfaces {
-				...TypeRef
-			}
-			enumValues(includeDeprecated: true) {
-				name
-				description
-				isDeprecated
-				deprecationReason
-			}
-			possibleTypes {
+			type {
 				...TypeRef
 			}
+			isDeprecated
+			deprecationReason
 		}
-
-		fragment InputValue on __InputValue {
+		inputFields {
+			...InputValue
+		}
+		interfaces {
+			...TypeRef
+		}
+		enumValues(includeDeprecated: true) {
 			name
 			description
-			type { ...TypeRef }
-			defaultValue
+			isDeprecated
+			deprecationReason
 		}
+		possibleTypes {
+			...TypeRef
+		}
+	}
 
-		fragment TypeRef on __Type {
+	fragment InputValue on __InputValue {
+		name
+		description
+		type { ...TypeRef }
+		defaultValue
+	}
+
+	fragment TypeRef on __Type {
+		kind
+		name
+		ofType {
 			kind
 			name
 			ofType {
@@ -159,16 +152,15 @@ func (c *HTTPClient) Introspect(ctx context.Context) (map[string]interface{}, er
 				ofType {
 					kind
 					name
-					ofType {
-						kind
-						name
-					}
 				}
 			}
 		}
-	`
+	}
+`
 
-	return c.executeOperation(ctx, query, nil, "")
+// Introspect queries the GraphQL schema
+func (c *HTTPClient) Introspect(ctx context.Context) (map[string]interface{}, error) {
+	return c.executeOperation(ctx, introspectionQuery, nil, "")
 }
 
 // executeOperation is the internal method that handles request/response
@@ -182,6 +174,12 @@ func (c *HTTPClient) executeOperation(ctx context.Context, query string, variabl
 		return nil, fmt.Errorf("URL must start with http:// or https://")
 	}
 
+	if c.config.ReadOnly {
+		if err := checkReadOnly(readOnlySource(c.config), query, operationName); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build request
 	request := GraphQLRequest{
 		Query: query,
@@ -193,67 +191,172 @@ func (c *HTTPClient) executeOperation(ctx context.Context, query string, variabl
 		request.OperationName = operationName
 	}
 
-	// Execute request
-	resp, err := c.client.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(request).
-		Post(c.config.URL)
-
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	if c.config.PersistedQueries {
+		// Hash only; a retry after PERSISTED_QUERY_NOT_FOUND adds the text.
+		sum := sha256.Sum256([]byte(query))
+		request.Query = ""
+		request.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])},
+		}
 	}
+	isMutation := isMutationOperation(query, operationName)
+
+	// Execute request, letting registered extensions.code handlers ask for a
+	// retry (e.g. after a token refresh or a rate-limit wait).
+	for attempt := 1; ; attempt++ {
+		resp, endpoint, err := c.post(ctx, request, isMutation)
+		if err != nil {
+			return nil, err
+		}
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w\nBody: %s", err, string(resp.Body()))
-	}
+		// Parse response
+		var result map[string]interface{}
+		err = decodeJSON(resp.Body(), &result)
+		if code := resp.StatusCode(); code == http.StatusTooManyRequests || code >= 500 {
+			// A gateway or overload response rather than a GraphQL one; these
+			// are the failures retryWithBackoff retries.
+			if _, ok := result["data"]; !ok && result["errors"] == nil {
+				return nil, fmt.Errorf("%w\nBody: %s", &httpStatusError{StatusCode: code, Status: resp.Status()}, truncateLine(string(resp.Body()), 200))
+			}
+		}
+		if err != nil {
+			if htmlErr := htmlResponseError(resp, endpoint); htmlErr != nil {
+				return nil, htmlErr
+			}
+			return nil, fmt.Errorf("failed to parse response: %w\nBody: %s", err, string(resp.Body()))
+		}
+		result = normalizeErrorShape(result)
 
-	// Check for errors in response; enrich with schema hints and return as typed error.
-	if rawErrors, ok := result["errors"].([]interface{}); ok {
+		// Check for errors in response; enrich with schema hints and return as typed error.
+		rawErrors, ok := result["errors"].([]interface{})
+		if !ok {
+			return result, nil
+		}
+		// A mutation whose response has data may have run, so it is only
+		// re-sent when the caller opted in.
+		resend := !isMutation || result["data"] == nil || c.config.RetryMutations
+		// Handlers get a copy of the config, so a refreshing handler runs
+		// unlocked; a token it sets is copied back for the other goroutines.
+		c.mu.Lock()
+		cfg := *c.config
+		c.mu.Unlock()
+		token := cfg.Token
+		retry, wait, code := dispatchErrorCodes(ctx, &cfg, rawErrors, attempt, resend)
+		if cfg.Token != token {
+			c.mu.Lock()
+			c.config.Token = cfg.Token
+			c.mu.Unlock()
+		}
+		if retry {
+			if wait > 0 {
+				fmt.Fprintf(os.Stderr, "warning: %s; retrying in %s (attempt %d of %d)\n", code, wait.Round(time.Millisecond), attempt+1, maxErrorCodeAttempts)
+			}
+			request.Query = query
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
 		c.enrichErrors(ctx, rawErrors)
 		return result, &GraphQLResponseError{Response: result, Query: query}
 	}
+}
 
-	return result, nil
+// post sends request and returns the response and the endpoint that produced
+// it. Redirects are followed here rather than by resty so the operation is
+// re-POSTed; mutations only with FollowRedirects. isMutation comes from the
+// caller, since a persisted-query request carries no query text to parse.
+// Redirects to another origin are refused, since the request carries the
+// bearer token.
+func (c *HTTPClient) post(ctx context.Context, request GraphQLRequest, isMutation bool) (*resty.Response, string, error) {
+	endpoint := c.config.URL
+	for hops := 0; ; hops++ {
+		req := c.client.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "application/json").
+			SetBody(request)
+		c.mu.Lock()
+		token := c.config.Token
+		c.mu.Unlock()
+		if token != "" {
+			// Set per request, never as a client default, so it only goes to
+			// endpoints post has vetted and a token refreshed by
+			// OnUnauthorized takes effect.
+			req.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+		resp, err := req.Post(endpoint)
+		if err != nil {
+			return nil, "", fmt.Errorf("request failed: %w", err)
+		}
+
+		target, err := redirectTarget(resp, endpoint)
+		if err != nil {
+			return nil, "", err
+		}
+		if target == "" {
+			return resp, endpoint, nil
+		}
+		if hops >= maxRedirects {
+			return nil, "", fmt.Errorf("endpoint redirected more than %d times (last to %s)", maxRedirects, target)
+		}
+		if err := checkRedirectOrigin(endpoint, target); err != nil {
+			return nil, "", err
+		}
+		if !c.config.FollowRedirects && isMutation {
+			return nil, "", fmt.Errorf("endpoint redirected to %s — update your --url (or pass --follow-redirects to re-send the mutation there)", target)
+		}
+		c.warnRedirect(target)
+		endpoint = target
+	}
+}
+
+// decodeJSON unmarshals data keeping numbers as json.Number, so integers beyond
+// 2^53 (large IDs, counters) keep their exact digits instead of being rounded
+// through float64. Use it for responses and user-supplied variables.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
 }
 
 // enrichErrors attaches schemaHint to each error's extensions map when the server
 // did not already provide one and the error message references a known type.
+// Lookups share Config.HintTimeout; errors left when it runs out get no hint.
 func (c *HTTPClient) enrichErrors(ctx context.Context, errors []interface{}) {
+	timeout := c.config.HintTimeout
+	if timeout < 0 {
+		return
+	}
+	if timeout == 0 {
+		timeout = DefaultHintTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	for _, e := range errors {
 		em, ok := e.(map[string]interface{})
 		if !ok {
 			continue
 		}
 		msg, _ := em["message"].(string)
-		if msg == "" {
-			continue
+		ext, _ := em["extensions"].(map[string]interface{})
+		if ext = AttachHint(ctx, c.getDescriber(), msg, ext); ext != nil {
+			em["extensions"] = ext
 		}
-
-		// Skip if server already attached a hint.
-		if ext, ok := em["extensions"].(map[string]interface{}); ok {
-			if _, hasHint := ext["schemaHint"]; hasHint {
-				continue
+		if ctx.Err() != nil {
+			if c.config.Debug {
+				fmt.Fprintf(os.Stderr, "debug: skipping schema hints: %v\n", ctx.Err())
 			}
+			return
 		}
-
-		typeName := extractTypeFromErrorMsg(msg)
-		if typeName == "" {
-			continue
-		}
-		hint, err := c.getDescriber().Describe(ctx, typeName)
-		if err != nil || hint == "" {
-			continue
-		}
-
-		ext, ok := em["extensions"].(map[string]interface{})
-		if !ok {
-			ext = make(map[string]interface{})
-			em["extensions"] = ext
-		}
-		ext["schemaHint"] = hint
 	}
 }
 
@@ -0,0 +1,229 @@
+package gqlcli
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//go:embed console/index.html
+var consoleHTML []byte
+
+// maxConsoleQueryBytes caps the body of a console query request.
+const maxConsoleQueryBytes = 1 << 20
+
+// consoleConfig holds options for NewConsoleHandler.
+type consoleConfig struct {
+	authorize     func(*http.Request) error
+	formatReg     FormatterRegistry
+	defaultFormat string
+}
+
+// ConsoleOption configures the handler returned by NewConsoleHandler.
+type ConsoleOption func(*consoleConfig)
+
+// WithConsoleAuthorizer rejects requests for which fn returns an error with 403 Forbidden.
+// It is called for every request, including the page itself.
+func WithConsoleAuthorizer(fn func(r *http.Request) error) ConsoleOption {
+	return func(o *consoleConfig) { o.authorize = fn }
+}
+
+// WithConsoleFormatters sets the formatters offered in the format selector.
+// Defaults to NewFormatterRegistry().
+func WithConsoleFormatters(reg FormatterRegistry) ConsoleOption {
+	return func(o *consoleConfig) { o.formatReg = reg }
+}
+
+// WithConsoleDefaultFormat sets the initially selected output format (default: json-pretty).
+func WithConsoleDefaultFormat(name string) ConsoleOption {
+	return func(o *consoleConfig) { o.defaultFormat = name }
+}
+
+type consoleTokenKey struct{}
+
+// ConsoleTokenFromContext returns the token the console user pasted for their
+// browser session, or "" if none. Call it from a WithContextEnricher function
+// to authenticate console requests the same way the CLI does.
+func ConsoleTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(consoleTokenKey{}).(string)
+	return token
+}
+
+// NewConsoleHandler returns an http.Handler serving a single-page web console
+// for the executor: a query editor, variables pane, format selector, and type
+// browser backed by a Describer. It can be mounted under any path prefix, e.g.
+//
+//	mux.Handle("/console/", http.StripPrefix("/console", gqlcli.NewConsoleHandler(exec)))
+//
+// Operations run through exec.Execute, so the executor's context enricher applies.
+// The token pasted in the UI is available to it via ConsoleTokenFromContext.
+func NewConsoleHandler(exec *InlineExecutor, opts ...ConsoleOption) http.Handler {
+	cfg := &consoleConfig{defaultFormat: "json-pretty"}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.formatReg == nil {
+		cfg.formatReg = NewFormatterRegistry()
+	}
+	return &consoleHandler{exec: exec, describer: NewDescriber(exec), cfg: cfg}
+}
+
+type consoleHandler struct {
+	exec      *InlineExecutor
+	describer *Describer
+	cfg       *consoleConfig
+}
+
+func (h *consoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.authorize != nil {
+		if err := h.cfg.authorize(r); err != nil {
+			writeConsoleJSON(w, http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		ctx = context.WithValue(ctx, consoleTokenKey{}, strings.TrimPrefix(auth, "Bearer "))
+	}
+
+	// Routes are matched on the path suffix so the handler works under any prefix.
+	path := r.URL.Path
+	switch {
+	case strings.HasSuffix(path, "/api/query") && r.Method == http.MethodPost:
+		h.serveQuery(ctx, w, r)
+	case strings.HasSuffix(path, "/api/formats"):
+		writeConsoleJSON(w, http.StatusOK, map[string]interface{}{
+			"formats": h.cfg.formatReg.List(),
+			"default": h.cfg.defaultFormat,
+		})
+	case strings.HasSuffix(path, "/api/types"):
+		h.serveTypes(ctx, w)
+	case strings.HasSuffix(path, "/api/describe"):
+		h.serveDescribe(ctx, w, r)
+	case strings.Contains(path, "/api/"):
+		writeConsoleJSON(w, http.StatusNotFound, map[string]interface{}{"error": "not found"})
+	default:
+		// Relative asset URLs need the page to be served from a directory path.
+		// Use the original request path: a StripPrefix wrapper may have rewritten r.URL.Path.
+		if !strings.HasSuffix(path, "/") {
+			orig := r.RequestURI
+			if i := strings.IndexAny(orig, "?#"); i >= 0 {
+				orig = orig[:i]
+			}
+			http.Redirect(w, r, orig+"/", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(consoleHTML)
+	}
+}
+
+func (h *consoleHandler) serveQuery(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Requiring JSON keeps cross-site form posts out: a browser only sends
+	// this content type from another origin after a CORS preflight.
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeConsoleJSON(w, http.StatusUnsupportedMediaType, map[string]interface{}{"error": "Content-Type must be application/json"})
+		return
+	}
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+		Format    string                 `json:"format"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConsoleQueryBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeConsoleJSON(w, http.StatusRequestEntityTooLarge, map[string]interface{}{"error": fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)})
+			return
+		}
+		writeConsoleJSON(w, http.StatusBadRequest, map[string]interface{}{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeConsoleJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "query is required"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = h.cfg.defaultFormat
+	}
+	formatter, err := h.cfg.formatReg.Get(req.Format)
+	if err != nil {
+		writeConsoleJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	raw, err := h.exec.Execute(ctx, req.Query, req.Variables)
+	if err != nil {
+		writeConsoleJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	var result map[string]interface{}
+	if err := decodeJSON(raw, &result); err != nil {
+		writeConsoleJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": fmt.Sprintf("failed to parse response: %v", err)})
+		return
+	}
+
+	var output string
+	if errs, ok := result["errors"].([]interface{}); ok && len(errs) > 0 {
+		output = formatErrors(errs)
+	} else if output, err = formatter.Format(result); err != nil {
+		writeConsoleJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	writeConsoleJSON(w, http.StatusOK, map[string]interface{}{"output": output, "result": result})
+}
+
+func (h *consoleHandler) serveTypes(ctx context.Context, w http.ResponseWriter) {
+	raw, err := h.exec.Execute(ctx, `{ __schema { types { name kind } } }`, nil)
+	if err != nil {
+		writeConsoleJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	var result struct {
+		Data struct {
+			Schema struct {
+				Types []map[string]interface{} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := decodeJSON(raw, &result); err != nil {
+		writeConsoleJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": fmt.Sprintf("failed to parse response: %v", err)})
+		return
+	}
+	types := result.Data.Schema.Types
+	sort.Slice(types, func(i, j int) bool {
+		ni, _ := types[i]["name"].(string)
+		nj, _ := types[j]["name"].(string)
+		return ni < nj
+	})
+	writeConsoleJSON(w, http.StatusOK, map[string]interface{}{"types": types})
+}
+
+func (h *consoleHandler) serveDescribe(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	typeName := r.URL.Query().Get("type")
+	if typeName == "" {
+		writeConsoleJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "type parameter is required"})
+		return
+	}
+	sdl, err := h.describer.DescribeWith(ctx, typeName, true, true)
+	if err != nil {
+		writeConsoleJSON(w, http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	writeConsoleJSON(w, http.StatusOK, map[string]interface{}{"sdl": sdl})
+}
+
+func writeConsoleJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
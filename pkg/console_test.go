@@ -0,0 +1,143 @@
+package gqlcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveConsole sends one request through h and returns the recorded response.
+func serveConsole(t *testing.T, h http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConsoleRoutesUnderPrefix(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	mux := http.NewServeMux()
+	mux.Handle("/console/", http.StripPrefix("/console", NewConsoleHandler(e, WithConsoleDefaultFormat("compact"))))
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		want                 string
+	}{
+		{"GET", "/console/", "", http.StatusOK, "<html"},
+		{"GET", "/console/api/formats", "", http.StatusOK, `"default":"compact"`},
+		{"GET", "/console/api/types", "", http.StatusOK, `"name":"Book"`},
+		{"GET", "/console/api/describe?type=Book", "", http.StatusOK, "type Book"},
+		{"GET", "/console/api/describe", "", http.StatusBadRequest, "type parameter is required"},
+		{"GET", "/console/api/describe?type=Nope", "", http.StatusNotFound, "Nope"},
+		{"POST", "/console/api/query", `{"query": "{ books { title } }", "format": "json"}`, http.StatusOK, "Cosmos"},
+		{"POST", "/console/api/query", `{"query": " "}`, http.StatusBadRequest, "query is required"},
+		{"POST", "/console/api/query", `{"query": "{ books { id } }", "format": "nope"}`, http.StatusBadRequest, "nope"},
+		{"GET", "/console/api/query", "", http.StatusNotFound, "not found"},
+		{"GET", "/console/api/other", "", http.StatusNotFound, "not found"},
+	}
+	for _, tt := range tests {
+		rec := serveConsole(t, mux, tt.method, tt.target, "application/json", tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d\n%s", tt.method, tt.target, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		assertContains(t, rec.Body.String(), tt.want)
+	}
+
+	// The page is only served from a directory path, so its relative asset
+	// URLs resolve under the prefix.
+	rec := serveConsole(t, mux, "GET", "/console/page?x=1", "", "")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/console/page/" {
+		t.Errorf("got %d to %q, want a redirect to /console/page/", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestConsoleAuthorizer(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	h := NewConsoleHandler(e, WithConsoleAuthorizer(func(r *http.Request) error {
+		if r.Header.Get("X-Console-Key") != "open" {
+			return errors.New("console key required")
+		}
+		return nil
+	}))
+
+	for _, target := range []string{"/", "/api/formats", "/api/types", "/api/query"} {
+		rec := serveConsole(t, h, "POST", target, "application/json", `{"query": "{ books { id } }"}`)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403", target, rec.Code)
+		}
+		assertContains(t, rec.Body.String(), "console key required")
+	}
+
+	req := httptest.NewRequest("GET", "/api/formats", nil)
+	req.Header.Set("X-Console-Key", "open")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("authorized request: status %d, want 200", rec.Code)
+	}
+}
+
+func TestConsoleTokenContext(t *testing.T) {
+	var tokens []string
+	e, _ := newTestExecutor(t, WithContextEnricher(func(ctx context.Context) context.Context {
+		tokens = append(tokens, ConsoleTokenFromContext(ctx))
+		return ctx
+	}))
+	h := NewConsoleHandler(e)
+
+	for _, auth := range []string{"Bearer s3cret", "Basic s3cret", ""} {
+		req := httptest.NewRequest("POST", "/api/query", strings.NewReader(`{"query": "{ books { id } }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status %d\n%s", auth, rec.Code, rec.Body)
+		}
+	}
+	if want := []string{"s3cret", "", ""}; strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("enricher saw tokens %q, want %q", tokens, want)
+	}
+}
+
+func TestConsoleQueryRequestChecks(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	h := NewConsoleHandler(e)
+	query := `{"query": "{ books { id } }"}`
+
+	tests := []struct {
+		name, contentType, body string
+		status                  int
+	}{
+		{"json", "application/json", query, http.StatusOK},
+		{"json with charset", "application/json; charset=utf-8", query, http.StatusOK},
+		{"no content type", "", query, http.StatusUnsupportedMediaType},
+		{"form post", "application/x-www-form-urlencoded", query, http.StatusUnsupportedMediaType},
+		{"text", "text/plain", query, http.StatusUnsupportedMediaType},
+		{"malformed", "application/json", `{"query":`, http.StatusBadRequest},
+		{"too large", "application/json", `{"query": "` + strings.Repeat(" ", maxConsoleQueryBytes) + `{ books { id } }"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rec := serveConsole(t, h, "POST", "/api/query", tt.contentType, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d\n%s", tt.name, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		var out map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Errorf("%s: response is not JSON: %v", tt.name, err)
+		}
+	}
+}
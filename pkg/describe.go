@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 )
@@ -17,10 +18,24 @@ import (
 // Results are cached after the first introspection call for each type.
 //
 // Use NewDescriber to create one from an InlineExecutor, or newSchemaHintDescriber
-// internally when wiring the schema hint error presenter.
+// internally when wiring the schema hint error presenter. A Describer for an
+// HTTP endpoint also reads and fills the on-disk SchemaCache, so types cached
+// by warm are described without contacting the endpoint until they are older
+// than Config.CacheTTL.
 type Describer struct {
 	exec  func(ctx context.Context, query string, vars map[string]interface{}) (json.RawMessage, error)
 	cache sync.Map
+
+	// disk and url locate the endpoint's SchemaCache; disk is nil for
+	// in-process schemas. Entries older than ttl are ignored.
+	disk *SchemaCache
+	url  string
+	ttl  time.Duration
+
+	// schemaTypes indexes the cached schema.json by type name. It is read
+	// once, on the first type missing from the describe entries.
+	schemaOnce  sync.Once
+	schemaTypes map[string]map[string]interface{}
 }
 
 // newSchemaHintDescriber creates a Describer backed by the given server.
@@ -59,9 +74,18 @@ func NewDescriber(exec *InlineExecutor) *Describer {
 }
 
 // NewDescriberFromHTTPClient creates a Describer that fetches type information
-// via introspection against the given HTTP client.
+// via introspection against the given HTTP client. Types are looked up in the
+// schema cache under Config.CacheDir first, and fetched types are saved there.
+// Cached entries older than Config.CacheTTL are fetched again; a negative TTL
+// turns the disk cache off.
 func NewDescriberFromHTTPClient(c *HTTPClient) *Describer {
-	d := &Describer{}
+	d := &Describer{url: c.config.URL, ttl: c.config.CacheTTL}
+	if d.ttl == 0 {
+		d.ttl = DefaultCacheTTL
+	}
+	if d.ttl > 0 {
+		d.disk = NewSchemaCache(c.config.CacheDir)
+	}
 	d.exec = func(ctx context.Context, query string, vars map[string]interface{}) (json.RawMessage, error) {
 		result, err := c.executeOperation(ctx, query, vars, "")
 		if err != nil {
@@ -98,19 +122,93 @@ func (d *Describer) DescribeWith(ctx context.Context, typeName string, showArgs,
 	return FormatTypeSDL(typeInfo, showArgs, !showDescriptions), nil
 }
 
-// fetch retrieves and caches the raw introspection data for a type.
+// fetch retrieves and caches the raw introspection data for a type, trying
+// memory, then the disk cache, then the schema itself.
 func (d *Describer) fetch(ctx context.Context, typeName string) (map[string]interface{}, error) {
 	if cached, ok := d.cache.Load(typeName); ok {
 		return cached.(map[string]interface{}), nil
 	}
+	if typeInfo := d.loadDisk(typeName); typeInfo != nil {
+		d.cache.Store(typeName, typeInfo)
+		return typeInfo, nil
+	}
 
-	raw, err := d.exec(ctx, buildDescribeQuery(typeName), nil)
+	typeInfo, err := d.fetchRemote(ctx, typeName)
 	if err != nil {
+		return nil, err
+	}
+	if d.disk != nil {
+		// Best effort: a read-only cache only costs the next run a request.
+		_ = d.disk.SaveType(d.url, typeName, typeInfo)
+	}
+	d.cache.Store(typeName, typeInfo)
+	return typeInfo, nil
+}
+
+// loadDisk returns typeName from the disk cache: its describe entry, or its
+// entry in the cached full schema. It returns nil when neither has it.
+func (d *Describer) loadDisk(typeName string) map[string]interface{} {
+	if d.disk == nil {
+		return nil
+	}
+	if entry, err := typeEntry(typeName); err != nil {
+		return nil
+	} else if d.disk.fresh(d.url, entry, d.ttl) {
+		if typeInfo, err := d.disk.LoadType(d.url, typeName); err == nil && typeInfo != nil {
+			return typeInfo
+		}
+	}
+	d.schemaOnce.Do(func() {
+		if !d.disk.fresh(d.url, "schema.json", d.ttl) {
+			return
+		}
+		result, err := d.disk.LoadSchema(d.url)
+		if err != nil {
+			return
+		}
+		schema, _ := unwrapSchema(result).(map[string]interface{})
+		types, _ := schema["types"].([]interface{})
+		d.schemaTypes = make(map[string]map[string]interface{}, len(types))
+		for _, t := range types {
+			if tm, ok := t.(map[string]interface{}); ok {
+				if name, _ := tm["name"].(string); name != "" {
+					d.schemaTypes[name] = tm
+				}
+			}
+		}
+	})
+	return d.schemaTypes[typeName]
+}
+
+// fetchRemote introspects typeName through exec, bypassing every cache.
+func (d *Describer) fetchRemote(ctx context.Context, typeName string) (map[string]interface{}, error) {
+	// exec may not watch ctx itself (the inline server runs resolvers to
+	// completion), so wait for it here and give up as soon as ctx is done.
+	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("introspection failed: %w", err)
 	}
+	type execResult struct {
+		raw json.RawMessage
+		err error
+	}
+	done := make(chan execResult, 1)
+	go func() {
+		raw, err := d.exec(ctx, buildDescribeQuery(typeName), nil)
+		done <- execResult{raw, err}
+	}()
+	var raw json.RawMessage
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("introspection failed: %w", ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("introspection failed: %w", r.err)
+		}
+		raw = r.raw
+	}
 
 	var result map[string]interface{}
-	if err := json.Unmarshal(raw, &result); err != nil {
+	if err := decodeJSON(raw, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse introspection response: %w", err)
 	}
 
@@ -123,8 +221,6 @@ func (d *Describer) fetch(ctx context.Context, typeName string) (map[string]inte
 	if !ok || typeInfo == nil {
 		return nil, fmt.Errorf("type %q not found in schema", typeName)
 	}
-
-	d.cache.Store(typeName, typeInfo)
 	return typeInfo, nil
 }
 
@@ -0,0 +1,163 @@
+package gqlcli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// referencedTypes returns the non-scalar named types used by typeName's
+// fields, input fields, and field arguments, deduplicated in order of first use.
+func (d *Describer) referencedTypes(ctx context.Context, typeName string) ([]string, error) {
+	typeInfo, err := d.fetch(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	seen := map[string]bool{typeName: true}
+	add := func(typeRef interface{}) {
+		name, kind := namedTypeKind(typeRef)
+		if name == "" || kind == "SCALAR" || seen[name] || strings.HasPrefix(name, "__") {
+			return
+		}
+		seen[name] = true
+		refs = append(refs, name)
+	}
+	for _, key := range []string{"fields", "inputFields"} {
+		list, _ := typeInfo[key].([]interface{})
+		for _, f := range list {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			add(fm["type"])
+			args, _ := fm["args"].([]interface{})
+			for _, a := range args {
+				if am, ok := a.(map[string]interface{}); ok {
+					add(am["type"])
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// namedTypeKind unwraps NON_NULL and LIST wrappers and returns the underlying
+// type's name and kind.
+func namedTypeKind(typeRef interface{}) (string, string) {
+	tm, ok := typeRef.(map[string]interface{})
+	for ok {
+		if name, _ := tm["name"].(string); name != "" {
+			kind, _ := tm["kind"].(string)
+			return name, kind
+		}
+		tm, ok = tm["ofType"].(map[string]interface{})
+	}
+	return "", ""
+}
+
+const describeNavHelp = `Pick a type to describe next (press Enter after each):
+  N   describe referenced type N
+  b   back to the previous type
+  q   quit and print the visited types
+`
+
+// describeNavigator is an interactive describe session: the SDL of the current
+// type, then a numbered list of the types it references. All hops share one
+// Describer, so types seen before are described from its cache.
+type describeNavigator struct {
+	d                *Describer
+	in               *bufio.Scanner
+	out              io.Writer
+	showArgs         bool
+	showDescriptions bool
+	// trail is the breadcrumb from the first type to the current one.
+	trail []string
+	// visited lists every type described, in first-visit order.
+	visited []string
+}
+
+func newDescribeNavigator(d *Describer, in io.Reader, out io.Writer, showArgs, showDescriptions bool) *describeNavigator {
+	return &describeNavigator{d: d, in: bufio.NewScanner(in), out: out, showArgs: showArgs, showDescriptions: showDescriptions}
+}
+
+// run navigates from typeName until q or end of input and returns the visited
+// types. An error describing the first type is returned; later ones are shown
+// and the session stays on the previous type.
+func (nav *describeNavigator) run(ctx context.Context, typeName string) ([]string, error) {
+	nav.trail = []string{typeName}
+	refs, err := nav.show(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		fmt.Fprint(nav.out, "describe> ")
+		if !nav.in.Scan() {
+			fmt.Fprintln(nav.out)
+			return nav.visited, nil
+		}
+		line := strings.TrimSpace(nav.in.Text())
+		prev := nav.trail
+		switch {
+		case line == "":
+			continue
+		case line == "q" || line == "quit":
+			return nav.visited, nil
+		case line == "?" || line == "h" || line == "help":
+			fmt.Fprint(nav.out, describeNavHelp)
+			continue
+		case line == "b" || line == "back":
+			if len(nav.trail) == 1 {
+				fmt.Fprintln(nav.out, "already at the first type")
+				continue
+			}
+			nav.trail = nav.trail[:len(nav.trail)-1]
+		default:
+			n, err := strconv.Atoi(line)
+			if err != nil || n < 1 || n > len(refs) {
+				fmt.Fprintf(nav.out, "unknown command %q (? for help)\n", line)
+				continue
+			}
+			nav.trail = append(nav.trail[:len(nav.trail):len(nav.trail)], refs[n-1])
+		}
+
+		next, err := nav.show(ctx)
+		if err != nil {
+			fmt.Fprintln(nav.out, err)
+			nav.trail = prev
+			continue
+		}
+		refs = next
+	}
+}
+
+// show prints the breadcrumb, SDL, and numbered references of the last type
+// in the trail and returns the references.
+func (nav *describeNavigator) show(ctx context.Context) ([]string, error) {
+	typeName := nav.trail[len(nav.trail)-1]
+	sdl, err := nav.d.DescribeWith(ctx, typeName, nav.showArgs, nav.showDescriptions)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := nav.d.referencedTypes(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(nav.visited, typeName) {
+		nav.visited = append(nav.visited, typeName)
+	}
+
+	fmt.Fprintf(nav.out, "\n%s\n\n%s", strings.Join(nav.trail, " > "), sdl)
+	if len(refs) == 0 {
+		fmt.Fprintln(nav.out, "\n(no referenced types; b: back, q: quit)")
+		return refs, nil
+	}
+	fmt.Fprintln(nav.out, "\nReferenced types:")
+	for i, r := range refs {
+		fmt.Fprintf(nav.out, "%3d %s\n", i+1, r)
+	}
+	return refs, nil
+}
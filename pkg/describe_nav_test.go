@@ -0,0 +1,157 @@
+package gqlcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReferencedTypes(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	d := NewDescriber(e)
+
+	tests := []struct {
+		typeName string
+		want     []string
+	}{
+		// Field types in field order; scalars (BigInt too) are left out.
+		{"Book", []string{"Genre", "Author"}},
+		// Cycles back to the type itself are not listed.
+		{"Author", []string{"Book"}},
+		// Argument types count, deduplicated across fields.
+		{"Query", []string{"Book", "Genre", "Author", "SearchResult"}},
+		{"Mutation", []string{"Book", "AddBookInput"}},
+		// Input fields, through list and non-null wrappers.
+		{"AddBookInput", []string{"Genre", "BookDetailsInput"}},
+		{"BookDetailsInput", []string{"DateInput"}},
+		{"Genre", nil},
+	}
+	for _, tt := range tests {
+		got, err := d.referencedTypes(context.Background(), tt.typeName)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.typeName, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.typeName, got, tt.want)
+		}
+	}
+	if _, err := d.referencedTypes(context.Background(), "Nope"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+func TestNamedTypeKind(t *testing.T) {
+	named := map[string]interface{}{"kind": "OBJECT", "name": "Book"}
+	tests := []struct {
+		ref                interface{}
+		wantName, wantKind string
+	}{
+		{named, "Book", "OBJECT"},
+		{map[string]interface{}{"kind": "NON_NULL", "name": nil, "ofType": named}, "Book", "OBJECT"},
+		{map[string]interface{}{"kind": "NON_NULL", "ofType": map[string]interface{}{
+			"kind": "LIST", "ofType": map[string]interface{}{
+				"kind": "NON_NULL", "ofType": map[string]interface{}{"kind": "SCALAR", "name": "String"}}}}, "String", "SCALAR"},
+		{map[string]interface{}{"kind": "LIST"}, "", ""},
+		{nil, "", ""},
+		{"Book", "", ""},
+	}
+	for i, tt := range tests {
+		if name, kind := namedTypeKind(tt.ref); name != tt.wantName || kind != tt.wantKind {
+			t.Errorf("%d: got %q %q, want %q %q", i, name, kind, tt.wantName, tt.wantKind)
+		}
+	}
+}
+
+// navigate runs a describe session from typeName over the given input lines.
+func navigate(t *testing.T, d *Describer, typeName string, lines ...string) ([]string, string, error) {
+	t.Helper()
+	var out strings.Builder
+	nav := newDescribeNavigator(d, strings.NewReader(strings.Join(lines, "\n")), &out, false, false)
+	visited, err := nav.run(context.Background(), typeName)
+	return visited, out.String(), err
+}
+
+func TestDescribeNavigator(t *testing.T) {
+	e, _ := newTestExecutor(t)
+
+	tests := []struct {
+		name        string
+		lines       []string
+		wantVisited []string
+		wantOut     []string
+	}{
+		{
+			name:        "hop to references and quit",
+			lines:       []string{"2", "1", "q", "1"},
+			wantVisited: []string{"Book", "Author"},
+			wantOut:     []string{"Book > Author\n", "Book > Author > Book\n", "Referenced types:\n  1 Genre\n  2 Author\n"},
+		},
+		{
+			name:        "back retraces the trail",
+			lines:       []string{"2", "b", "1", "back", "b"},
+			wantVisited: []string{"Book", "Author", "Genre"},
+			wantOut:     []string{"Book > Genre\n", "already at the first type"},
+		},
+		{
+			name:        "a type without references",
+			lines:       []string{"1", "1"},
+			wantVisited: []string{"Book", "Genre"},
+			wantOut:     []string{"enum Genre", "(no referenced types; b: back, q: quit)", `unknown command "1"`},
+		},
+		{
+			name:        "bad input is reported, blank lines ignored",
+			lines:       []string{"", "0", "3", "x", "?", "quit"},
+			wantVisited: []string{"Book"},
+			wantOut:     []string{`unknown command "0"`, `unknown command "3"`, `unknown command "x"`, "N   describe referenced type N"},
+		},
+		{
+			name:        "end of input ends the session",
+			lines:       nil,
+			wantVisited: []string{"Book"},
+			wantOut:     []string{"type Book {", "describe> \n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited, out, err := navigate(t, NewDescriber(e), "Book", tt.lines...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(visited, tt.wantVisited) {
+				t.Errorf("visited %v, want %v", visited, tt.wantVisited)
+			}
+			assertContains(t, out, tt.wantOut...)
+		})
+	}
+}
+
+func TestDescribeNavigatorErrors(t *testing.T) {
+	e, _ := newTestExecutor(t)
+
+	// The first type failing ends the session with its error.
+	if _, _, err := navigate(t, NewDescriber(e), "Nope", "q"); err == nil {
+		t.Error("expected an error for an unknown first type")
+	}
+
+	// A later type failing is shown, and the session stays where it was.
+	d := &Describer{exec: func(ctx context.Context, q string, v map[string]interface{}) (json.RawMessage, error) {
+		if strings.Contains(q, `"Author"`) {
+			return nil, errors.New("author lookup failed")
+		}
+		return e.Execute(ctx, q, v)
+	}}
+	visited, out, err := navigate(t, d, "Book", "2", "1", "q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, out, "author lookup failed", "Book > Genre\n")
+	if strings.Contains(out, "Book > Author") {
+		t.Errorf("the failed hop was kept in the trail:\n%s", out)
+	}
+	if want := []string{"Book", "Genre"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited %v, want %v", visited, want)
+	}
+}
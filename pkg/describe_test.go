@@ -0,0 +1,180 @@
+package gqlcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestDescriberDescribe(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	d := NewDescriber(e)
+
+	got, err := d.DescribeWith(context.Background(), "Query", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "describe/query.graphql", got)
+
+	got, err = d.Describe(context.Background(), "AddBookInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "describe/addbookinput.graphql", got)
+
+	got, err = d.Describe(context.Background(), "Genre")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, got, "enum Genre", "FICTION", "HISTORY")
+}
+
+func TestDescriberUnknownType(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	_, err := NewDescriber(e).Describe(context.Background(), "Magazine")
+	if err == nil || !strings.Contains(err.Error(), `type "Magazine" not found`) {
+		t.Errorf("got %v, want a not-found error", err)
+	}
+}
+
+func TestDescriberCachesTypes(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	var calls int32
+	d := &Describer{exec: func(ctx context.Context, q string, v map[string]interface{}) (json.RawMessage, error) {
+		atomic.AddInt32(&calls, 1)
+		return e.Execute(ctx, q, v)
+	}}
+	for i := 0; i < 3; i++ {
+		if _, err := d.Describe(context.Background(), "Book"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("introspected %d times, want 1", calls)
+	}
+}
+
+func TestDescriberHonoursDeadline(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	d := &Describer{exec: func(ctx context.Context, q string, v map[string]interface{}) (json.RawMessage, error) {
+		<-block
+		return nil, nil
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := d.Describe(ctx, "Book"); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Errorf("got %v, want a deadline error", err)
+	}
+}
+
+// slowExec is a Describer exec that ignores ctx and takes d to answer, like
+// the inline server running a slow resolver to completion.
+func slowExec(d time.Duration) func(context.Context, string, map[string]interface{}) (json.RawMessage, error) {
+	return func(ctx context.Context, q string, v map[string]interface{}) (json.RawMessage, error) {
+		time.Sleep(d)
+		return json.RawMessage(`{"data":{"__type":null}}`), nil
+	}
+}
+
+func TestDescriberSlowExecReturnsWithinDeadline(t *testing.T) {
+	d := &Describer{exec: slowExec(5 * time.Second)}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := d.Describe(ctx, "Book")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Describe waited %s for a 100ms deadline", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Errorf("got %v, want a deadline error", err)
+	}
+
+	// A context that is already done never reaches exec.
+	var calls int32
+	d = &Describer{exec: func(ctx context.Context, q string, v map[string]interface{}) (json.RawMessage, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}}
+	if _, err := d.Describe(ctx, "Book"); err == nil || atomic.LoadInt32(&calls) != 0 {
+		t.Errorf("got %v after %d exec calls, want an error and none", err, calls)
+	}
+}
+
+// TestInlineHintBudgetIsShared runs the schema hint presenter for several
+// errors of one operation against a slow Describer: together they may take
+// the budget once, not once per error.
+func TestInlineHintBudgetIsShared(t *testing.T) {
+	const budget = 100 * time.Millisecond
+	present := makeSchemaHintPresenter(&Describer{exec: slowExec(5 * time.Second)}, budget)
+	ctx := withHintBudget(context.Background(), budget)
+
+	start := time.Now()
+	for _, typ := range []string{"Book", "Author", "Genre", "Query"} {
+		gqlErr := present(ctx, &gqlerror.Error{Message: `Cannot query field "x" on type "` + typ + `".`})
+		if _, ok := gqlErr.Extensions["schemaHint"]; ok {
+			t.Errorf("%s: hint attached although the lookup timed out", typ)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 4*budget {
+		t.Errorf("four errors took %s with a %s budget", elapsed, budget)
+	}
+}
+
+// TestHTTPHintBudget sends a query with several invalid fields to an
+// endpoint whose introspection is slow and expects the original errors back
+// within Config.HintTimeout, without hints and with a debug note.
+func TestHTTPHintBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Query string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "__type") {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors":[
+			{"message":"Cannot query field \"titel\" on type \"Book\"."},
+			{"message":"Cannot query field \"nam\" on type \"Author\"."},
+			{"message":"Unknown argument \"limit\" on field \"Query.books\"."}
+		]}`))
+	}))
+	defer srv.Close()
+
+	const budget = 200 * time.Millisecond
+	c := NewHTTPClient(&Config{URL: srv.URL, HintTimeout: budget, Debug: true})
+	var err error
+	start := time.Now()
+	_, stderr := captureOutput(t, func() {
+		_, err = c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ books(limit: 1) { titel author { nam } } }"})
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the error took %s with a %s hint budget", elapsed, budget)
+	}
+	var gqlErr *GraphQLResponseError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("got %v, want the GraphQL errors", err)
+	}
+	errs := gqlErr.Response["errors"].([]interface{})
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3", len(errs))
+	}
+	for _, e := range errs {
+		ext, _ := e.(map[string]interface{})["extensions"].(map[string]interface{})
+		if _, ok := ext["schemaHint"]; ok {
+			t.Errorf("hint attached although the lookup timed out: %v", e)
+		}
+	}
+	assertContains(t, stderr, "debug: skipping schema hints")
+}
@@ -0,0 +1,471 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffOp is the kind of change recorded in a DiffEntry.
+type DiffOp string
+
+const (
+	DiffAdded   DiffOp = "add"
+	DiffRemoved DiffOp = "remove"
+	DiffChanged DiffOp = "replace"
+)
+
+// DiffEntry is a single difference between two decoded JSON documents.
+type DiffEntry struct {
+	Path []string
+	Op   DiffOp
+	Old  interface{}
+	New  interface{}
+}
+
+// DiffOptions controls how RenderDiff presents a diff.
+type DiffOptions struct {
+	// Format is one of "text" (default), "markdown", or "json-patch".
+	Format string
+	// Color enables ANSI colors in text output (added green, removed red, changed yellow).
+	Color bool
+	// Context is the number of unchanged siblings shown around each change.
+	// Longer runs of unchanged siblings are collapsed. Negative shows everything.
+	Context int
+	// KeyArraysBy matches array elements by this field (e.g. "name") instead of by index,
+	// so reordering does not produce spurious changes. A json-patch still addresses
+	// elements by their index in the old document and appends added elements, so
+	// applying it yields the new document up to the order of those arrays.
+	KeyArraysBy string
+}
+
+// DiffFormats lists the formats accepted by RenderDiff.
+var DiffFormats = []string{"text", "markdown", "json-patch"}
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// Diff returns the differences between old and new, sorted by path.
+// keyBy has the same meaning as DiffOptions.KeyArraysBy.
+// Null and missing object fields are treated as equal.
+func Diff(old, new interface{}, keyBy string) []DiffEntry {
+	old, new = stripNullValues(old), stripNullValues(new)
+	var out []DiffEntry
+	collectDiff(nil, old, new, keyBy, &out)
+	return out
+}
+
+// RenderDiff computes and renders the differences between old and new.
+func RenderDiff(old, new interface{}, opts DiffOptions) (string, error) {
+	old, new = stripNullValues(old), stripNullValues(new)
+	switch opts.Format {
+	case "", "text":
+		lines := diffLines(old, new, opts)
+		if len(lines) == 0 {
+			return "no differences\n", nil
+		}
+		var b strings.Builder
+		for _, l := range lines {
+			b.WriteString(l.render(opts.Color, false))
+		}
+		return b.String(), nil
+	case "markdown":
+		entries := Diff(old, new, opts.KeyArraysBy)
+		if len(entries) == 0 {
+			return "No differences.\n", nil
+		}
+		var added, removed, changed int
+		for _, e := range entries {
+			switch e.Op {
+			case DiffAdded:
+				added++
+			case DiffRemoved:
+				removed++
+			case DiffChanged:
+				changed++
+			}
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "**%d added, %d removed, %d changed**\n\n```diff\n", added, removed, changed)
+		for _, l := range diffLines(old, new, opts) {
+			b.WriteString(l.render(false, true))
+		}
+		b.WriteString("```\n")
+		return b.String(), nil
+	case "json-patch":
+		var ops []map[string]interface{}
+		collectPatch("", old, new, opts.KeyArraysBy, &ops)
+		if ops == nil {
+			ops = []map[string]interface{}{}
+		}
+		out, err := json.MarshalIndent(ops, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON patch: %w", err)
+		}
+		return string(out) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown diff format %q (valid: %s)", opts.Format, strings.Join(DiffFormats, ", "))
+	}
+}
+
+// --- structural walk ---
+
+// diffContainer is a map or array normalized to an ordered key set.
+type diffContainer struct {
+	keys []string
+	vals map[string]interface{}
+	list bool // keys are array indexes
+}
+
+func asDiffContainer(v interface{}, keyBy string) (*diffContainer, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		c := &diffContainer{vals: val}
+		for k := range val {
+			c.keys = append(c.keys, k)
+		}
+		sort.Strings(c.keys)
+		return c, true
+	case []interface{}:
+		if keyBy != "" {
+			if c, ok := keyedDiffContainer(val, keyBy); ok {
+				return c, true
+			}
+		}
+		c := &diffContainer{vals: make(map[string]interface{}, len(val)), list: true}
+		for i, item := range val {
+			k := strconv.Itoa(i)
+			c.keys = append(c.keys, k)
+			c.vals[k] = item
+		}
+		return c, true
+	}
+	return nil, false
+}
+
+// keyedDiffContainer indexes array elements by their keyBy field. It fails when
+// any element is not an object or the key is missing or duplicated.
+func keyedDiffContainer(items []interface{}, keyBy string) (*diffContainer, bool) {
+	c := &diffContainer{vals: make(map[string]interface{}, len(items))}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		k, ok := m[keyBy].(string)
+		if !ok {
+			return nil, false
+		}
+		if _, dup := c.vals[k]; dup {
+			return nil, false
+		}
+		c.keys = append(c.keys, k)
+		c.vals[k] = item
+	}
+	sort.Strings(c.keys)
+	return c, true
+}
+
+// unionKeys merges the keys of both containers in stable order.
+func unionKeys(a, b *diffContainer) []string {
+	seen := make(map[string]bool, len(a.keys)+len(b.keys))
+	var keys []string
+	for _, c := range []*diffContainer{a, b} {
+		for _, k := range c.keys {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	if a.list && b.list {
+		sort.Slice(keys, func(i, j int) bool {
+			ki, _ := strconv.Atoi(keys[i])
+			kj, _ := strconv.Atoi(keys[j])
+			return ki < kj
+		})
+	} else {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+func collectDiff(path []string, old, new interface{}, keyBy string, out *[]DiffEntry) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	oc, oOK := asDiffContainer(old, keyBy)
+	nc, nOK := asDiffContainer(new, keyBy)
+	if !oOK || !nOK || oc.list != nc.list {
+		*out = append(*out, DiffEntry{Path: path, Op: DiffChanged, Old: old, New: new})
+		return
+	}
+	for _, k := range unionKeys(oc, nc) {
+		ov, inOld := oc.vals[k]
+		nv, inNew := nc.vals[k]
+		child := append(append([]string(nil), path...), k)
+		switch {
+		case !inOld:
+			*out = append(*out, DiffEntry{Path: child, Op: DiffAdded, New: nv})
+		case !inNew:
+			*out = append(*out, DiffEntry{Path: child, Op: DiffRemoved, Old: ov})
+		default:
+			collectDiff(child, ov, nv, keyBy, out)
+		}
+	}
+}
+
+// --- text/markdown rendering ---
+
+type diffLine struct {
+	mark  byte // ' ', '+', '-', '~', '@' (collapsed marker)
+	depth int
+	key   string
+	text  string
+	old   string // only for '~'
+}
+
+func (l diffLine) render(color, markdown bool) string {
+	indent := strings.Repeat("  ", l.depth)
+	prefix := ""
+	if l.key != "" {
+		prefix = l.key + ": "
+	}
+	switch l.mark {
+	case '+':
+		return paint(color, ansiGreen, fmt.Sprintf("+ %s%s%s\n", indent, prefix, l.text))
+	case '-':
+		return paint(color, ansiRed, fmt.Sprintf("- %s%s%s\n", indent, prefix, l.text))
+	case '~':
+		if markdown {
+			// diff fences only understand +/-, so show a change as a removal and an addition.
+			return fmt.Sprintf("- %s%s%s\n+ %s%s%s\n", indent, prefix, l.old, indent, prefix, l.text)
+		}
+		return paint(color, ansiYellow, fmt.Sprintf("~ %s%s%s → %s\n", indent, prefix, l.old, l.text))
+	case '@':
+		return fmt.Sprintf("  %s%s\n", indent, l.text)
+	default:
+		if l.text == "" {
+			// Header line for a changed container; its children follow.
+			return fmt.Sprintf("  %s%s:\n", indent, l.key)
+		}
+		return fmt.Sprintf("  %s%s%s\n", indent, prefix, l.text)
+	}
+}
+
+func paint(enabled bool, code, s string) string {
+	if !enabled {
+		return s
+	}
+	// Keep the trailing newline outside the color sequence.
+	return code + strings.TrimSuffix(s, "\n") + ansiReset + "\n"
+}
+
+func diffLines(old, new interface{}, opts DiffOptions) []diffLine {
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	var lines []diffLine
+	walkDiffLines(old, new, 0, opts, &lines)
+	return lines
+}
+
+func walkDiffLines(old, new interface{}, depth int, opts DiffOptions, lines *[]diffLine) {
+	oc, oOK := asDiffContainer(old, opts.KeyArraysBy)
+	nc, nOK := asDiffContainer(new, opts.KeyArraysBy)
+	if !oOK || !nOK || oc.list != nc.list {
+		*lines = append(*lines, diffLine{mark: '~', depth: depth, old: diffSummary(old), text: diffSummary(new)})
+		return
+	}
+
+	keys := unionKeys(oc, nc)
+	changed := make([]bool, len(keys))
+	for i, k := range keys {
+		ov, inOld := oc.vals[k]
+		nv, inNew := nc.vals[k]
+		changed[i] = inOld != inNew || !reflect.DeepEqual(ov, nv)
+	}
+
+	label := func(k string) string {
+		if oc.list {
+			return "[" + k + "]"
+		}
+		return k
+	}
+
+	collapsed := 0
+	flush := func() {
+		if collapsed > 0 {
+			*lines = append(*lines, diffLine{mark: '@', depth: depth, text: fmt.Sprintf("… %d unchanged", collapsed)})
+			collapsed = 0
+		}
+	}
+
+	for i, k := range keys {
+		ov, inOld := oc.vals[k]
+		nv, inNew := nc.vals[k]
+		if !changed[i] {
+			if nearChange(changed, i, opts.Context) {
+				flush()
+				*lines = append(*lines, diffLine{mark: ' ', depth: depth, key: label(k), text: diffSummary(nv)})
+			} else {
+				collapsed++
+			}
+			continue
+		}
+		flush()
+		switch {
+		case !inOld:
+			*lines = append(*lines, diffLine{mark: '+', depth: depth, key: label(k), text: diffSummary(nv)})
+		case !inNew:
+			*lines = append(*lines, diffLine{mark: '-', depth: depth, key: label(k), text: diffSummary(ov)})
+		default:
+			_, ocOK := asDiffContainer(ov, opts.KeyArraysBy)
+			_, ncOK := asDiffContainer(nv, opts.KeyArraysBy)
+			if ocOK && ncOK {
+				*lines = append(*lines, diffLine{mark: ' ', depth: depth, key: label(k)})
+				walkDiffLines(ov, nv, depth+1, opts, lines)
+			} else {
+				*lines = append(*lines, diffLine{mark: '~', depth: depth, key: label(k), old: diffSummary(ov), text: diffSummary(nv)})
+			}
+		}
+	}
+	flush()
+}
+
+// nearChange reports whether index i is within context positions of a changed sibling.
+func nearChange(changed []bool, i, context int) bool {
+	if context < 0 {
+		return true
+	}
+	for j := i - context; j <= i+context; j++ {
+		if j >= 0 && j < len(changed) && changed[j] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSummary renders a value compactly; large values are truncated.
+func diffSummary(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(out)
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
+
+// --- JSON patch (RFC 6902) ---
+
+func collectPatch(pointer string, old, new interface{}, keyBy string, ops *[]map[string]interface{}) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := pointer + "/" + escapePointer(k)
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inOld:
+				*ops = append(*ops, map[string]interface{}{"op": "add", "path": child, "value": nv})
+			case !inNew:
+				*ops = append(*ops, map[string]interface{}{"op": "remove", "path": child})
+			default:
+				collectPatch(child, ov, nv, keyBy, ops)
+			}
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+		if keyBy != "" && collectKeyedPatch(pointer, o, n, keyBy, ops) {
+			return
+		}
+		common := len(o)
+		if len(n) < common {
+			common = len(n)
+		}
+		for i := 0; i < common; i++ {
+			collectPatch(pointer+"/"+strconv.Itoa(i), o[i], n[i], keyBy, ops)
+		}
+		for i := common; i < len(n); i++ {
+			*ops = append(*ops, map[string]interface{}{"op": "add", "path": pointer + "/" + strconv.Itoa(i), "value": n[i]})
+		}
+		// Remove from the end so earlier indexes stay valid.
+		for i := len(o) - 1; i >= common; i-- {
+			*ops = append(*ops, map[string]interface{}{"op": "remove", "path": pointer + "/" + strconv.Itoa(i)})
+		}
+		return
+	}
+	*ops = append(*ops, map[string]interface{}{"op": "replace", "path": pointer, "value": new})
+}
+
+// collectKeyedPatch patches an array whose elements are matched by their keyBy
+// field, so an insertion or reordering does not turn into a replace of every
+// later element. Kept elements are patched at their old index, removed ones are
+// removed from the end backwards, and added ones are appended in new order. It
+// returns false, adding nothing, when either array cannot be keyed.
+func collectKeyedPatch(pointer string, old, new []interface{}, keyBy string, ops *[]map[string]interface{}) bool {
+	oc, ok := keyedDiffContainer(old, keyBy)
+	if !ok {
+		return false
+	}
+	nc, ok := keyedDiffContainer(new, keyBy)
+	if !ok {
+		return false
+	}
+	keyAt := func(item interface{}) string {
+		k, _ := item.(map[string]interface{})[keyBy].(string)
+		return k
+	}
+	for i, item := range old {
+		if nv, kept := nc.vals[keyAt(item)]; kept {
+			collectPatch(pointer+"/"+strconv.Itoa(i), item, nv, keyBy, ops)
+		}
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		if _, kept := nc.vals[keyAt(old[i])]; !kept {
+			*ops = append(*ops, map[string]interface{}{"op": "remove", "path": pointer + "/" + strconv.Itoa(i)})
+		}
+	}
+	for _, item := range new {
+		if _, existed := oc.vals[keyAt(item)]; !existed {
+			*ops = append(*ops, map[string]interface{}{"op": "add", "path": pointer + "/-", "value": item})
+		}
+	}
+	return true
+}
+
+// escapePointer escapes a JSON pointer reference token (RFC 6901).
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,258 @@
+package gqlcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// diffFixture returns two introspection-like documents: a type is added and
+// one removed, another gains a field and changes a kind, the types are
+// reordered, and a positional list grows.
+func diffFixture(t *testing.T) (old, new interface{}) {
+	t.Helper()
+	const oldJSON = `{
+  "queryType": {"name": "Query"},
+  "directives": ["include", "skip"],
+  "types": [
+    {"name": "Query", "kind": "OBJECT", "fields": [{"name": "books"}, {"name": "authors"}]},
+    {"name": "Book", "kind": "OBJECT", "fields": [{"name": "id"}, {"name": "title"}, {"name": "pages"}]},
+    {"name": "Legacy", "kind": "OBJECT", "fields": [{"name": "code"}]},
+    {"name": "Genre", "kind": "ENUM"},
+    {"name": "Author", "kind": "OBJECT", "fields": [{"name": "name"}]}
+  ]
+}`
+	const newJSON = `{
+  "queryType": {"name": "Query"},
+  "directives": ["include", "skip", "deprecated"],
+  "types": [
+    {"name": "Author", "kind": "OBJECT", "fields": [{"name": "name"}]},
+    {"name": "Book", "kind": "OBJECT", "fields": [{"name": "id"}, {"name": "title"}, {"name": "pages"}, {"name": "isbn"}]},
+    {"name": "Genre", "kind": "SCALAR"},
+    {"name": "Query", "kind": "OBJECT", "fields": [{"name": "books"}, {"name": "authors"}]},
+    {"name": "Review", "kind": "OBJECT", "fields": [{"name": "stars"}]}
+  ]
+}`
+	if err := decodeJSON([]byte(oldJSON), &old); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeJSON([]byte(newJSON), &new); err != nil {
+		t.Fatal(err)
+	}
+	return old, new
+}
+
+func TestRenderDiffGolden(t *testing.T) {
+	old, new := diffFixture(t)
+	tests := []struct {
+		golden string
+		opts   DiffOptions
+	}{
+		{"text.txt", DiffOptions{Format: "text", Context: 1, KeyArraysBy: "name"}},
+		{"text-color.txt", DiffOptions{Format: "text", Color: true, Context: 1, KeyArraysBy: "name"}},
+		{"text-all.txt", DiffOptions{Format: "text", Context: -1, KeyArraysBy: "name"}},
+		{"markdown.txt", DiffOptions{Format: "markdown", Context: 1, KeyArraysBy: "name"}},
+		{"json-patch.txt", DiffOptions{Format: "json-patch", KeyArraysBy: "name"}},
+		{"json-patch-positional.txt", DiffOptions{Format: "json-patch"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := RenderDiff(old, new, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("diff", tt.golden), got)
+		})
+	}
+
+	if _, err := RenderDiff(old, new, DiffOptions{Format: "yaml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	for _, format := range DiffFormats {
+		got, err := RenderDiff(old, old, DiffOptions{Format: format})
+		if err != nil || !strings.Contains(strings.ToLower(got), "no differences") && got != "[]\n" {
+			t.Errorf("%s of equal documents: %q, %v", format, got, err)
+		}
+	}
+}
+
+// TestJSONPatchApplies applies the keyed patch to the old document and checks
+// the outcome equals the new one, ignoring the order of keyed arrays.
+func TestJSONPatchApplies(t *testing.T) {
+	old, new := diffFixture(t)
+	for _, keyBy := range []string{"", "name"} {
+		out, err := RenderDiff(old, new, DiffOptions{Format: "json-patch", KeyArraysBy: keyBy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ops []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &ops); err != nil {
+			t.Fatal(err)
+		}
+		doc := roundTrip(t, old)
+		for _, op := range ops {
+			doc = applyPatchOp(t, doc, op)
+		}
+		if got, want := sortByName(doc), sortByName(roundTrip(t, new)); !reflect.DeepEqual(got, want) {
+			t.Errorf("keyBy %q: patched document differs\n got %v\nwant %v", keyBy, got, want)
+		}
+	}
+
+	keyed, _ := RenderDiff(old, new, DiffOptions{Format: "json-patch", KeyArraysBy: "name"})
+	positional, _ := RenderDiff(old, new, DiffOptions{Format: "json-patch"})
+	if strings.Count(keyed, `"op"`) >= strings.Count(positional, `"op"`) {
+		t.Errorf("keying by name should avoid spurious replaces:\nkeyed:\n%s\npositional:\n%s", keyed, positional)
+	}
+}
+
+func TestQueryDiffPrev(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	const query = "{ books { title } }"
+
+	stdout, stderr, err := runApp(t, app, "query", "--diff-prev", query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stderr, "no previous result")
+	assertContains(t, stdout, "Cosmos")
+
+	postGraphQL(t, ts.URL, `mutation { addBook(input: {title: "Neuromancer", authorName: "William Gibson"}) { id } }`)
+
+	stdout, _, err = runApp(t, app, "query", "--diff-prev", "--diff-format", "markdown", query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "**1 added, 0 removed, 0 changed**", `+     [4]: {"title":"Neuromancer"}`)
+
+	stdout, _, _ = runApp(t, app, "query", "--diff-prev", query)
+	if stdout != "no differences\n" {
+		t.Errorf("unchanged result: %q", stdout)
+	}
+
+	// Other variables are a different operation with no previous result.
+	_, stderr, _ = runApp(t, app, "query", "--diff-prev", "--var", "genre=SCIENCE", `query($genre: Genre) { books(genre: $genre) { title } }`)
+	assertContains(t, stderr, "no previous result")
+}
+
+func TestQueryCompareURL(t *testing.T) {
+	before, after := newTestServer(t), newTestServer(t)
+	postGraphQL(t, after.URL, `mutation { archiveBooks(ids: ["b2"]) }`)
+	app := httpApp(t, before.URL)
+
+	stdout, _, err := runApp(t, app, "query", "--compare-url", after.URL, "--diff-format", "json-patch", "{ books { id } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"op": "remove"`)
+	sent := before.Requests()
+
+	if _, _, err := runApp(t, app, "query", "--compare-url", after.URL, "--diff-prev", "{ books { id } }"); err == nil {
+		t.Error("expected --diff-prev with --compare-url to fail")
+	}
+	if _, _, err := runApp(t, app, "query", "--compare-url", after.URL, "--diff-format", "yaml", "{ books { id } }"); err == nil {
+		t.Error("expected an unknown --diff-format to fail")
+	}
+	if before.Requests() != sent {
+		t.Errorf("invalid diff flags reached the endpoint: %d requests", before.Requests()-sent)
+	}
+}
+
+func postGraphQL(t *testing.T, url, query string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// applyPatchOp applies one RFC 6902 add, remove, or replace to doc.
+func applyPatchOp(t *testing.T, doc interface{}, op map[string]interface{}) interface{} {
+	t.Helper()
+	path, _ := op["path"].(string)
+	if path == "" {
+		return op["value"]
+	}
+	tokens := strings.Split(path[1:], "/")
+	var apply func(node interface{}, tokens []string) interface{}
+	apply = func(node interface{}, tokens []string) interface{} {
+		tok := strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[0])
+		last := len(tokens) == 1
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if !last {
+				n[tok] = apply(n[tok], tokens[1:])
+			} else if op["op"] == "remove" {
+				delete(n, tok)
+			} else {
+				n[tok] = op["value"]
+			}
+			return n
+		case []interface{}:
+			i := len(n)
+			if tok != "-" {
+				var err error
+				if i, err = strconv.Atoi(tok); err != nil || i > len(n) {
+					t.Fatalf("bad index in %s", path)
+				}
+			}
+			switch {
+			case !last:
+				n[i] = apply(n[i], tokens[1:])
+			case op["op"] == "add":
+				n = append(n[:i], append([]interface{}{op["value"]}, n[i:]...)...)
+			case op["op"] == "remove":
+				n = append(n[:i], n[i+1:]...)
+			default:
+				n[i] = op["value"]
+			}
+			return n
+		}
+		t.Fatalf("path %s does not exist", path)
+		return nil
+	}
+	return apply(doc, tokens)
+}
+
+// sortByName orders every array of named objects by name.
+func sortByName(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = sortByName(child)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = sortByName(child)
+		}
+		sort.SliceStable(val, func(i, j int) bool {
+			a, _ := val[i].(map[string]interface{})
+			b, _ := val[j].(map[string]interface{})
+			an, _ := a["name"].(string)
+			bn, _ := b["name"].(string)
+			return an < bn
+		})
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// defaultDryRunField is the variable or input field set by --server-dry-run
+// when Config.DryRunField is empty.
+const defaultDryRunField = "dryRun"
+
+// dryRunField returns the field --server-dry-run sets for cfg.
+func dryRunField(cfg *Config) string {
+	if cfg.DryRunField != "" {
+		return cfg.DryRunField
+	}
+	return defaultDryRunField
+}
+
+// applyServerDryRun sets field to true for the server's dry-run convention:
+// the operation's $field variable if it declares and passes one, otherwise
+// field on each passed input-object variable whose type declares it. Input
+// types are introspected through d, never read from a cache, so a field the
+// schema has since dropped is not trusted. It returns where the flag was set,
+// e.g. ["$input.dryRun"], and refuses with an error when there is nowhere to
+// set it, so the mutation is never sent for real. variables must not be nil.
+func applyServerDryRun(ctx context.Context, d *Describer, mutation, operationName, field string, variables map[string]interface{}) ([]string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: mutation})
+	if err != nil {
+		return nil, fmt.Errorf("--server-dry-run: cannot parse mutation: %w", err)
+	}
+	var op *ast.OperationDefinition
+	for _, o := range doc.Operations {
+		if operationName == "" || o.Name == operationName {
+			op = o
+			break
+		}
+	}
+	if op == nil || op.Operation != ast.Mutation {
+		return nil, fmt.Errorf("--server-dry-run: no mutation to run")
+	}
+
+	used := usedVariables(doc, op)
+	for _, v := range op.VariableDefinitions {
+		if v.Variable == field && used[field] {
+			variables[field] = true
+			return []string{"$" + field}, nil
+		}
+	}
+
+	var set, checked []string
+	for _, v := range op.VariableDefinitions {
+		if !used[v.Variable] {
+			continue
+		}
+		typeName := v.Type.Name()
+		typeInfo, err := d.fetchRemote(ctx, typeName)
+		if err != nil {
+			return nil, fmt.Errorf("--server-dry-run: %w", err)
+		}
+		if kind, _ := typeInfo["kind"].(string); kind != "INPUT_OBJECT" {
+			continue
+		}
+		checked = append(checked, typeName)
+		if !hasInputField(typeInfo, field) {
+			continue
+		}
+		value, ok := variables[v.Variable].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("--server-dry-run: $%s must be an object to set %s on it", v.Variable, field)
+		}
+		value[field] = true
+		set = append(set, "$"+v.Variable+"."+field)
+	}
+	if len(set) == 0 {
+		where := "passes no input object variables"
+		if len(checked) > 0 {
+			where = "none of its input types (" + strings.Join(checked, ", ") + ") declare it"
+		}
+		return nil, fmt.Errorf("--server-dry-run: the mutation passes no $%s variable to a field and %s; refusing to run it for real", field, where)
+	}
+	return set, nil
+}
+
+// usedVariables returns the variables op passes as arguments, in its own
+// selections, the fragments it spreads, or their directives. A variable that
+// is only declared has no effect on the server.
+func usedVariables(doc *ast.QueryDocument, op *ast.OperationDefinition) map[string]bool {
+	used := map[string]bool{}
+	var value func(v *ast.Value)
+	value = func(v *ast.Value) {
+		if v == nil {
+			return
+		}
+		if v.Kind == ast.Variable {
+			used[v.Raw] = true
+		}
+		for _, child := range v.Children {
+			value(child.Value)
+		}
+	}
+	directives := func(list ast.DirectiveList) {
+		for _, dir := range list {
+			for _, arg := range dir.Arguments {
+				value(arg.Value)
+			}
+		}
+	}
+	spread := map[string]bool{}
+	var walk func(set ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch s := sel.(type) {
+			case *ast.Field:
+				for _, arg := range s.Arguments {
+					value(arg.Value)
+				}
+				directives(s.Directives)
+				walk(s.SelectionSet)
+			case *ast.InlineFragment:
+				directives(s.Directives)
+				walk(s.SelectionSet)
+			case *ast.FragmentSpread:
+				directives(s.Directives)
+				if frag := doc.Fragments.ForName(s.Name); frag != nil && !spread[s.Name] {
+					spread[s.Name] = true
+					walk(frag.SelectionSet)
+				}
+			}
+		}
+	}
+	directives(op.Directives)
+	walk(op.SelectionSet)
+	return used
+}
+
+func hasInputField(typeInfo map[string]interface{}, name string) bool {
+	fields, _ := typeInfo["inputFields"].([]interface{})
+	for _, f := range fields {
+		if fm, ok := f.(map[string]interface{}); ok && fm["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// markDryRun records under extensions.dryRun that result came from a dry run,
+// so saved or piped JSON can't be mistaken for a real change.
+func markDryRun(result map[string]interface{}, set []string) {
+	if result == nil {
+		return
+	}
+	ext, _ := result["extensions"].(map[string]interface{})
+	if ext == nil {
+		ext = map[string]interface{}{}
+		result["extensions"] = ext
+	}
+	ext["dryRun"] = map[string]interface{}{"set": set}
+}
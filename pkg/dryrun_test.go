@@ -0,0 +1,161 @@
+package gqlcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// dryRunApp registers the HTTP commands for ts with a history log and
+// returns the app and the log's path.
+func dryRunApp(t *testing.T, ts *testServer) (*cli.App, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	history := filepath.Join(t.TempDir(), "history.jsonl")
+	cfg := &Config{URL: ts.URL, Format: "json", CacheDir: t.TempDir(), HintTimeout: -1}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).WithHistoryFile(history).RegisterCommands(app)
+	return app, history
+}
+
+// bookCount returns how many books ts serves.
+func bookCount(t *testing.T, ts *testServer) int {
+	t.Helper()
+	result, err := NewHTTPClient(&Config{URL: ts.URL}).Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ books { id } }"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(result["data"].(map[string]interface{})["books"].([]interface{}))
+}
+
+func TestServerDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantSet string
+	}{
+		{
+			name: "$dryRun variable",
+			args: []string{"--variables", `{"title": "Cosmos II"}`,
+				`mutation($title: String!, $dryRun: Boolean) { addBook(input: {title: $title, authorName: "Carl Sagan", dryRun: $dryRun}) { id } }`},
+			wantSet: "$dryRun",
+		},
+		{
+			name: "$input.dryRun with --input",
+			args: []string{"--input", `{"title": "Cosmos II", "authorName": "Carl Sagan"}`,
+				`mutation($input: AddBookInput!) { addBook(input: $input) { id } }`},
+			wantSet: "$input.dryRun",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t)
+			app, history := dryRunApp(t, ts)
+			before := bookCount(t, ts)
+
+			args := append([]string{"mutation", "--server-dry-run"}, tt.args...)
+			stdout, stderr, err := runApp(t, app, args...)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, stderr)
+			}
+			assertContains(t, stderr, "DRY RUN: "+tt.wantSet+" set to true")
+			assertContains(t, stdout, `"dryRun"`, tt.wantSet)
+			if n := bookCount(t, ts); n != before {
+				t.Errorf("the dry run added a book: %d, was %d", n, before)
+			}
+
+			data, err := os.ReadFile(history)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertContains(t, string(data), `"dryRun":true`)
+			stdout, _, _ = runApp(t, app, "history", "tail")
+			assertContains(t, stdout, "mutation addBook", "dry run")
+		})
+	}
+}
+
+func TestServerDryRunRefuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutation string
+		want     string
+	}{
+		{"no input object", `mutation { archiveBooks(ids: ["b1"]) }`, "passes no input object variables"},
+		{"input type without the field", `mutation($ids: [ID!]!) { archiveBooks(ids: $ids) }`, "passes no input object variables"},
+		{"declared but unused $dryRun", `mutation($dryRun: Boolean) { archiveBooks(ids: ["b1"]) }`, "passes no $dryRun variable to a field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t)
+			app, history := dryRunApp(t, ts)
+			before := bookCount(t, ts)
+
+			_, _, err := runApp(t, app, "mutation", "--server-dry-run", "--variables", `{"ids": ["b1"]}`, tt.mutation)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want a refusal mentioning %q", err, tt.want)
+			}
+			if n := bookCount(t, ts); n != before {
+				t.Errorf("the refused mutation ran: %d books, was %d", n, before)
+			}
+			if data, _ := os.ReadFile(history); strings.Contains(string(data), `"dryRun":true`) {
+				t.Error("a refused mutation was recorded as a dry run")
+			}
+		})
+	}
+}
+
+// TestServerDryRunIgnoresCache checks the input type is introspected even
+// when the schema cache holds a stale copy of it.
+func TestServerDryRunIgnoresCache(t *testing.T) {
+	ts := newTestServer(t)
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: ts.URL, Format: "json", CacheDir: t.TempDir(), HintTimeout: -1}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).RegisterCommands(app)
+
+	stale := map[string]interface{}{"kind": "INPUT_OBJECT", "name": "AddBookInput", "inputFields": []interface{}{
+		map[string]interface{}{"name": "title"},
+	}}
+	if err := NewSchemaCache(cfg.CacheDir).SaveType(ts.URL, "AddBookInput", stale); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := runApp(t, app, "mutation", "--server-dry-run", "--input", `{"title": "X", "authorName": "Y"}`,
+		`mutation($input: AddBookInput!) { addBook(input: $input) { id } }`)
+	if err != nil {
+		t.Fatalf("the stale cached type was used: %v", err)
+	}
+	assertContains(t, stderr, "DRY RUN: $input.dryRun")
+}
+
+func TestApplyServerDryRunNeedsUsedVariables(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	d := NewDescriber(e)
+	tests := []struct {
+		mutation string
+		want     string // "" means refused
+	}{
+		{`mutation($dryRun: Boolean, $input: AddBookInput!) { addBook(input: $input) { id } }`, "$input.dryRun"},
+		{`mutation($dryRun: Boolean) { addBook(input: {title: "x", authorName: "y", dryRun: $dryRun}) { id } }`, "$dryRun"},
+		{`mutation($dryRun: Boolean) { ...Add } fragment Add on Mutation { addBook(input: {title: "x", authorName: "y", dryRun: $dryRun}) { id } }`, "$dryRun"},
+		{`mutation($dryRun: Boolean) { archiveBooks(ids: ["b1"]) }`, ""},
+		{`mutation($input: AddBookInput!) { archiveBooks(ids: ["b1"]) }`, ""},
+	}
+	for _, tt := range tests {
+		vars := map[string]interface{}{"input": map[string]interface{}{}}
+		set, err := applyServerDryRun(context.Background(), d, tt.mutation, "", "dryRun", vars)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: set %v, want a refusal", tt.mutation, set)
+			}
+			continue
+		}
+		if err != nil || len(set) != 1 || set[0] != tt.want {
+			t.Errorf("%s: got %v, %v; want %s", tt.mutation, set, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package gqlcli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// EndpointRecord is the identity recorded for an endpoint on first use.
+type EndpointRecord struct {
+	URL         string   `json:"url"`
+	Fingerprint string   `json:"fingerprint"`
+	TypeCount   int      `json:"typeCount"`
+	RootFields  []string `json:"rootFields"`
+	// Probe is the cheap root-type check; see probeEndpoint.
+	Probe     string    `json:"probe,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastUsed  time.Time `json:"lastUsed"`
+}
+
+// EndpointRegistry persists endpoint fingerprints in {dir}/endpoints.json
+// (trust on first use). It shares its directory with SchemaCache.
+type EndpointRegistry struct {
+	path string
+}
+
+// NewEndpointRegistry creates a registry stored in dir.
+// An empty dir uses DefaultCacheDir.
+func NewEndpointRegistry(dir string) *EndpointRegistry {
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	return &EndpointRegistry{path: filepath.Join(dir, "endpoints.json")}
+}
+
+// List returns all known endpoints sorted by URL.
+func (r *EndpointRegistry) List() ([]EndpointRecord, error) {
+	records, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]EndpointRecord, 0, len(records))
+	for _, rec := range records {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out, nil
+}
+
+// Get returns the record for url, or nil if the endpoint has not been seen.
+func (r *EndpointRegistry) Get(url string) (*EndpointRecord, error) {
+	records, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := records[url]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+// Put stores rec, replacing any previous record for the same URL.
+func (r *EndpointRegistry) Put(rec EndpointRecord) error {
+	records, err := r.load()
+	if err != nil {
+		return err
+	}
+	records[rec.URL] = rec
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return fmt.Errorf("failed to create endpoints directory: %w", err)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode endpoints: %w", err)
+	}
+	if err := writeFileAtomic(r.path, data); err != nil {
+		return fmt.Errorf("failed to write endpoints: %w", err)
+	}
+	return nil
+}
+
+func (r *EndpointRegistry) load() (map[string]EndpointRecord, error) {
+	records := map[string]EndpointRecord{}
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoints: %w", err)
+	}
+	if err := decodeJSON(data, &records); err != nil {
+		return nil, fmt.Errorf("corrupt endpoints file %s: %w", r.path, err)
+	}
+	return records, nil
+}
+
+// endpointProbeQuery is the cheap check run on every use. The full
+// fingerprint is only taken when its answer changes.
+const endpointProbeQuery = `{ __schema { queryType { name } mutationType { name } } }`
+
+// probeEndpoint returns the endpoint's root type names, e.g. "Query,Mutation".
+func probeEndpoint(ctx context.Context, c *HTTPClient) (string, error) {
+	result, err := c.executeOperation(ctx, endpointProbeQuery, nil, "")
+	if err != nil {
+		return "", err
+	}
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid introspection response")
+	}
+	var names []string
+	for _, key := range []string{"queryType", "mutationType"} {
+		rt, _ := schema[key].(map[string]interface{})
+		name, _ := rt["name"].(string)
+		names = append(names, name)
+	}
+	return strings.Join(names, ","), nil
+}
+
+// endpointIdentityQuery fetches just enough of the schema to fingerprint it.
+const endpointIdentityQuery = `{ __schema { types { name } queryType { name fields { name } } mutationType { name fields { name } } } }`
+
+// fingerprintEndpoint queries url's identity and returns a fresh record.
+func fingerprintEndpoint(ctx context.Context, c *HTTPClient) (*EndpointRecord, error) {
+	result, err := c.executeOperation(ctx, endpointIdentityQuery, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid introspection response")
+	}
+	types, _ := schema["types"].([]interface{})
+
+	var roots []string
+	for _, key := range []string{"queryType", "mutationType"} {
+		rt, ok := schema[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typeName, _ := rt["name"].(string)
+		fields, _ := rt["fields"].([]interface{})
+		for _, f := range fields {
+			if fm, ok := f.(map[string]interface{}); ok {
+				name, _ := fm["name"].(string)
+				roots = append(roots, typeName+"."+name)
+			}
+		}
+	}
+	sort.Strings(roots)
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", strings.Join(roots, ","), len(types))))
+	return &EndpointRecord{
+		URL:         c.config.URL,
+		Fingerprint: hex.EncodeToString(sum[:8]),
+		TypeCount:   len(types),
+		RootFields:  roots,
+	}, nil
+}
+
+// identityChangedDrastically reports whether cur looks like a different API
+// rather than the same one having evolved: the type count moved by more than
+// a quarter, or fewer than half of the root fields are shared.
+func identityChangedDrastically(prev, cur *EndpointRecord) bool {
+	if prev.Fingerprint == cur.Fingerprint {
+		return false
+	}
+	diff := prev.TypeCount - cur.TypeCount
+	if diff < 0 {
+		diff = -diff
+	}
+	if prev.TypeCount > 0 && float64(diff)/float64(prev.TypeCount) > 0.25 {
+		return true
+	}
+	shared := 0
+	prevSet := make(map[string]bool, len(prev.RootFields))
+	for _, f := range prev.RootFields {
+		prevSet[f] = true
+	}
+	for _, f := range cur.RootFields {
+		if prevSet[f] {
+			shared++
+		}
+	}
+	total := len(prev.RootFields) + len(cur.RootFields) - shared
+	return total > 0 && float64(shared)/float64(total) < 0.5
+}
+
+// checkEndpointIdentity records the endpoint on first use and warns when its
+// identity has changed drastically since. Mutations are refused in that case
+// unless accept is set. Each use costs one cheap probe; the schema is only
+// fingerprinted on first use and when the probe's answer changes. Failures to
+// reach the endpoint are left to the real request, and failures to read or
+// write the registry only produce a warning.
+func (b *CLIBuilder) checkEndpointIdentity(ctx context.Context, c *HTTPClient, isMutation, accept bool) error {
+	reg := NewEndpointRegistry(b.config.CacheDir)
+	probe, err := probeEndpoint(ctx, c)
+	if err != nil {
+		return nil
+	}
+	prev, err := reg.Get(c.config.URL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	if prev != nil && prev.Probe == probe {
+		prev.LastUsed = now
+		saveEndpointRecord(reg, *prev)
+		return nil
+	}
+	cur, err := fingerprintEndpoint(ctx, c)
+	if err != nil {
+		return nil
+	}
+	cur.Probe = probe
+	cur.FirstSeen, cur.LastUsed = now, now
+	if prev != nil {
+		cur.FirstSeen = prev.FirstSeen
+		if identityChangedDrastically(prev, cur) {
+			fmt.Fprintf(os.Stderr, "warning: endpoint identity changed since last use (was %d types, now %d) — is this the right environment?\n",
+				prev.TypeCount, cur.TypeCount)
+			if !accept {
+				if isMutation {
+					return cli.Exit("refusing to run a mutation against a changed endpoint; re-run with --accept-endpoint-change if this is intended", 1)
+				}
+				// Keep the old record so the warning repeats until the change is accepted.
+				prev.LastUsed = now
+				saveEndpointRecord(reg, *prev)
+				return nil
+			}
+		}
+	}
+	saveEndpointRecord(reg, *cur)
+	return nil
+}
+
+// saveEndpointRecord stores rec, warning instead of failing: the registry is
+// bookkeeping and must never cost the user their operation.
+func saveEndpointRecord(reg *EndpointRegistry, rec EndpointRecord) {
+	if err := reg.Put(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record endpoint identity: %v\n", err)
+	}
+}
+
+// GetEndpointsCommand returns the endpoints command for inspecting known endpoints.
+func (b *CLIBuilder) GetEndpointsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "endpoints",
+		Usage: "Inspect endpoints recorded on first use",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List known endpoints with their fingerprints and last-used times",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "cache-dir",
+						Usage:   "Cache directory (default: ~/.gqlcli/cache)",
+						Value:   b.config.CacheDir,
+						EnvVars: []string{"GQLCLI_CACHE_DIR"},
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Output format: table (default), json, json-pretty, toon, plain",
+						Value:   "table",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					records, err := NewEndpointRegistry(c.String("cache-dir")).List()
+					if err != nil {
+						return err
+					}
+					rows := make([]interface{}, 0, len(records))
+					for _, rec := range records {
+						rows = append(rows, map[string]interface{}{
+							"url":         rec.URL,
+							"fingerprint": rec.Fingerprint,
+							"types":       float64(rec.TypeCount),
+							"first_seen":  rec.FirstSeen.Format(time.RFC3339),
+							"last_used":   rec.LastUsed.Format(time.RFC3339),
+						})
+					}
+					formatter, err := b.formatReg.Get(c.String("format"))
+					if err != nil {
+						return err
+					}
+					out, err := formatter.Format(map[string]interface{}{"endpoints": rows})
+					if err != nil {
+						return err
+					}
+					fmt.Println(out)
+					return nil
+				},
+			},
+		},
+	}
+}
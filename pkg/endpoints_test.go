@@ -0,0 +1,124 @@
+package gqlcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckEndpointIdentityProbesFirst(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := &Config{URL: srv.URL, CacheDir: t.TempDir(), HintTimeout: -1}
+	b, c := NewCLIBuilder(cfg), NewHTTPClient(cfg)
+
+	if err := b.checkEndpointIdentity(context.Background(), c, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Requests() != 2 {
+		t.Errorf("first use sent %d requests, want the probe and the fingerprint", srv.Requests())
+	}
+	rec, err := NewEndpointRegistry(cfg.CacheDir).Get(srv.URL)
+	if err != nil || rec == nil {
+		t.Fatalf("no record: %v", err)
+	}
+	if rec.Probe != "Query,Mutation" || rec.TypeCount == 0 || rec.Fingerprint == "" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+
+	if err := b.checkEndpointIdentity(context.Background(), c, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Requests() != 3 {
+		t.Errorf("a known endpoint took %d more requests, want only the probe", srv.Requests()-2)
+	}
+}
+
+func TestCheckEndpointIdentityProbeChange(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := &Config{URL: srv.URL, CacheDir: t.TempDir(), HintTimeout: -1}
+	b, c := NewCLIBuilder(cfg), NewHTTPClient(cfg)
+	reg := NewEndpointRegistry(cfg.CacheDir)
+	if err := reg.Put(EndpointRecord{URL: srv.URL, Probe: "Query,", Fingerprint: "0000", TypeCount: 400, RootFields: []string{"Query.other"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	_, stderr := captureOutput(t, func() {
+		err = b.checkEndpointIdentity(context.Background(), c, true, false)
+	})
+	if err == nil || !strings.Contains(err.Error(), "--accept-endpoint-change") {
+		t.Errorf("mutation against a changed endpoint: got %v", err)
+	}
+	assertContains(t, stderr, "endpoint identity changed since last use (was 400 types")
+
+	captureOutput(t, func() {
+		err = b.checkEndpointIdentity(context.Background(), c, true, true)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, _ := reg.Get(srv.URL); rec.Probe != "Query,Mutation" || rec.TypeCount == 400 {
+		t.Errorf("accepted change not recorded: %+v", rec)
+	}
+}
+
+func TestCheckEndpointIdentityRegistryFailureIsBestEffort(t *testing.T) {
+	srv := newTestServer(t)
+	blocker := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocker, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{URL: srv.URL, CacheDir: blocker, HintTimeout: -1}
+
+	var err error
+	_, stderr := captureOutput(t, func() {
+		err = NewCLIBuilder(cfg).checkEndpointIdentity(context.Background(), NewHTTPClient(cfg), true, false)
+	})
+	if err != nil {
+		t.Errorf("an unwritable registry failed the operation: %v", err)
+	}
+	assertContains(t, stderr, "warning: failed to read endpoints")
+}
+
+func TestSaveEndpointRecordWarns(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocker, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := captureOutput(t, func() {
+		saveEndpointRecord(&EndpointRegistry{path: filepath.Join(blocker, "endpoints.json")}, EndpointRecord{URL: "http://localhost/graphql"})
+	})
+	assertContains(t, stderr, "warning: could not record endpoint identity")
+}
+
+func TestEndpointRegistryConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewEndpointRegistry(dir)
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- reg.Put(EndpointRecord{URL: "http://localhost/graphql", Fingerprint: "f"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "endpoints.json" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only endpoints.json", names)
+	}
+}
@@ -0,0 +1,180 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorCodeEvent describes one GraphQL error carrying extensions.code.
+type ErrorCodeEvent struct {
+	Code       string // upper-cased extensions.code
+	Message    string
+	Extensions map[string]interface{}
+	// Attempt is 1 for the first response to an operation, 2 after one retry, ...
+	Attempt int
+	// Config is a copy of the client's configuration. A handler may update
+	// Token before asking for a retry; the client then uses it for the retry
+	// and every later operation.
+	Config *Config
+}
+
+// ErrorCodeDecision is what an ErrorCodeHandler wants done about an error.
+type ErrorCodeDecision struct {
+	// Retry sends the operation again after Wait, up to maxErrorCodeAttempts
+	// attempts in total.
+	Retry bool
+	Wait  time.Duration
+	// Suggestion is added to the error's extensions as "suggestion" when the
+	// operation is not retried.
+	Suggestion string
+}
+
+// ErrorCodeHandler reacts to a GraphQL error with a given extensions.code.
+type ErrorCodeHandler func(ctx context.Context, ev ErrorCodeEvent) ErrorCodeDecision
+
+// maxErrorCodeAttempts bounds how often an operation is sent when handlers
+// keep asking for retries.
+const maxErrorCodeAttempts = 3
+
+// maxRateLimitWait is the longest retryAfter that is waited out. A longer one
+// fails at once, suggesting when to try again.
+const maxRateLimitWait = 30 * time.Second
+
+var (
+	errorCodeMu       sync.RWMutex
+	errorCodeHandlers = map[string]ErrorCodeHandler{
+		"UNAUTHENTICATED":     handleUnauthenticated,
+		"RATE_LIMITED":        handleRateLimited,
+		"RATE_LIMIT_EXCEEDED": handleRateLimited,
+		"TOO_MANY_REQUESTS":   handleRateLimited,
+
+		"PERSISTED_QUERY_NOT_FOUND":     handlePersistedQueryNotFound,
+		"PERSISTED_QUERY_NOT_SUPPORTED": handlePersistedQueryNotFound,
+	}
+)
+
+// RegisterErrorCodeHandler sets the handler the HTTP client runs for GraphQL
+// errors whose extensions.code equals code (case-insensitive), replacing any
+// built-in handler for it. Errors with codes that have no handler are
+// returned unchanged.
+func RegisterErrorCodeHandler(code string, h ErrorCodeHandler) {
+	errorCodeMu.Lock()
+	defer errorCodeMu.Unlock()
+	errorCodeHandlers[strings.ToUpper(code)] = h
+}
+
+func lookupErrorCodeHandler(code string) ErrorCodeHandler {
+	errorCodeMu.RLock()
+	defer errorCodeMu.RUnlock()
+	return errorCodeHandlers[strings.ToUpper(code)]
+}
+
+// dispatchErrorCodes runs the registered handler for each coded error in
+// errs. It returns whether to retry, how long to wait first, and the code
+// that asked for the longest wait. resend is false when the operation must
+// not be sent again (a mutation whose response carried data); handlers are
+// still consulted for suggestions. When not retrying, handler suggestions
+// are attached to the errors' extensions.
+func dispatchErrorCodes(ctx context.Context, cfg *Config, errs []interface{}, attempt int, resend bool) (bool, time.Duration, string) {
+	type pending struct {
+		em         map[string]interface{}
+		ext        map[string]interface{}
+		suggestion string
+	}
+	var notes []pending
+	retry, refused := false, false
+	var wait time.Duration
+	var waitCode string
+	for _, e := range errs {
+		em, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ext, _ := em["extensions"].(map[string]interface{})
+		code, _ := ext["code"].(string)
+		h := lookupErrorCodeHandler(code)
+		if h == nil {
+			continue
+		}
+		msg, _ := em["message"].(string)
+		d := h(ctx, ErrorCodeEvent{Code: strings.ToUpper(code), Message: msg, Extensions: ext, Attempt: attempt, Config: cfg})
+		if d.Retry && !resend {
+			refused = true
+		} else if d.Retry && attempt < maxErrorCodeAttempts {
+			if !retry || d.Wait > wait {
+				wait, waitCode = d.Wait, strings.ToUpper(code)
+			}
+			retry = true
+		}
+		if d.Suggestion != "" {
+			notes = append(notes, pending{em: em, ext: ext, suggestion: d.Suggestion})
+		}
+	}
+	if retry {
+		return true, wait, waitCode
+	}
+	if refused {
+		for _, e := range errs {
+			if em, ok := e.(map[string]interface{}); ok {
+				ext, _ := em["extensions"].(map[string]interface{})
+				notes = append(notes, pending{em: em, ext: ext, suggestion: mutationNotResentSuggestion})
+				break
+			}
+		}
+	}
+	for _, n := range notes {
+		if n.ext == nil {
+			n.ext = map[string]interface{}{}
+			n.em["extensions"] = n.ext
+		}
+		if prev, _ := n.ext["suggestion"].(string); prev != "" {
+			// A handler's suggestion and the refused-resend note on one error.
+			n.suggestion = prev + "; " + n.suggestion
+		}
+		n.ext["suggestion"] = n.suggestion
+	}
+	return false, 0, ""
+}
+
+// mutationNotResentSuggestion explains a retry that was refused because the
+// mutation may already have run.
+const mutationNotResentSuggestion = "the mutation was not re-sent because its response carried data, so it may have run; " +
+	"check its effects, then run it again (or pass --retry-mutations)"
+
+// handleUnauthenticated refreshes the token through Config.OnUnauthorized and
+// retries once; otherwise it suggests logging in.
+func handleUnauthenticated(ctx context.Context, ev ErrorCodeEvent) ErrorCodeDecision {
+	if ev.Config.OnUnauthorized == nil || ev.Attempt > 1 {
+		return ErrorCodeDecision{Suggestion: "not authenticated: run login (or supply a valid token) and try again"}
+	}
+	token, err := ev.Config.OnUnauthorized(ctx)
+	if err != nil {
+		return ErrorCodeDecision{Suggestion: fmt.Sprintf("not authenticated and the token refresh failed: %v", err)}
+	}
+	ev.Config.Token = token
+	return ErrorCodeDecision{Retry: true}
+}
+
+// handleRateLimited retries after extensions.retryAfter seconds, or with
+// exponential backoff from one second when the server gives no hint. A
+// retryAfter beyond maxRateLimitWait is not waited out.
+func handleRateLimited(_ context.Context, ev ErrorCodeEvent) ErrorCodeDecision {
+	_, wait := isRateLimited(map[string]interface{}{"extensions": ev.Extensions})
+	if wait == 0 {
+		wait = time.Second << (ev.Attempt - 1)
+	}
+	return ErrorCodeDecision{
+		Retry:      wait <= maxRateLimitWait,
+		Wait:       wait,
+		Suggestion: fmt.Sprintf("rate limited: try again in %s", wait.Round(time.Second)),
+	}
+}
+
+// handlePersistedQueryNotFound retries once; the client then sends the full
+// query text along with its hash, which registers it with the server.
+func handlePersistedQueryNotFound(_ context.Context, ev ErrorCodeEvent) ErrorCodeDecision {
+	return ErrorCodeDecision{Retry: ev.Attempt == 1}
+}
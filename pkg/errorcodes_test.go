@@ -0,0 +1,163 @@
+package gqlcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandleRateLimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		ext       map[string]interface{}
+		attempt   int
+		wantRetry bool
+		wantWait  time.Duration
+	}{
+		{"retryAfter seconds", map[string]interface{}{"retryAfter": json.Number("2")}, 1, true, 2 * time.Second},
+		{"retryAfter string", map[string]interface{}{"retryAfter": "0.5"}, 1, true, 500 * time.Millisecond},
+		{"backoff first", map[string]interface{}{}, 1, true, time.Second},
+		{"backoff third", map[string]interface{}{}, 3, true, 4 * time.Second},
+		{"over the cap", map[string]interface{}{"retryAfter": json.Number("3600")}, 1, false, time.Hour},
+	}
+	for _, tt := range tests {
+		d := handleRateLimited(context.Background(), ErrorCodeEvent{Code: "RATE_LIMITED", Extensions: tt.ext, Attempt: tt.attempt, Config: &Config{}})
+		if d.Retry != tt.wantRetry || d.Wait != tt.wantWait {
+			t.Errorf("%s: got retry=%v wait=%s, want retry=%v wait=%s", tt.name, d.Retry, d.Wait, tt.wantRetry, tt.wantWait)
+		}
+		if !strings.HasPrefix(d.Suggestion, "rate limited: try again in") {
+			t.Errorf("%s: suggestion %q", tt.name, d.Suggestion)
+		}
+	}
+}
+
+func TestHandleUnauthenticated(t *testing.T) {
+	ctx := context.Background()
+
+	d := handleUnauthenticated(ctx, ErrorCodeEvent{Attempt: 1, Config: &Config{}})
+	if d.Retry || !strings.Contains(d.Suggestion, "run login") {
+		t.Errorf("without OnUnauthorized: %+v", d)
+	}
+
+	cfg := &Config{Token: "old", OnUnauthorized: func(context.Context) (string, error) { return "new", nil }}
+	d = handleUnauthenticated(ctx, ErrorCodeEvent{Attempt: 1, Config: cfg})
+	if !d.Retry || cfg.Token != "new" {
+		t.Errorf("refresh: %+v, token %q", d, cfg.Token)
+	}
+	if d = handleUnauthenticated(ctx, ErrorCodeEvent{Attempt: 2, Config: cfg}); d.Retry {
+		t.Errorf("retried a second time: %+v", d)
+	}
+
+	cfg.OnUnauthorized = func(context.Context) (string, error) { return "", errors.New("refresh token expired") }
+	d = handleUnauthenticated(ctx, ErrorCodeEvent{Attempt: 1, Config: cfg})
+	if d.Retry || !strings.Contains(d.Suggestion, "refresh token expired") {
+		t.Errorf("failed refresh: %+v", d)
+	}
+}
+
+func TestHandlePersistedQueryNotFound(t *testing.T) {
+	if d := handlePersistedQueryNotFound(context.Background(), ErrorCodeEvent{Attempt: 1}); !d.Retry || d.Wait != 0 {
+		t.Errorf("attempt 1: %+v, want an immediate retry", d)
+	}
+	if d := handlePersistedQueryNotFound(context.Background(), ErrorCodeEvent{Attempt: 2}); d.Retry {
+		t.Errorf("attempt 2: %+v, want no retry", d)
+	}
+}
+
+func TestDispatchErrorCodesRefusedResend(t *testing.T) {
+	errs := []interface{}{map[string]interface{}{
+		"message":    "slow down",
+		"extensions": map[string]interface{}{"code": "RATE_LIMITED", "retryAfter": json.Number("1")},
+	}}
+	retry, _, _ := dispatchErrorCodes(context.Background(), &Config{}, errs, 1, false)
+	if retry {
+		t.Fatal("retried although resend was refused")
+	}
+	suggestion := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})["suggestion"].(string)
+	assertContains(t, suggestion, "rate limited: try again in 1s", "not re-sent", "--retry-mutations")
+}
+
+// codedErrorServer answers every request with body and counts requests.
+func codedErrorServer(t *testing.T, body string) (*httptest.Server, *int32) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func TestRateLimitedMutationRetries(t *testing.T) {
+	const limited = `"errors":[{"message":"slow down","extensions":{"code":"RATE_LIMITED","retryAfter":0.01}}]`
+	const mutation = `mutation { addBook(input: {title: "x", authorName: "y"}) { id } }`
+	tests := []struct {
+		name           string
+		body           string
+		retryMutations bool
+		wantRequests   int32
+	}{
+		{"no data", `{"data":null,` + limited + `}`, false, maxErrorCodeAttempts},
+		{"partial data", `{"data":{"addBook":{"id":"b9"}},` + limited + `}`, false, 1},
+		{"partial data opted in", `{"data":{"addBook":{"id":"b9"}},` + limited + `}`, true, maxErrorCodeAttempts},
+	}
+	for _, tt := range tests {
+		srv, n := codedErrorServer(t, tt.body)
+		c := NewHTTPClient(&Config{URL: srv.URL, HintTimeout: -1, RetryMutations: tt.retryMutations})
+		_, stderr := captureOutput(t, func() {
+			if _, err := c.ExecuteMutation(context.Background(), ExecutionModeHTTP, MutationOptions{Mutation: mutation}); err == nil {
+				t.Errorf("%s: expected the rate limit error", tt.name)
+			}
+		})
+		if *n != tt.wantRequests {
+			t.Errorf("%s: sent %d times, want %d", tt.name, *n, tt.wantRequests)
+		}
+		if want := int(tt.wantRequests) - 1; strings.Count(stderr, "warning: RATE_LIMITED; retrying in 10ms") != want {
+			t.Errorf("%s: want %d retry notices, stderr:\n%s", tt.name, want, stderr)
+		}
+	}
+}
+
+func TestRateLimitedLongWaitNotRetried(t *testing.T) {
+	srv, n := codedErrorServer(t, `{"errors":[{"message":"slow down","extensions":{"code":"RATE_LIMITED","retryAfter":120}}]}`)
+	c := NewHTTPClient(&Config{URL: srv.URL, HintTimeout: -1})
+	start := time.Now()
+	_, err := c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ books { id } }"})
+	var gqlErr *GraphQLResponseError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("got %v", err)
+	}
+	if *n != 1 || time.Since(start) > 5*time.Second {
+		t.Errorf("sent %d times in %s; a 2m retryAfter must not be waited out", *n, time.Since(start))
+	}
+	ext := gqlErr.Response["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	if ext["suggestion"] != "rate limited: try again in 2m0s" {
+		t.Errorf("suggestion %v", ext["suggestion"])
+	}
+}
+
+func TestPersistedQueries(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewHTTPClient(&Config{URL: srv.URL, HintTimeout: -1, PersistedQueries: true})
+	const q = `{ books(first: 1) { title } }`
+
+	for i, wantRequests := range []int{2, 3} {
+		result, err := c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: q})
+		if err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		if title := result["data"].(map[string]interface{})["books"].([]interface{})[0].(map[string]interface{})["title"]; title != "The Dispossessed" {
+			t.Errorf("run %d: got %v", i+1, result)
+		}
+		if srv.Requests() != wantRequests {
+			t.Errorf("run %d: %d requests in total, want %d (hash miss and full query, then hash hit)", i+1, srv.Requests(), wantRequests)
+		}
+	}
+}
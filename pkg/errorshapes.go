@@ -0,0 +1,164 @@
+package gqlcli
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErrorShapeAdapter recognizes a response whose errors don't follow the
+// GraphQL spec and returns them as a spec errors array. It returns ok=false
+// when resp doesn't have its shape.
+type ErrorShapeAdapter func(resp map[string]interface{}) (errors []interface{}, ok bool)
+
+type namedErrorShape struct {
+	name  string
+	adapt ErrorShapeAdapter
+}
+
+var (
+	errorShapeMu sync.RWMutex
+	// errorShapes are tried in order; RegisterErrorShapeAdapter prepends.
+	errorShapes = []namedErrorShape{
+		{"errors-object", adaptErrorsObject},
+		{"top-level-error", adaptTopLevelError},
+		{"rest-problem", adaptRESTProblem},
+		{"top-level-message", adaptTopLevelMessage},
+	}
+)
+
+// RegisterErrorShapeAdapter adds an adapter for a gateway's error shape, tried
+// before the built-in ones. Registering a name again replaces that adapter.
+// Built-in adapters: "errors-object", "top-level-error", "rest-problem", and
+// "top-level-message".
+func RegisterErrorShapeAdapter(name string, a ErrorShapeAdapter) {
+	errorShapeMu.Lock()
+	defer errorShapeMu.Unlock()
+	// Build a new slice: normalizeErrorShape iterates the old one unlocked.
+	shapes := make([]namedErrorShape, 0, len(errorShapes)+1)
+	shapes = append(shapes, namedErrorShape{name, a})
+	for _, s := range errorShapes {
+		if s.name != name {
+			shapes = append(shapes, s)
+		}
+	}
+	errorShapes = shapes
+}
+
+// normalizeErrorShape rewrites a response with non-spec errors into the
+// standard errors array, so they are reported instead of an empty success.
+// The payload as received is kept under extensions.originalResponse, next to
+// any extensions it had. Spec responses (an errors array, or data without
+// error keys) are returned as is.
+func normalizeErrorShape(resp map[string]interface{}) map[string]interface{} {
+	if _, ok := resp["errors"].([]interface{}); ok {
+		return resp
+	}
+	errorShapeMu.RLock()
+	shapes := errorShapes
+	errorShapeMu.RUnlock()
+
+	for _, s := range shapes {
+		errs, ok := s.adapt(resp)
+		if !ok || len(errs) == 0 {
+			continue
+		}
+		ext := map[string]interface{}{}
+		if orig, ok := resp["extensions"].(map[string]interface{}); ok {
+			for k, v := range orig {
+				ext[k] = v
+			}
+		}
+		ext["originalResponse"] = resp
+		out := map[string]interface{}{"errors": errs, "extensions": ext}
+		if data, ok := resp["data"]; ok {
+			out["data"] = data
+		}
+		return out
+	}
+	return resp
+}
+
+// adaptErrorsObject handles "errors" given as one object or a string.
+func adaptErrorsObject(resp map[string]interface{}) ([]interface{}, bool) {
+	switch e := resp["errors"].(type) {
+	case map[string]interface{}:
+		return []interface{}{shapeError(e)}, true
+	case string:
+		return []interface{}{map[string]interface{}{"message": e}}, true
+	}
+	return nil, false
+}
+
+// adaptTopLevelError handles {"error": "..."} and {"error": {"message": ...}}.
+func adaptTopLevelError(resp map[string]interface{}) ([]interface{}, bool) {
+	switch e := resp["error"].(type) {
+	case map[string]interface{}:
+		return []interface{}{shapeError(e)}, true
+	case string:
+		return []interface{}{withCode(map[string]interface{}{"message": e}, resp["code"])}, true
+	}
+	return nil, false
+}
+
+// adaptRESTProblem handles REST-style {"code": ..., "detail": ...} bodies,
+// including RFC 7807 problem details ({"title", "detail", "status"}).
+func adaptRESTProblem(resp map[string]interface{}) ([]interface{}, bool) {
+	detail, _ := resp["detail"].(string)
+	if detail == "" {
+		return nil, false
+	}
+	code := resp["code"]
+	if code == nil {
+		code = resp["status"]
+	}
+	return []interface{}{withCode(map[string]interface{}{"message": detail}, code)}, true
+}
+
+// adaptTopLevelMessage handles a bare {"message": "..."} with no data.
+func adaptTopLevelMessage(resp map[string]interface{}) ([]interface{}, bool) {
+	msg, _ := resp["message"].(string)
+	if msg == "" || resp["data"] != nil {
+		return nil, false
+	}
+	return []interface{}{withCode(map[string]interface{}{"message": msg}, resp["code"])}, true
+}
+
+// shapeError converts an error object to a spec error: its message comes from
+// "message", "detail", or "title", and a "code" moves to extensions.code.
+func shapeError(e map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range e {
+		out[k] = v
+	}
+	if _, ok := out["message"].(string); !ok {
+		for _, key := range []string{"detail", "title", "error"} {
+			if s, ok := e[key].(string); ok && s != "" {
+				out["message"] = s
+				break
+			}
+		}
+	}
+	if _, ok := out["message"].(string); !ok {
+		out["message"] = "unknown error"
+	}
+	code := e["code"]
+	delete(out, "code")
+	return withCode(out, code)
+}
+
+// withCode sets extensions.code on a spec error unless code is nil or the
+// error already has one. Numeric codes are stored as strings.
+func withCode(e map[string]interface{}, code interface{}) map[string]interface{} {
+	if code == nil {
+		return e
+	}
+	ext, _ := e["extensions"].(map[string]interface{})
+	if ext == nil {
+		ext = map[string]interface{}{}
+		e["extensions"] = ext
+	}
+	if _, ok := ext["code"]; !ok {
+		ext["code"] = fmt.Sprint(code)
+	}
+	return e
+}
@@ -0,0 +1,188 @@
+package gqlcli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestNormalizeErrorShape runs normalizeErrorShape over payloads captured
+// from gateways and APIs in front of GraphQL endpoints.
+func TestNormalizeErrorShape(t *testing.T) {
+	tests := []struct {
+		file     string
+		wantMsg  string // "" means the response is returned as is
+		wantCode interface{}
+	}{
+		{"aws-api-gateway.json", "Forbidden", nil},
+		{"azure-apim.json", "Access denied due to missing subscription key. Make sure to include subscription key when making requests to an API.", nil},
+		{"github.json", "Bad credentials", nil},
+		{"google-apis.json", "Request is missing required authentication credential. Expected OAuth 2 access token, login cookie or other valid authentication credential.", "401"},
+		{"shopify.json", "[API] Invalid API key or access token (unrecognized login or wrong password)", nil},
+		{"express-jwt.json", "Unauthorized", "invalid_token"},
+		{"spring-problem.json", "Failed to read request", "400"},
+		{"rest-code-detail.json", "Too many requests, retry in 30 seconds", "RATE_LIMITED"},
+		{"errors-object-with-extensions.json", "Upstream service unavailable", "SERVICE_UNAVAILABLE"},
+		{"spec.json", "", nil},
+		{"data-with-message.json", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			resp := loadErrorShape(t, tt.file)
+			got := normalizeErrorShape(resp)
+			if tt.wantMsg == "" {
+				if !reflect.DeepEqual(got, loadErrorShape(t, tt.file)) {
+					t.Errorf("a spec response was rewritten: %v", got)
+				}
+				return
+			}
+
+			errs, ok := got["errors"].([]interface{})
+			if !ok || len(errs) != 1 {
+				t.Fatalf("got errors %v, want one", got["errors"])
+			}
+			e := errs[0].(map[string]interface{})
+			if e["message"] != tt.wantMsg {
+				t.Errorf("message %q, want %q", e["message"], tt.wantMsg)
+			}
+			ext, _ := e["extensions"].(map[string]interface{})
+			if ext["code"] != tt.wantCode {
+				t.Errorf("code %v, want %v", ext["code"], tt.wantCode)
+			}
+			top := got["extensions"].(map[string]interface{})
+			if !reflect.DeepEqual(top["originalResponse"], loadErrorShape(t, tt.file)) {
+				t.Errorf("originalResponse differs from the payload: %v", top["originalResponse"])
+			}
+			if _, ok := resp["data"]; ok {
+				if _, ok := got["data"]; !ok {
+					t.Error("data was dropped")
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeErrorShapeKeepsExtensions(t *testing.T) {
+	got := normalizeErrorShape(loadErrorShape(t, "errors-object-with-extensions.json"))
+	ext := got["extensions"].(map[string]interface{})
+	if ext["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("the response's extensions were replaced: %v", ext)
+	}
+	if _, ok := ext["originalResponse"]; !ok {
+		t.Error("no originalResponse")
+	}
+	// The payload as received does not gain the originalResponse key.
+	orig := ext["originalResponse"].(map[string]interface{})
+	if _, ok := orig["extensions"].(map[string]interface{})["originalResponse"]; ok {
+		t.Error("originalResponse was added to the original payload's extensions")
+	}
+}
+
+// restoreErrorShapes puts back the built-in adapters at the end of the test.
+func restoreErrorShapes(t *testing.T) {
+	errorShapeMu.RLock()
+	saved := errorShapes
+	errorShapeMu.RUnlock()
+	t.Cleanup(func() {
+		errorShapeMu.Lock()
+		defer errorShapeMu.Unlock()
+		errorShapes = saved
+	})
+}
+
+func TestRegisterErrorShapeAdapter(t *testing.T) {
+	restoreErrorShapes(t)
+	fault := func(reason string) ErrorShapeAdapter {
+		return func(resp map[string]interface{}) ([]interface{}, bool) {
+			if _, ok := resp["fault"]; !ok {
+				return nil, false
+			}
+			return []interface{}{map[string]interface{}{"message": reason}}, true
+		}
+	}
+	message := func(resp map[string]interface{}) string {
+		return resp["errors"].([]interface{})[0].(map[string]interface{})["message"].(string)
+	}
+	apigee := map[string]interface{}{"fault": map[string]interface{}{"faultstring": "Invalid ApiKey"}, "message": "denied"}
+
+	// Without an adapter the top-level message is used.
+	if got := message(normalizeErrorShape(apigee)); got != "denied" {
+		t.Errorf("built-in adapters: got %q", got)
+	}
+
+	// A registered adapter is tried before the built-in ones.
+	RegisterErrorShapeAdapter("apigee", fault("Invalid ApiKey"))
+	errorShapeMu.RLock()
+	before := errorShapes
+	snapshot := append([]namedErrorShape(nil), before...)
+	errorShapeMu.RUnlock()
+	if got := message(normalizeErrorShape(apigee)); got != "Invalid ApiKey" {
+		t.Errorf("registered adapter: got %q", got)
+	}
+
+	// Registering the name again replaces it, and leaves a slice a reader
+	// already holds untouched.
+	RegisterErrorShapeAdapter("apigee", fault("replaced"))
+	if got := message(normalizeErrorShape(apigee)); got != "replaced" {
+		t.Errorf("replaced adapter: got %q", got)
+	}
+	if len(before) != len(snapshot) {
+		t.Fatalf("the old slice changed length")
+	}
+	for i := range before {
+		if before[i].name != snapshot[i].name {
+			t.Errorf("the old slice changed at %d: %s, was %s", i, before[i].name, snapshot[i].name)
+		}
+	}
+	names := 0
+	errorShapeMu.RLock()
+	for _, s := range errorShapes {
+		if s.name == "apigee" {
+			names++
+		}
+	}
+	errorShapeMu.RUnlock()
+	if names != 1 {
+		t.Errorf("apigee is registered %d times", names)
+	}
+}
+
+func TestNonSpecErrorsAreReported(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "errorshapes", "google-apis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	_, err = NewHTTPClient(&Config{URL: srv.URL, HintTimeout: -1}).Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ books { id } }"})
+	var gqlErr *GraphQLResponseError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("got %v, want the gateway error", err)
+	}
+	ext := gqlErr.Response["extensions"].(map[string]interface{})
+	if _, ok := ext["originalResponse"]; !ok {
+		t.Errorf("no originalResponse in %v", gqlErr.Response)
+	}
+}
+
+func loadErrorShape(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "errorshapes", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp map[string]interface{}
+	if err := decodeJSON(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
@@ -0,0 +1,242 @@
+package gqlcli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// recipe is one runnable example shown by the examples command. Recipes name
+// commands and flags as data rather than text so the tests can check them
+// against the full http and inline command sets and they never drift.
+type recipe struct {
+	topic       string
+	description string
+	command     string // command path, e.g. "endpoints list"
+	flags       []recipeFlag
+	// mode limits the recipe to "http" (CLIBuilder) or "inline"
+	// (InlineCommandSet) commands; empty means both.
+	mode string
+}
+
+type recipeFlag struct {
+	name  string
+	value string // empty for boolean flags
+}
+
+func opt(name, value string) recipeFlag { return recipeFlag{name: name, value: value} }
+
+var recipeTopics = []struct{ name, summary string }{
+	{"auth", "log in and check the current session"},
+	{"variables", "pass variables as JSON, flags, and files"},
+	{"files", "keep operations and variables in files"},
+	{"pagination", "walk cursor-paginated connections"},
+	{"formatting", "choose output formats for people, tools, and LLMs"},
+	{"ci", "cache schemas, detect breaking changes, and report results in pipelines"},
+}
+
+var recipes = []recipe{
+	{topic: "auth", description: "Log in and save a session token", command: "login", mode: "inline",
+		flags: []recipeFlag{opt("email", "me@example.com"), opt("password", "$PASSWORD")}},
+	{topic: "auth", description: "Show who the saved token belongs to", command: "whoami", mode: "inline"},
+	{topic: "auth", description: "Forget the saved token", command: "logout", mode: "inline"},
+
+	{topic: "variables", description: "Pass variables as JSON", command: "query",
+		flags: []recipeFlag{opt("query", "query($id: ID!) { book(id: $id) { title } }"), opt("variables", `{"id":"1"}`)}},
+	{topic: "variables", description: "Set variables one at a time, coerced to their declared types", command: "query",
+		flags: []recipeFlag{opt("query", "query($id: ID!, $first: Int) { book(id: $id) { title } }"), opt("var", "id=1"), opt("var", "first=10")}},
+	{topic: "variables", description: "Build a list variable from repeated flags", command: "mutation",
+		flags: []recipeFlag{opt("query", "mutation($ids: [ID!]!) { archive(ids: $ids) }"), opt("var", "ids=1"), opt("var", "ids=2")}, mode: "inline"},
+	{topic: "variables", description: "Build a list variable from repeated flags", command: "mutation",
+		flags: []recipeFlag{opt("mutation", "mutation($ids: [ID!]!) { archive(ids: $ids) }"), opt("var", "ids=1"), opt("var", "ids=2")}, mode: "http"},
+	{topic: "variables", description: "Wrap a JSON object as the $input variable", command: "mutation", mode: "http",
+		flags: []recipeFlag{opt("mutation", "mutation($input: AddBookInput!) { addBook(input: $input) { id } }"), opt("input", `{"title":"Dune"}`)}},
+
+	{topic: "files", description: "Run an operation stored in a file", command: "query", mode: "http",
+		flags: []recipeFlag{opt("query-file", "books.graphql"), opt("variables-file", "vars.json")}},
+	{topic: "files", description: "Run an operation stored in a file", command: "query", mode: "inline",
+		flags: []recipeFlag{opt("file", "books.graphql"), opt("var-file", "vars.json")}},
+	{topic: "files", description: "Read a list variable from a file, one element per line", command: "query",
+		flags: []recipeFlag{opt("query", "query($ids: [ID!]!) { books(ids: $ids) { title } }"), opt("var-file-lines", "ids=ids.txt")}},
+	{topic: "files", description: "Save the result to a file", command: "query",
+		flags: []recipeFlag{opt("query", "{ books { id title } }"), opt("format", "json"), opt("output", "books.json")}},
+	{topic: "files", description: "Preview a query-to-mutation pipeline defined in a manifest", command: "pipe", mode: "http",
+		flags: []recipeFlag{opt("manifest", "retitle.json"), opt("dry-run", "")}},
+
+	{topic: "pagination", description: "Fetch the first page of a connection", command: "query",
+		flags: []recipeFlag{opt("query", "query($after: String) { books(first: 50, after: $after) { edges { node { id title } } pageInfo { endCursor hasNextPage } } }")}},
+	{topic: "pagination", description: "Fetch the next page using the previous endCursor", command: "query",
+		flags: []recipeFlag{opt("query", "query($after: String) { books(first: 50, after: $after) { edges { node { id title } } pageInfo { endCursor hasNextPage } } }"), opt("var", "after=CURSOR")}},
+	{topic: "pagination", description: "Flatten a connection into CSV rows (edges/node wrappers are removed)", command: "query",
+		flags: []recipeFlag{opt("query", "{ books(first: 100) { edges { node { id title } } } }"), opt("format", "csv")}},
+
+	{topic: "formatting", description: "Aligned table for the terminal", command: "query",
+		flags: []recipeFlag{opt("query", "{ books { id title } }"), opt("format", "table")}},
+	{topic: "formatting", description: "Token-efficient output for LLM prompts", command: "query",
+		flags: []recipeFlag{opt("query", "{ books { id title } }"), opt("format", "toon")}},
+	{topic: "formatting", description: "Screen-reader-friendly path = value lines", command: "query",
+		flags: []recipeFlag{opt("query", "{ books { id title } }"), opt("accessible", "")}},
+	{topic: "formatting", description: "Fall back to JSON if the chosen formatter fails", command: "query",
+		flags: []recipeFlag{opt("query", "{ books { id title } }"), opt("format", "csv"), opt("format-fallback", "json")}},
+
+	{topic: "ci", description: "Cache the schema and all type descriptions for offline use", command: "warm", mode: "http",
+		flags: []recipeFlag{opt("all-types", "")}},
+	{topic: "ci", description: "Save the schema to compare against later", command: "introspect", mode: "http",
+		flags: []recipeFlag{opt("format", "json"), opt("output", "schema.json")}},
+	{topic: "ci", description: "Export types, fields, and arguments as CSV for a schema audit", command: "introspect", mode: "http",
+		flags: []recipeFlag{opt("format", "csv"), opt("output-dir", "schema-audit")}},
+	{topic: "ci", description: "Post schema changes as a markdown PR comment", command: "schema-diff", mode: "http",
+		flags: []recipeFlag{opt("against-file", "schema.json"), opt("format", "markdown")}},
+	{topic: "ci", description: "Send a health check result to a webhook, failing the job if delivery fails", command: "query",
+		flags: []recipeFlag{opt("query", "{ health { status } }"), opt("format", "json"), opt("post-result", "https://hooks.example.com/ci?status={{.data.health.status | urlquery}}")}},
+}
+
+// examplesCommand builds the examples command for mode ("http" or "inline").
+// In http mode the endpoint (--url, GRAPHQL_URL, or defaultURL) is substituted
+// into recipes for commands that take --url.
+func examplesCommand(mode, defaultURL string) *cli.Command {
+	flags := []cli.Flag{
+		&cli.BoolFlag{Name: "all", Usage: "Show recipes for every topic"},
+	}
+	if mode == "http" {
+		flags = append(flags, &cli.StringFlag{
+			Name:    "url",
+			Aliases: []string{"u"},
+			Usage:   "Endpoint to show in the recipes (env: GRAPHQL_URL)",
+			Value:   defaultURL,
+			EnvVars: []string{"GRAPHQL_URL"},
+		})
+	}
+	return &cli.Command{
+		Name:      "examples",
+		Usage:     "Show copy-pasteable recipes by topic",
+		ArgsUsage: "[TOPIC]",
+		Description: "Without a topic, lists the topics. With a topic (or --all), prints runnable commands " +
+			"for it. Recipes for commands this program does not register are left out.",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			topic := c.Args().First()
+			if topic == "" && !c.Bool("all") {
+				fmt.Println("Topics:")
+				for _, t := range recipeTopics {
+					if n := len(availableRecipes(c.App, mode, t.name)); n > 0 {
+						fmt.Printf("  %-11s %s (%d)\n", t.name, t.summary, n)
+					}
+				}
+				fmt.Printf("\nRun '%s examples TOPIC' to see the commands.\n", c.App.Name)
+				return nil
+			}
+
+			found, known := false, false
+			for _, t := range recipeTopics {
+				if topic != "" && t.name != topic {
+					continue
+				}
+				known = true
+				list := availableRecipes(c.App, mode, t.name)
+				if len(list) == 0 {
+					continue
+				}
+				found = true
+				fmt.Printf("# %s\n\n", t.name)
+				for _, r := range list {
+					fmt.Printf("# %s\n%s\n\n", r.description, renderRecipe(c.App, r, c.String("url")))
+				}
+			}
+			if known && !found {
+				return fmt.Errorf("no examples for %q in %s", topic, c.App.Name)
+			}
+			if !found {
+				names := make([]string, len(recipeTopics))
+				for i, t := range recipeTopics {
+					names[i] = t.name
+				}
+				return fmt.Errorf("unknown topic %q (valid: %s)", topic, strings.Join(names, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// GetExamplesCommand returns the examples command.
+func (b *CLIBuilder) GetExamplesCommand() *cli.Command {
+	return examplesCommand("http", b.config.URL)
+}
+
+// availableRecipes returns the recipes for topic whose command is registered in
+// app. Embedders choose which commands to mount, so recipes for the rest are hidden.
+func availableRecipes(app *cli.App, mode, topic string) []recipe {
+	var out []recipe
+	for _, r := range recipes {
+		if r.topic == topic && (r.mode == "" || r.mode == mode) && findCommand(app, r.command) != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func findCommand(app *cli.App, path string) *cli.Command {
+	cmds := app.Commands
+	var cmd *cli.Command
+	for _, name := range strings.Fields(path) {
+		cmd = nil
+		for _, candidate := range cmds {
+			if candidate.HasName(name) {
+				cmd = candidate
+				break
+			}
+		}
+		if cmd == nil {
+			return nil
+		}
+		cmds = cmd.Subcommands
+	}
+	return cmd
+}
+
+func commandHasFlag(cmd *cli.Command, name string) bool {
+	for _, f := range cmd.Flags {
+		for _, n := range f.Names() {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderRecipe formats r as a shell command line, adding --url when the
+// command accepts it and an endpoint is configured.
+func renderRecipe(app *cli.App, r recipe, url string) string {
+	parts := append([]string{app.Name}, strings.Fields(r.command)...)
+	if cmd := findCommand(app, r.command); url != "" && cmd != nil && commandHasFlag(cmd, "url") {
+		parts = append(parts, "--url", shellQuote(url))
+	}
+	for _, f := range r.flags {
+		parts = append(parts, "--"+f.name)
+		if f.value != "" {
+			parts = append(parts, shellQuote(f.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote single-quotes s unless it is made only of safe characters.
+// Values starting with $ are left bare so environment variables expand.
+func shellQuote(s string) string {
+	if strings.HasPrefix(s, "$") && !strings.ContainsAny(s, " '\"") {
+		return s
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe && s != "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package gqlcli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// checkRecipes reports recipes for mode whose command app does not register,
+// and recipe flags the command does not define.
+func checkRecipes(app *cli.App, mode string) []string {
+	var problems []string
+	for _, r := range recipes {
+		if r.mode != "" && r.mode != mode {
+			continue
+		}
+		cmd := findCommand(app, r.command)
+		if cmd == nil {
+			problems = append(problems, fmt.Sprintf("%s: no such command (%s)", r.command, r.description))
+			continue
+		}
+		for _, f := range r.flags {
+			if !commandHasFlag(cmd, f.name) {
+				problems = append(problems, fmt.Sprintf("%s: --%s (%s)", r.command, f.name, r.description))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// TestRecipesReferToRegisteredFlags checks every recipe against the complete
+// command set of its mode: all CLIBuilder commands, and an InlineCommandSet
+// with login.
+func TestRecipesReferToRegisteredFlags(t *testing.T) {
+	ts := newTestServer(t)
+	inline, _ := inlineApp(t, WithLogin(testLoginConfig(NewTokenStoreAt(t.TempDir()))))
+	apps := map[string]*cli.App{"http": httpApp(t, ts.URL), "inline": inline}
+	for mode, app := range apps {
+		if problems := checkRecipes(app, mode); len(problems) > 0 {
+			t.Errorf("%s recipes refer to missing commands or flags:\n  %s", mode, strings.Join(problems, "\n  "))
+		}
+	}
+
+	topics := map[string]bool{}
+	for _, topic := range recipeTopics {
+		topics[topic.name] = true
+	}
+	for _, r := range recipes {
+		if !topics[r.topic] {
+			t.Errorf("%q: unknown topic %q", r.description, r.topic)
+		}
+		if r.mode != "" && apps[r.mode] == nil {
+			t.Errorf("%q: unknown mode %q", r.description, r.mode)
+		}
+	}
+}
+
+func TestCheckRecipesReportsMissingCommands(t *testing.T) {
+	app := &cli.App{Name: "t", Commands: []*cli.Command{{Name: "query", Flags: []cli.Flag{&cli.StringFlag{Name: "query"}}}}}
+	problems := strings.Join(checkRecipes(app, "inline"), "\n")
+	assertContains(t, problems, "login: no such command", "query: --var (", "query: --format (")
+}
+
+func TestExamplesHideUnregisteredCommands(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "examples", "auth")
+	if err == nil || !strings.Contains(err.Error(), `no examples for "auth"`) {
+		t.Errorf("auth recipes shown without login: %q, %v", stdout, err)
+	}
+
+	stdout, _, err = runApp(t, app, "examples", "variables")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "books query --query", "--var id=1 --var first=10")
+}
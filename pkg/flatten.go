@@ -0,0 +1,222 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ArrayMode controls how Flatten treats arrays nested inside a record.
+type ArrayMode int
+
+const (
+	// ArraysExplode produces one row per element of a single array path (see
+	// FlattenOptions.ExplodePath); every other array is JSON-encoded, so sibling
+	// arrays never multiply rows. Scalar elements keep the array's key.
+	ArraysExplode ArrayMode = iota
+	// ArraysJSON keeps one row per record and encodes the array as a JSON string.
+	ArraysJSON
+	// ArraysKeep keeps one row per record and leaves the array value as is, for
+	// callers that render arrays themselves.
+	ArraysKeep
+)
+
+// FlattenOptions controls Flatten.
+type FlattenOptions struct {
+	Arrays ArrayMode
+	// CollapseEdges replaces Relay connections ({edges: [{node: {...}}]}) with
+	// the list of nodes, dropping pageInfo and cursors.
+	CollapseEdges bool
+	// MaxDepth limits how many object levels are expanded into columns; deeper
+	// values are JSON-encoded. Zero means unlimited.
+	MaxDepth int
+	// Delimiter joins nested keys (default ".").
+	Delimiter string
+	// ExplodePath is the delimiter-joined key of the array ArraysExplode
+	// expands, e.g. "author.books". By default it is the shallowest array in
+	// the data, the first by key order on ties.
+	ExplodePath string
+}
+
+// Flatten converts a decoded GraphQL result into flat records with
+// delimiter-joined keys, e.g. {"author": {"name": "x"}} becomes
+// {"author.name": "x"}. A top-level array yields one record per element; an
+// object yields a single record (or several when arrays are exploded).
+//
+// It returns the records and the sorted union of their keys. Null values are
+// kept as nil so every column that appears in any record is reported.
+func Flatten(data interface{}, opts FlattenOptions) ([]map[string]interface{}, []string) {
+	if opts.Delimiter == "" {
+		opts.Delimiter = "."
+	}
+	f := flattener{opts: opts}
+	if opts.Arrays == ArraysExplode && opts.ExplodePath == "" {
+		f.opts.ExplodePath = f.defaultExplodePath(data)
+	}
+
+	var rows []map[string]interface{}
+	switch v := f.collapse(data).(type) {
+	case []interface{}:
+		for _, item := range v {
+			rows = append(rows, f.value("", item, 0)...)
+		}
+	case map[string]interface{}:
+		rows = f.value("", v, 0)
+	default:
+		rows = []map[string]interface{}{{"value": v}}
+	}
+
+	seen := map[string]bool{}
+	var columns []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return rows, columns
+}
+
+type flattener struct {
+	opts FlattenOptions
+}
+
+func (f flattener) key(prefix, k string) string {
+	if prefix == "" {
+		return k
+	}
+	return prefix + f.opts.Delimiter + k
+}
+
+// collapse returns the node list for a Relay connection when CollapseEdges is set.
+func (f flattener) collapse(v interface{}) interface{} {
+	if !f.opts.CollapseEdges {
+		return v
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	edges, ok := m["edges"].([]interface{})
+	if !ok {
+		return v
+	}
+	nodes := make([]interface{}, 0, len(edges))
+	for _, e := range edges {
+		em, ok := e.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		node, ok := em["node"]
+		if !ok {
+			return v
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// value flattens v found at prefix into one or more partial rows.
+func (f flattener) value(prefix string, v interface{}, depth int) []map[string]interface{} {
+	switch val := f.collapse(v).(type) {
+	case map[string]interface{}:
+		if len(val) == 0 || (f.opts.MaxDepth > 0 && depth >= f.opts.MaxDepth) {
+			return []map[string]interface{}{{f.rootKey(prefix): encodeFlatJSON(val)}}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rows := []map[string]interface{}{{}}
+		for _, k := range keys {
+			rows = crossRows(rows, f.value(f.key(prefix, k), val[k], depth+1))
+		}
+		return rows
+	case []interface{}:
+		if f.opts.Arrays == ArraysKeep {
+			return []map[string]interface{}{{f.rootKey(prefix): val}}
+		}
+		if f.opts.Arrays == ArraysJSON || len(val) == 0 || f.rootKey(prefix) != f.opts.ExplodePath {
+			return []map[string]interface{}{{f.rootKey(prefix): encodeFlatJSON(val)}}
+		}
+		var rows []map[string]interface{}
+		for _, item := range val {
+			rows = append(rows, f.value(prefix, item, depth)...)
+		}
+		return rows
+	default:
+		return []map[string]interface{}{{f.rootKey(prefix): val}}
+	}
+}
+
+// defaultExplodePath returns the key of the shallowest non-empty array below
+// the records of data, the first by key order on ties, or "" if there is none.
+func (f flattener) defaultExplodePath(data interface{}) string {
+	best, bestDepth := "", -1
+	var walk func(prefix string, v interface{}, depth int)
+	walk = func(prefix string, v interface{}, depth int) {
+		switch val := f.collapse(v).(type) {
+		case map[string]interface{}:
+			if f.opts.MaxDepth > 0 && depth >= f.opts.MaxDepth {
+				return
+			}
+			for k, child := range val {
+				walk(f.key(prefix, k), child, depth+1)
+			}
+		case []interface{}:
+			if len(val) == 0 {
+				return
+			}
+			key := f.rootKey(prefix)
+			if bestDepth < 0 || depth < bestDepth || depth == bestDepth && key < best {
+				best, bestDepth = key, depth
+			}
+		}
+	}
+	// A top-level array is the list of records, not a path to explode.
+	if list, ok := f.collapse(data).([]interface{}); ok {
+		for _, item := range list {
+			walk("", item, 0)
+		}
+	} else {
+		walk("", data, 0)
+	}
+	return best
+}
+
+// rootKey names a value that has no key of its own (a scalar at the top level).
+func (f flattener) rootKey(prefix string) string {
+	if prefix == "" {
+		return "value"
+	}
+	return prefix
+}
+
+// crossRows merges every row of a with every row of b.
+func crossRows(a, b []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(a)*len(b))
+	for _, ra := range a {
+		for _, rb := range b {
+			row := make(map[string]interface{}, len(ra)+len(rb))
+			for k, v := range ra {
+				row[k] = v
+			}
+			for k, v := range rb {
+				row[k] = v
+			}
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+func encodeFlatJSON(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
@@ -0,0 +1,159 @@
+package gqlcli
+
+import (
+	"reflect"
+	"testing"
+)
+
+type row = map[string]interface{}
+
+func TestFlatten(t *testing.T) {
+	book := func(id string, tags ...interface{}) row {
+		return row{"id": id, "author": row{"name": "Le Guin", "born": nil}, "tags": tags}
+	}
+	tests := []struct {
+		name     string
+		data     interface{}
+		opts     FlattenOptions
+		wantRows []map[string]interface{}
+		wantCols []string
+	}{
+		{
+			name:     "nested objects and nulls",
+			data:     row{"id": "b1", "author": row{"name": "Le Guin", "born": nil}, "isbn": nil},
+			wantRows: []map[string]interface{}{{"id": "b1", "author.name": "Le Guin", "author.born": nil, "isbn": nil}},
+			wantCols: []string{"author.born", "author.name", "id", "isbn"},
+		},
+		{
+			name: "top-level list is one record per element",
+			data: []interface{}{row{"id": "b1"}, row{"id": "b2", "pages": 183}},
+			wantRows: []map[string]interface{}{
+				{"id": "b1"},
+				{"id": "b2", "pages": 183},
+			},
+			wantCols: []string{"id", "pages"},
+		},
+		{
+			name: "heterogeneous array elements",
+			data: row{"results": []interface{}{row{"title": "Cosmos"}, row{"name": "Sagan"}, "loose", nil}},
+			wantRows: []map[string]interface{}{
+				{"results.title": "Cosmos"},
+				{"results.name": "Sagan"},
+				{"results": "loose"},
+				{"results": nil},
+			},
+			wantCols: []string{"results", "results.name", "results.title"},
+		},
+		{
+			name: "explode keeps scalar elements under the array key",
+			data: book("b1", "classic", "sf"),
+			wantRows: []map[string]interface{}{
+				{"id": "b1", "author.name": "Le Guin", "author.born": nil, "tags": "classic"},
+				{"id": "b1", "author.name": "Le Guin", "author.born": nil, "tags": "sf"},
+			},
+			wantCols: []string{"author.born", "author.name", "id", "tags"},
+		},
+		{
+			name: "sibling arrays do not multiply rows",
+			data: row{"tags": []interface{}{"a", "b", "c"}, "ids": []interface{}{"x", "y"}, "shelf": row{"books": []interface{}{"z"}}},
+			wantRows: []map[string]interface{}{
+				{"ids": "x", "tags": `["a","b","c"]`, "shelf.books": `["z"]`},
+				{"ids": "y", "tags": `["a","b","c"]`, "shelf.books": `["z"]`},
+			},
+			wantCols: []string{"ids", "shelf.books", "tags"},
+		},
+		{
+			name: "explicit explode path",
+			data: row{"tags": []interface{}{"a", "b"}, "shelf": row{"books": []interface{}{row{"id": "b1"}, row{"id": "b2"}}}},
+			opts: FlattenOptions{ExplodePath: "shelf.books"},
+			wantRows: []map[string]interface{}{
+				{"tags": `["a","b"]`, "shelf.books.id": "b1"},
+				{"tags": `["a","b"]`, "shelf.books.id": "b2"},
+			},
+			wantCols: []string{"shelf.books.id", "tags"},
+		},
+		{
+			name:     "arrays as JSON",
+			data:     book("b1", "classic", "sf"),
+			opts:     FlattenOptions{Arrays: ArraysJSON},
+			wantRows: []map[string]interface{}{{"id": "b1", "author.name": "Le Guin", "author.born": nil, "tags": `["classic","sf"]`}},
+			wantCols: []string{"author.born", "author.name", "id", "tags"},
+		},
+		{
+			name:     "arrays kept",
+			data:     row{"tags": []interface{}{"a"}},
+			opts:     FlattenOptions{Arrays: ArraysKeep},
+			wantRows: []map[string]interface{}{{"tags": []interface{}{"a"}}},
+			wantCols: []string{"tags"},
+		},
+		{
+			name:     "empty array and object",
+			data:     row{"tags": []interface{}{}, "meta": row{}},
+			wantRows: []map[string]interface{}{{"tags": "[]", "meta": "{}"}},
+			wantCols: []string{"meta", "tags"},
+		},
+		{
+			name:     "deep nesting with a custom delimiter",
+			data:     row{"a": row{"b": row{"c": row{"d": 1}}}},
+			opts:     FlattenOptions{Delimiter: "/"},
+			wantRows: []map[string]interface{}{{"a/b/c/d": 1}},
+			wantCols: []string{"a/b/c/d"},
+		},
+		{
+			name:     "max depth encodes deeper objects",
+			data:     row{"a": row{"b": row{"c": row{"d": 1}}}, "x": 2},
+			opts:     FlattenOptions{MaxDepth: 2},
+			wantRows: []map[string]interface{}{{"a.b": `{"c":{"d":1}}`, "x": 2}},
+			wantCols: []string{"a.b", "x"},
+		},
+		{
+			name: "relay edges collapse to nodes",
+			data: row{"edges": []interface{}{
+				row{"cursor": "c1", "node": row{"id": "b1", "tags": row{"edges": []interface{}{row{"node": "sf"}}}}},
+				row{"cursor": "c2", "node": row{"id": "b2"}},
+			}, "pageInfo": row{"hasNextPage": false}},
+			opts: FlattenOptions{CollapseEdges: true},
+			wantRows: []map[string]interface{}{
+				{"id": "b1", "tags": "sf"},
+				{"id": "b2"},
+			},
+			wantCols: []string{"id", "tags"},
+		},
+		{
+			name:     "scalar",
+			data:     "ok",
+			wantRows: []map[string]interface{}{{"value": "ok"}},
+			wantCols: []string{"value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, cols := Flatten(tt.data, tt.opts)
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows:\n got %v\nwant %v", rows, tt.wantRows)
+			}
+			if !reflect.DeepEqual(cols, tt.wantCols) {
+				t.Errorf("columns: got %v, want %v", cols, tt.wantCols)
+			}
+		})
+	}
+}
+
+func TestFlattenDefaultExplodePath(t *testing.T) {
+	f := flattener{opts: FlattenOptions{Delimiter: "."}}
+	tests := []struct {
+		data interface{}
+		want string
+	}{
+		{row{"b": []interface{}{1}, "a": []interface{}{1}}, "a"},
+		{row{"deep": row{"list": []interface{}{1}}, "top": []interface{}{1}}, "top"},
+		{row{"empty": []interface{}{}, "x": row{"list": []interface{}{1}}}, "x.list"},
+		{[]interface{}{row{"id": 1}, row{"tags": []interface{}{"a"}}}, "tags"},
+		{row{"id": 1}, ""},
+	}
+	for _, tt := range tests {
+		if got := f.defaultExplodePath(tt.data); got != tt.want {
+			t.Errorf("defaultExplodePath(%v) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
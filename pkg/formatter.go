@@ -1,6 +1,7 @@
 package gqlcli
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -55,20 +56,29 @@ func (f *TableFormatter) Format(data map[string]interface{}) (string, error) {
 		return formatErrors(errs), nil
 	}
 
+	// Render the fields of a GraphQL response rather than its envelope.
+	if dataField, ok := data["data"].(map[string]interface{}); ok {
+		data = dataField
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	var buf strings.Builder
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
 
-	// Get the first key-value pair
-	for key, value := range data {
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Fprint(w, "\n")
+		}
 		fmt.Fprintf(w, "## %s\n\n", key)
 
-		if value == nil {
+		switch v := data[key].(type) {
+		case nil:
 			fmt.Fprint(w, "null\n")
-			w.Flush()
-			return buf.String(), nil
-		}
-
-		switch v := value.(type) {
 		case []interface{}:
 			formatArrayTable(w, v)
 		case map[string]interface{}:
@@ -76,7 +86,6 @@ func (f *TableFormatter) Format(data map[string]interface{}) (string, error) {
 		default:
 			fmt.Fprintf(w, "Value: %v\n", v)
 		}
-		break
 	}
 
 	w.Flush()
@@ -129,7 +138,7 @@ func (f *TOONFormatter) Format(data map[string]interface{}) (string, error) {
 	}
 
 	// Encode using official TOON library's MarshalString
-	toonOutput, err := toon.MarshalString(dataField)
+	toonOutput, err := toon.MarshalString(preserveLargeInts(dataField))
 	if err != nil {
 		return "", fmt.Errorf("TOON encoding failed: %w", err)
 	}
@@ -141,6 +150,39 @@ func (f *TOONFormatter) Name() string {
 	return "toon"
 }
 
+// maxSafeInteger is the largest integer a float64 holds exactly (2^53 - 1).
+const maxSafeInteger = 1<<53 - 1
+
+// preserveLargeInts replaces integer json.Numbers beyond maxSafeInteger with
+// their digit strings. The TOON encoder parses json.Number as float64, which
+// would round them; it writes large int64 values as strings for the same reason.
+func preserveLargeInts(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = preserveLargeInts(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = preserveLargeInts(item)
+		}
+		return out
+	case json.Number:
+		if strings.ContainsAny(val.String(), ".eE") {
+			return val
+		}
+		if i, err := val.Int64(); err == nil && i <= maxSafeInteger && i >= -maxSafeInteger {
+			return val
+		}
+		return val.String()
+	default:
+		return v
+	}
+}
+
 // LLMFormatter outputs data in human and LLM-friendly markdown format
 type LLMFormatter struct{}
 
@@ -159,6 +201,11 @@ func (f *LLMFormatter) Format(data map[string]interface{}) (string, error) {
 		return buf.String(), nil
 	}
 
+	// An introspection result is rendered as the schema's type definitions.
+	if schema, ok := introspectionSchema(data); ok {
+		return f.formatSchema(schema)
+	}
+
 	// Format data
 	dataField, _ := data["data"].(map[string]interface{})
 	formatDataAsMarkdown(&buf, dataField)
@@ -170,6 +217,168 @@ func (f *LLMFormatter) Name() string {
 	return "llm"
 }
 
+// formatSchema renders the root operation types followed by one section per
+// type, in the order of schemaTypeBlocks.
+func (f *LLMFormatter) formatSchema(schema map[string]interface{}) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("# GraphQL Schema\n\n")
+	for _, root := range []string{"queryType", "mutationType", "subscriptionType"} {
+		if t, ok := schema[root].(map[string]interface{}); ok {
+			if name, _ := t["name"].(string); name != "" {
+				fmt.Fprintf(&buf, "- **%s**: %s\n", strings.TrimSuffix(root, "Type"), name)
+			}
+		}
+	}
+	blocks, err := schemaTypeBlocks(schema, f.formatSchemaType)
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+	buf.WriteString(joinSchemaBlocks(blocks))
+	return buf.String(), nil
+}
+
+// formatSchemaType renders one introspected type as a markdown section
+// holding its SDL.
+func (f *LLMFormatter) formatSchemaType(t map[string]interface{}) string {
+	name, _ := t["name"].(string)
+	kind, _ := t["kind"].(string)
+	return fmt.Sprintf("### %s (%s)\n\n```graphql\n%s```\n", name, strings.ToLower(kind), FormatTypeSDL(t, true, false))
+}
+
+// introspectionSchema returns the __schema object of an introspection result,
+// with or without its data wrapper.
+func introspectionSchema(data map[string]interface{}) (map[string]interface{}, bool) {
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		data = inner
+	}
+	schema, ok := data["__schema"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	_, hasTypes := schema["types"].([]interface{})
+	return schema, hasTypes
+}
+
+// CSVFormatter outputs the result as CSV, one row per record.
+// Nested objects become dot-separated columns and nested arrays are exploded
+// into additional rows (see Flatten). When the response has a single top-level
+// field, its value is the record source; otherwise the whole data object is.
+type CSVFormatter struct{}
+
+// NewCSVFormatter creates a CSV formatter
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+func (f *CSVFormatter) Format(data map[string]interface{}) (string, error) {
+	if errs, ok := data["errors"].([]interface{}); ok && len(errs) > 0 {
+		return formatErrors(errs), nil
+	}
+
+	var source interface{} = data
+	if dataField, ok := data["data"].(map[string]interface{}); ok {
+		source = dataField
+		if len(dataField) == 1 {
+			for _, v := range dataField {
+				source = v
+			}
+		}
+	}
+
+	rows, columns := Flatten(source, FlattenOptions{Arrays: ArraysExplode, CollapseEdges: true})
+
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			if v := row[col]; v != nil {
+				record[i] = fmt.Sprintf("%v", v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("CSV encoding failed: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func (f *CSVFormatter) Name() string {
+	return "csv"
+}
+
+// PlainFormatter outputs one "path = value" line per leaf value, with no
+// alignment padding, color, or decorative characters. Keys are sorted and array
+// elements are addressed by index, so output is deterministic and reads well
+// with a screen reader.
+type PlainFormatter struct{}
+
+// NewPlainFormatter creates a plain formatter
+func NewPlainFormatter() *PlainFormatter {
+	return &PlainFormatter{}
+}
+
+func (f *PlainFormatter) Format(data map[string]interface{}) (string, error) {
+	var lines []string
+	flattenPlain("", data, &lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+func (f *PlainFormatter) Name() string {
+	return "plain"
+}
+
+func flattenPlain(path string, value interface{}, lines *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, plainLine(path, "{}"))
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			flattenPlain(child, v[k], lines)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, plainLine(path, "[]"))
+			return
+		}
+		for i, item := range v {
+			flattenPlain(fmt.Sprintf("%s[%d]", path, i), item, lines)
+		}
+	case nil:
+		*lines = append(*lines, plainLine(path, "null"))
+	default:
+		*lines = append(*lines, plainLine(path, fmt.Sprintf("%v", v)))
+	}
+}
+
+func plainLine(path, value string) string {
+	// Keep one record per line even when a value spans several.
+	value = strings.ReplaceAll(value, "\n", " ")
+	if path == "" {
+		return value
+	}
+	return path + " = " + value
+}
+
 // DefaultFormatterRegistry manages available formatters
 type DefaultFormatterRegistry struct {
 	formatters map[string]Formatter
@@ -188,6 +397,8 @@ func NewFormatterRegistry() *DefaultFormatterRegistry {
 	r.formatters["compact"] = NewCompactFormatter()
 	r.formatters["toon"] = NewTOONFormatter()
 	r.formatters["llm"] = NewLLMFormatter()
+	r.formatters["plain"] = NewPlainFormatter()
+	r.formatters["csv"] = NewCSVFormatter()
 
 	return r
 }
@@ -203,7 +414,7 @@ func (r *DefaultFormatterRegistry) Register(name string, formatter Formatter) er
 func (r *DefaultFormatterRegistry) Get(name string) (Formatter, error) {
 	formatter, ok := r.formatters[name]
 	if !ok {
-		return nil, fmt.Errorf("formatter '%s' not found", name)
+		return nil, fmt.Errorf("unknown format %q (valid: %s)", name, strings.Join(r.List(), ", "))
 	}
 	return formatter, nil
 }
@@ -285,31 +496,12 @@ func formatArrayTable(w *tabwriter.Writer, data []interface{}) {
 		return
 	}
 
-	// Collect all unique field names
-	fieldSet := make(map[string]bool)
-	var objects []map[string]interface{}
-
-	for _, item := range data {
-		if obj, ok := item.(map[string]interface{}); ok {
-			objects = append(objects, obj)
-			for key := range obj {
-				fieldSet[key] = true
-			}
-		}
-	}
-
-	if len(objects) == 0 {
+	rows, fields := Flatten(data, FlattenOptions{Arrays: ArraysKeep, CollapseEdges: true})
+	if len(rows) == 0 {
 		fmt.Fprint(w, "No objects found in array\n")
 		return
 	}
 
-	// Convert to sorted slice
-	var fields []string
-	for field := range fieldSet {
-		fields = append(fields, field)
-	}
-	sort.Strings(fields)
-
 	// Print header
 	for i, field := range fields {
 		if i > 0 {
@@ -329,12 +521,12 @@ func formatArrayTable(w *tabwriter.Writer, data []interface{}) {
 	fmt.Fprint(w, "\n")
 
 	// Print data rows
-	for _, obj := range objects {
+	for _, row := range rows {
 		for i, field := range fields {
 			if i > 0 {
 				fmt.Fprint(w, "\t")
 			}
-			fmt.Fprint(w, formatTableValue(obj[field]))
+			fmt.Fprint(w, formatTableValue(row[field]))
 		}
 		fmt.Fprint(w, "\n")
 	}
@@ -346,17 +538,13 @@ func formatObjectTable(w *tabwriter.Writer, data map[string]interface{}) {
 		return
 	}
 
-	var keys []string
-	for key := range data {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
+	rows, keys := Flatten(data, FlattenOptions{Arrays: ArraysKeep, CollapseEdges: true})
 
 	fmt.Fprint(w, "FIELD\tVALUE\n")
 	fmt.Fprint(w, "-----\t-----\n")
 
 	for _, key := range keys {
-		fmt.Fprintf(w, "%s\t%s\n", key, formatTableValue(data[key]))
+		fmt.Fprintf(w, "%s\t%s\n", key, formatTableValue(rows[0][key]))
 	}
 }
 
@@ -373,6 +561,8 @@ func formatTableValue(value interface{}) string {
 			return "true"
 		}
 		return "false"
+	case json.Number:
+		return v.String()
 	case float64:
 		if v == float64(int64(v)) {
 			return fmt.Sprintf("%.0f", v)
@@ -403,7 +593,7 @@ func formatTableValue(value interface{}) string {
 				valStr = val
 			case nil:
 				valStr = "null"
-			case bool, float64:
+			case bool, float64, json.Number:
 				valStr = fmt.Sprintf("%v", val)
 			case map[string]interface{}:
 				valStr = "{...}"
@@ -425,7 +615,14 @@ func formatTableValue(value interface{}) string {
 }
 
 func formatDataAsMarkdown(buf *strings.Builder, data map[string]interface{}) {
-	for key, value := range data {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := data[key]
 		fmt.Fprintf(buf, "## %s\n\n", key)
 
 		if value == nil {
@@ -435,12 +632,24 @@ func formatDataAsMarkdown(buf *strings.Builder, data map[string]interface{}) {
 
 		switch v := value.(type) {
 		case map[string]interface{}:
-			for k, val := range v {
-				fmt.Fprintf(buf, "- **%s**: %v\n", k, val)
+			rows, cols := Flatten(v, FlattenOptions{Arrays: ArraysKeep, CollapseEdges: true})
+			for _, col := range cols {
+				fmt.Fprintf(buf, "- **%s**: %s\n", col, formatTableValue(rows[0][col]))
 			}
 		case []interface{}:
-			for i, item := range v {
-				fmt.Fprintf(buf, "%d. %v\n", i+1, item)
+			rows, cols := Flatten(v, FlattenOptions{Arrays: ArraysKeep, CollapseEdges: true})
+			if len(rows) == 0 {
+				fmt.Fprint(buf, "(empty)\n")
+				break
+			}
+			fmt.Fprintf(buf, "| %s |\n", strings.Join(cols, " | "))
+			fmt.Fprintf(buf, "|%s\n", strings.Repeat(" --- |", len(cols)))
+			for _, row := range rows {
+				cells := make([]string, len(cols))
+				for i, col := range cols {
+					cells[i] = strings.ReplaceAll(formatTableValue(row[col]), "|", "\\|")
+				}
+				fmt.Fprintf(buf, "| %s |\n", strings.Join(cells, " | "))
 			}
 		default:
 			fmt.Fprintf(buf, "%v\n", v)
@@ -0,0 +1,58 @@
+package gqlcli
+
+import (
+	"strings"
+	"testing"
+)
+
+// formatterFixture is a books response: nested objects, lists of objects and
+// scalars, a null, and an integer beyond float64 precision.
+func formatterFixture(t *testing.T) map[string]interface{} {
+	e, _ := newTestExecutor(t)
+	return execute(t, e, `{
+  books(first: 3) { id title genre pages isbn tags author { name } }
+  bigNumber
+}`, nil)
+}
+
+func TestFormattersGolden(t *testing.T) {
+	r := NewFormatterRegistry()
+	for _, name := range r.List() {
+		t.Run(name, func(t *testing.T) {
+			f, err := r.Get(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := f.Format(formatterFixture(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "formatters/"+name+".txt", out)
+		})
+	}
+}
+
+func TestFormattersErrors(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `{ failing }`, nil)
+	r := NewFormatterRegistry()
+	for _, name := range r.List() {
+		t.Run(name, func(t *testing.T) {
+			f, _ := r.Get(name)
+			out, err := f.Format(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, "failing always fails") {
+				t.Errorf("error message missing from %s output:\n%s", name, out)
+			}
+		})
+	}
+}
+
+func TestFormatterRegistryUnknown(t *testing.T) {
+	_, err := NewFormatterRegistry().Get("yaml")
+	if err == nil || !strings.Contains(err.Error(), `unknown format "yaml"`) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// FieldInfo describes the field or argument an example value is generated for.
+type FieldInfo struct {
+	Name       string // field or argument name; empty for a bare scalar
+	ParentType string // type declaring the field; empty for a bare scalar
+	Type       string // GraphQL type, e.g. "SKU!" or "[IBAN]"
+}
+
+// NamedType returns Type without list and non-null wrappers.
+func (f FieldInfo) NamedType() string {
+	return strings.Trim(f.Type, "[]!")
+}
+
+// ValueGenerator returns an example value for field. rnd is seeded by the
+// caller so generated examples are reproducible.
+type ValueGenerator func(field FieldInfo, rnd *rand.Rand) interface{}
+
+var (
+	valueGeneratorMu sync.RWMutex
+	valueGenerators  = map[string]ValueGenerator{}
+)
+
+// RegisterValueGenerator sets the generator used for example values of the
+// scalar or type scalarOrTypeName (e.g. "SKU", "IBAN", "GeoJSON"), replacing
+// any earlier one. Registered generators take precedence over the built-in
+// placeholders such as "string" or "<datetime>", and over the first value of
+// an enum or the filled-in fields of an input object in describe
+// --variables-template.
+func RegisterValueGenerator(scalarOrTypeName string, gen func(field FieldInfo, rnd *rand.Rand) interface{}) {
+	valueGeneratorMu.Lock()
+	defer valueGeneratorMu.Unlock()
+	valueGenerators[scalarOrTypeName] = gen
+}
+
+func lookupValueGenerator(name string) ValueGenerator {
+	valueGeneratorMu.RLock()
+	defer valueGeneratorMu.RUnlock()
+	return valueGenerators[name]
+}
+
+// exampleValue returns an example value for field: from the generator
+// registered for its named type if there is one, else the built-in
+// placeholder for that type.
+func exampleValue(field FieldInfo, rnd *rand.Rand) interface{} {
+	name := field.NamedType()
+	if gen := lookupValueGenerator(name); gen != nil {
+		return gen(field, rnd)
+	}
+	return scalarExample(name)
+}
+
+// inputTemplate returns an example value for every field of the input object
+// typeName, as printed by describe --variables-template. Nested input objects
+// are filled in the same way, lists get one element, and enums their first
+// value. A field whose input object is already being filled (a recursive
+// input) is left null.
+func (d *Describer) inputTemplate(ctx context.Context, typeName string, rnd *rand.Rand) (map[string]interface{}, error) {
+	typeInfo, err := d.fetch(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	if kind, _ := typeInfo["kind"].(string); kind != "INPUT_OBJECT" {
+		return nil, fmt.Errorf("%s is not an input object type (it is %s)", typeName, kind)
+	}
+	return d.inputFieldsTemplate(ctx, typeName, typeInfo, rnd, map[string]bool{})
+}
+
+func (d *Describer) inputFieldsTemplate(ctx context.Context, typeName string, typeInfo map[string]interface{}, rnd *rand.Rand, filling map[string]bool) (map[string]interface{}, error) {
+	filling[typeName] = true
+	defer delete(filling, typeName)
+
+	out := map[string]interface{}{}
+	fields, _ := typeInfo["inputFields"].([]interface{})
+	for _, f := range fields {
+		fm, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := fm["name"].(string)
+		field := FieldInfo{Name: name, ParentType: typeName, Type: formatTypeRef(fm["type"])}
+		v, err := d.templateValue(ctx, field, fm["type"], rnd, filling)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// templateValue returns the example value for field, whose introspected type
+// is typeRef, wrapped in a one-element list per list level.
+func (d *Describer) templateValue(ctx context.Context, field FieldInfo, typeRef interface{}, rnd *rand.Rand, filling map[string]bool) (interface{}, error) {
+	name, kind := namedTypeKind(typeRef)
+	var v interface{}
+	switch gen := lookupValueGenerator(name); {
+	case gen != nil:
+		v = gen(field, rnd)
+	case kind == "ENUM":
+		typeInfo, err := d.fetch(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if values, _ := typeInfo["enumValues"].([]interface{}); len(values) > 0 {
+			v, _ = values[0].(map[string]interface{})["name"].(string)
+		}
+	case kind == "INPUT_OBJECT":
+		if filling[name] {
+			return nil, nil
+		}
+		typeInfo, err := d.fetch(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = d.inputFieldsTemplate(ctx, name, typeInfo, rnd, filling); err != nil {
+			return nil, err
+		}
+	default:
+		v = scalarExample(name)
+	}
+	for tm, ok := typeRef.(map[string]interface{}); ok; tm, ok = tm["ofType"].(map[string]interface{}) {
+		if tm["kind"] == "LIST" {
+			v = []interface{}{v}
+		}
+	}
+	return v, nil
+}
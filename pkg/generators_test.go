@@ -0,0 +1,137 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// registerTestGenerator registers gen for name until the end of the test.
+func registerTestGenerator(t *testing.T, name string, gen ValueGenerator) {
+	t.Helper()
+	RegisterValueGenerator(name, gen)
+	t.Cleanup(func() {
+		valueGeneratorMu.Lock()
+		defer valueGeneratorMu.Unlock()
+		delete(valueGenerators, name)
+	})
+}
+
+func TestExampleValuePrecedence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	// Without a generator every type gets its built-in placeholder.
+	for _, tt := range []struct {
+		typ  string
+		want interface{}
+	}{
+		{"Int!", 0},
+		{"String", "string"},
+		{"[ID!]!", "id"},
+		{"SKU", "<sku>"},
+	} {
+		if got := exampleValue(FieldInfo{Type: tt.typ}, rnd); got != tt.want {
+			t.Errorf("%s: got %v, want the placeholder %v", tt.typ, got, tt.want)
+		}
+	}
+
+	// A registered generator wins over the placeholder, for built-in scalars
+	// too, whatever wrappers the field's type has.
+	registerTestGenerator(t, "SKU", func(FieldInfo, *rand.Rand) interface{} { return "SKU-1" })
+	registerTestGenerator(t, "String", func(FieldInfo, *rand.Rand) interface{} { return "text" })
+	for typ, want := range map[string]interface{}{"SKU": "SKU-1", "[SKU!]!": "SKU-1", "String!": "text", "Int": 0} {
+		if got := exampleValue(FieldInfo{Type: typ}, rnd); got != want {
+			t.Errorf("%s: got %v, want %v", typ, got, want)
+		}
+	}
+
+	// Registering again replaces the earlier generator.
+	registerTestGenerator(t, "SKU", func(FieldInfo, *rand.Rand) interface{} { return "SKU-2" })
+	if got := exampleValue(FieldInfo{Type: "SKU"}, rnd); got != "SKU-2" {
+		t.Errorf("got %v, want the later generator's SKU-2", got)
+	}
+}
+
+func TestValueGeneratorArguments(t *testing.T) {
+	var got FieldInfo
+	registerTestGenerator(t, "IBAN", func(f FieldInfo, rnd *rand.Rand) interface{} {
+		got = f
+		return fmt.Sprintf("GB%02d", rnd.Intn(100))
+	})
+
+	field := FieldInfo{Name: "payee", ParentType: "Transfer", Type: "[IBAN!]"}
+	first := exampleValue(field, rand.New(rand.NewSource(7)))
+	if got != field {
+		t.Errorf("generator got %+v, want %+v", got, field)
+	}
+	if again := exampleValue(field, rand.New(rand.NewSource(7))); again != first {
+		t.Errorf("same seed gave %v then %v", first, again)
+	}
+}
+
+func TestScalarExamplesUseGenerators(t *testing.T) {
+	ts := newTestServer(t)
+	cfg := &Config{URL: ts.URL, CacheDir: t.TempDir()}
+	registerTestGenerator(t, "BigInt", func(f FieldInfo, rnd *rand.Rand) interface{} {
+		if f.Name != "" || f.ParentType != "" {
+			t.Errorf("a bare scalar got field %+v", f)
+		}
+		return "12345678901234567890"
+	})
+
+	result, err := NewHTTPClient(cfg).Introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewSchemaCache(cfg.CacheDir)
+	if err := cache.SaveSchema(ts.URL, result); err != nil {
+		t.Fatal(err)
+	}
+	scalars, err := cache.LoadScalarExamples(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scalars["BigInt"] != "12345678901234567890" {
+		t.Errorf("BigInt: got %v, want the registered generator's value", scalars["BigInt"])
+	}
+	if scalars["String"] != "string" || scalars["Boolean"] != true {
+		t.Errorf("built-in scalars: got %v and %v, want their placeholders", scalars["String"], scalars["Boolean"])
+	}
+}
+
+func TestVariablesTemplate(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "describe", "--variables-template", "AddBookInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "describe/addbookinput-template.json", stdout)
+
+	// Generators see the input field they fill, and win over enum values and
+	// filled-in input objects as well as scalar placeholders.
+	var fields []FieldInfo
+	registerTestGenerator(t, "BigInt", func(f FieldInfo, rnd *rand.Rand) interface{} {
+		fields = append(fields, f)
+		return "978" + fmt.Sprint(rnd.Intn(10))
+	})
+	registerTestGenerator(t, "Genre", func(FieldInfo, *rand.Rand) interface{} { return "HISTORY" })
+	registerTestGenerator(t, "DateInput", func(FieldInfo, *rand.Rand) interface{} { return map[string]interface{}{"year": 1969} })
+	stdout, _, err = runApp(t, app, "describe", "--variables-template", "AddBookInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"genre": "HISTORY"`, `"isbn": "978`, `"year": 1969`)
+	if want := (FieldInfo{Name: "isbn", ParentType: "BookDetailsInput", Type: "BigInt"}); len(fields) != 1 || fields[0] != want {
+		t.Errorf("generator got %+v, want %+v", fields, want)
+	}
+	again, _, _ := runApp(t, app, "describe", "--variables-template", "AddBookInput")
+	if again != stdout {
+		t.Errorf("template changed between runs:\n%s\n%s", stdout, again)
+	}
+
+	if _, _, err := runApp(t, app, "describe", "--variables-template", "Book"); err == nil || !strings.Contains(err.Error(), "not an input object") {
+		t.Errorf("got %v, want an error for an output type", err)
+	}
+}
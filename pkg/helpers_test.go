@@ -0,0 +1,161 @@
+package gqlcli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wricardo/gqlcli/internal/testschema"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files under testdata")
+
+// newTestExecutor returns an executor over a fresh testschema.
+func newTestExecutor(t *testing.T, opts ...Option) (*InlineExecutor, *testschema.Schema) {
+	t.Helper()
+	schema := testschema.New()
+	return NewInlineExecutor(schema, opts...), schema
+}
+
+// execute runs query on e and decodes the response.
+func execute(t *testing.T, e *InlineExecutor, query string, vars map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	raw, err := e.Execute(context.Background(), query, vars)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var result map[string]interface{}
+	if err := decodeJSON(raw, &result); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return result
+}
+
+// captureOutput runs fn with os.Stdout and os.Stderr redirected and returns
+// what it wrote to each.
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	read := func(f **os.File) (func() string, func()) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := *f
+		*f = w
+		var buf bytes.Buffer
+		done := make(chan struct{})
+		go func() {
+			io.Copy(&buf, r)
+			close(done)
+		}()
+		return func() string {
+				w.Close()
+				<-done
+				return buf.String()
+			}, func() {
+				*f = orig
+			}
+	}
+	outText, outRestore := read(&os.Stdout)
+	errText, errRestore := read(&os.Stderr)
+	defer outRestore()
+	defer errRestore()
+	fn()
+	return outText(), errText()
+}
+
+// runApp runs app with args, capturing its output. Exit codes are returned as
+// errors instead of ending the test binary.
+func runApp(t *testing.T, app *cli.App, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	app.ExitErrHandler = func(*cli.Context, error) {}
+	stdout, stderr = captureOutput(t, func() {
+		err = app.Run(append([]string{app.Name}, args...))
+	})
+	return stdout, stderr, err
+}
+
+// inlineApp mounts an InlineCommandSet over a fresh testschema.
+func inlineApp(t *testing.T, opts ...CommandSetOption) (*cli.App, *testschema.Schema) {
+	t.Helper()
+	e, schema := newTestExecutor(t, WithSchemaHints())
+	app := &cli.App{Name: "books"}
+	NewInlineCommandSet(e, opts...).Mount(app)
+	return app, schema
+}
+
+// testServer serves a fresh testschema over HTTP and counts the requests
+// it receives.
+type testServer struct {
+	*httptest.Server
+	schema   *testschema.Schema
+	requests int32
+}
+
+func newTestServer(t *testing.T, opts ...Option) *testServer {
+	t.Helper()
+	e, schema := newTestExecutor(t, opts...)
+	ts := &testServer{schema: schema}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ts.requests, 1)
+		e.Server().ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// Requests returns how many requests the server has received.
+func (ts *testServer) Requests() int {
+	return int(atomic.LoadInt32(&ts.requests))
+}
+
+// httpApp registers the HTTP commands for url, with HOME and the cache in
+// temporary directories.
+func httpApp(t *testing.T, url string) *cli.App {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: url, Format: "json", CacheDir: t.TempDir()}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).RegisterCommands(app)
+	return app
+}
+
+// checkGolden compares got with testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch (run go test -update to accept)\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func assertContains(t *testing.T, s string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %q in:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package gqlcli
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wricardo/gqlcli/internal/hints"
+)
+
+// DefaultHintTimeout is how long schema hint lookups may take in total for one
+// response. When it runs out the remaining errors are returned without hints,
+// so a slow endpoint never delays the original error by more than this.
+const DefaultHintTimeout = 3 * time.Second
+
+// hintBudget is the hint time allowed for one operation. The clock starts at
+// the first lookup, so slow resolvers don't eat into it.
+type hintBudget struct {
+	timeout time.Duration
+	once    sync.Once
+	until   time.Time
+}
+
+type hintBudgetKey struct{}
+
+// hintContext returns a context bounded by the operation's hint budget (see
+// withHintBudget), or by timeout alone when ctx carries none.
+func hintContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if b, ok := ctx.Value(hintBudgetKey{}).(*hintBudget); ok {
+		b.once.Do(func() { b.until = time.Now().Add(b.timeout) })
+		return context.WithDeadline(ctx, b.until)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func withHintBudget(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, hintBudgetKey{}, &hintBudget{timeout: timeout})
+}
+
+// HintSource describes a type as SDL for the schemaHint extension.
+// *Describer implements it.
+type HintSource interface {
+	Describe(ctx context.Context, typeName string) (string, error)
+}
+
+// ExtractTypeFromValidationMessage returns the name of the type a GraphQL
+// validation error refers to, or "" if msg is not a recognized validation error.
+func ExtractTypeFromValidationMessage(msg string) string {
+	return hints.ExtractType(msg)
+}
+
+// AttachHint adds a schemaHint extension for the type msg refers to; see hints.Attach.
+func AttachHint(ctx context.Context, src HintSource, msg string, extensions map[string]interface{}) map[string]interface{} {
+	return hints.Attach(ctx, src, msg, extensions)
+}
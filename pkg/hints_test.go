@@ -0,0 +1,63 @@
+package gqlcli
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSchemaHintPathsAgree sends the same invalid operations through the HTTP
+// client (hints added by enrichErrors) and the inline executor (hints added by
+// its error presenter) and expects the same hints from both.
+func TestSchemaHintPathsAgree(t *testing.T) {
+	ts := newTestServer(t)
+	client := NewHTTPClient(&Config{URL: ts.URL, CacheDir: t.TempDir()})
+	inline, _ := newTestExecutor(t, WithSchemaHints())
+
+	tests := []struct {
+		query    string
+		wantHint string
+	}{
+		{`{ books { titel } }`, "type Book {"},
+		{`{ books(limit: 1) { id } }`, "type Query {"},
+		{`{ books }`, "type Book {"},
+		{`{ search(text: "x") { title } }`, "union SearchResult"},
+		{`mutation { addBook(input: {authorName: "x"}) { id } }`, "input AddBookInput {"},
+		{`mutation { addBook(input: {title: "x", authorName: "y", subtitle: "z"}) { id } }`, "input AddBookInput {"},
+		{`{ failing }`, ""},
+	}
+	for _, tt := range tests {
+		viaHTTP, err := client.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: tt.query})
+		if err == nil {
+			t.Fatalf("%s: expected errors", tt.query)
+		}
+		httpHints := schemaHints(viaHTTP)
+		inlineHints := schemaHints(execute(t, inline, tt.query, nil))
+
+		if !reflect.DeepEqual(httpHints, inlineHints) {
+			t.Errorf("%s: hints differ\nhttp:   %q\ninline: %q", tt.query, httpHints, inlineHints)
+		}
+		if tt.wantHint == "" {
+			if len(httpHints) > 0 {
+				t.Errorf("%s: unexpected hint %q", tt.query, httpHints)
+			}
+		} else if len(httpHints) == 0 || !strings.Contains(httpHints[0], tt.wantHint) {
+			t.Errorf("%s: got hints %q, want one for %s", tt.query, httpHints, tt.wantHint)
+		}
+	}
+}
+
+// schemaHints returns the schemaHint extension of each error in result that has one.
+func schemaHints(result map[string]interface{}) []string {
+	var out []string
+	errs, _ := result["errors"].([]interface{})
+	for _, e := range errs {
+		em, _ := e.(map[string]interface{})
+		ext, _ := em["extensions"].(map[string]interface{})
+		if hint, ok := ext["schemaHint"].(string); ok {
+			out = append(out, hint)
+		}
+	}
+	return out
+}
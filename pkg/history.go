@@ -0,0 +1,235 @@
+package gqlcli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// historyRecord is one line of the log written by NewJSONLUsageRecorder.
+type historyRecord struct {
+	Time          string `json:"time"`
+	Command       string `json:"command"`
+	Operation     string `json:"operation"`
+	OperationType string `json:"operationType"`
+	Endpoint      string `json:"endpoint"`
+	DurationMs    int64  `json:"durationMs"`
+	Success       bool   `json:"success"`
+	ErrorClass    string `json:"errorClass"`
+	DryRun        bool   `json:"dryRun"`
+}
+
+// historyFilter selects the records history tail prints.
+type historyFilter struct {
+	opType     string
+	endpoint   string
+	errorsOnly bool
+}
+
+func (f historyFilter) match(r historyRecord) bool {
+	if f.opType != "" && r.OperationType != f.opType {
+		return false
+	}
+	if f.endpoint != "" && !strings.Contains(strings.ToLower(r.Endpoint), strings.ToLower(f.endpoint)) {
+		return false
+	}
+	return !f.errorsOnly || !r.Success
+}
+
+// historyTailer reads the lines appended to a JSONL file since its last read.
+type historyTailer struct {
+	path    string
+	offset  int64
+	partial []byte
+}
+
+// next returns the complete lines appended since the last call. A final line
+// still being written (no newline yet) is held back until it is finished. If
+// the file shrank, it was truncated or replaced, and reading starts over.
+func (t *historyTailer) next() ([][]byte, error) {
+	f, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < t.offset {
+		t.offset, t.partial = 0, nil
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, nil
+	}
+	t.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines [][]byte
+	for _, line := range bytes.Split(data[:end], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// formatHistoryLine renders r as one line: time, operation, duration, status,
+// and whether it was a dry run.
+func formatHistoryLine(r historyRecord) string {
+	ts := r.Time
+	if t, err := time.Parse(time.RFC3339Nano, r.Time); err == nil {
+		ts = t.Local().Format("2006-01-02 15:04:05")
+	}
+	op := r.Operation
+	if r.OperationType != "" {
+		op = r.OperationType + " " + op
+	}
+	if op == "" {
+		op = r.Command
+	}
+	status := "ok"
+	if !r.Success {
+		status = "error"
+		if r.ErrorClass != "" {
+			status += " (" + r.ErrorClass + ")"
+		}
+	}
+	if r.DryRun {
+		status += "  dry run"
+	}
+	return fmt.Sprintf("%s  %-40s  %7dms  %s", ts, op, r.DurationMs, status)
+}
+
+// historyCommand reads the usage log at path.
+func historyCommand(path string) *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Inspect the command history log",
+		Subcommands: []*cli.Command{
+			historyTailCommand(path),
+		},
+	}
+}
+
+func historyTailCommand(path string) *cli.Command {
+	return &cli.Command{
+		Name:  "tail",
+		Usage: "Print the latest history entries, and with --follow, new ones as they are recorded",
+		Description: "Reads the JSONL history at " + path + ". Concurrent writers append one " +
+			"record per write, so entries never interleave; a record still being written is " +
+			"shown once it is complete. --format json prints the raw records for piping.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "follow", Aliases: []string{"f"}, Usage: "Keep running and print entries as they are appended"},
+			&cli.IntFlag{Name: "lines", Aliases: []string{"n"}, Usage: "Number of existing entries to print first", Value: 10},
+			&cli.StringFlag{Name: "op-type", Usage: "Only operations of this type: query, mutation, or subscription"},
+			&cli.StringFlag{Name: "endpoint", Usage: "Only operations sent to an endpoint URL containing this text"},
+			&cli.BoolFlag{Name: "errors-only", Usage: "Only failed commands"},
+			&cli.StringFlag{Name: "format", Usage: "Output format: line or json (raw records)", Value: "line"},
+			&cli.DurationFlag{Name: "interval", Usage: "How often --follow checks for new entries", Value: 500 * time.Millisecond},
+		},
+		Action: func(c *cli.Context) error {
+			filter := historyFilter{
+				opType:     strings.ToLower(c.String("op-type")),
+				endpoint:   c.String("endpoint"),
+				errorsOnly: c.Bool("errors-only"),
+			}
+			switch filter.opType {
+			case "", "query", "mutation", "subscription":
+			default:
+				return fmt.Errorf("invalid --op-type %q (valid: query, mutation, subscription)", c.String("op-type"))
+			}
+			format := c.String("format")
+			if format != "line" && format != "json" {
+				return fmt.Errorf("invalid --format %q (valid: line, json)", format)
+			}
+
+			// Parsed lines that pass the filter, rendered for output.
+			render := func(lines [][]byte) []string {
+				var out []string
+				for _, line := range lines {
+					var r historyRecord
+					if err := decodeJSON(line, &r); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: skipping malformed history line: %s\n", truncateLine(string(line), 80))
+						continue
+					}
+					if !filter.match(r) {
+						continue
+					}
+					if format == "json" {
+						out = append(out, string(line))
+					} else {
+						out = append(out, formatHistoryLine(r))
+					}
+				}
+				return out
+			}
+
+			t := &historyTailer{path: path}
+			lines, err := t.next()
+			if err != nil {
+				return fmt.Errorf("failed to read history: %w", err)
+			}
+			backlog := render(lines)
+			if n := c.Int("lines"); n >= 0 && len(backlog) > n {
+				backlog = backlog[len(backlog)-n:]
+			}
+			for _, line := range backlog {
+				fmt.Println(line)
+			}
+			if !c.Bool("follow") {
+				return nil
+			}
+
+			ctx := c.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ticker := time.NewTicker(c.Duration("interval"))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+				lines, err := t.next()
+				if err != nil {
+					return fmt.Errorf("failed to read history: %w", err)
+				}
+				for _, line := range render(lines) {
+					fmt.Println(line)
+				}
+			}
+		},
+	}
+}
+
+// truncateLine shortens s to at most n bytes for messages.
+func truncateLine(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
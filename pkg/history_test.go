@@ -0,0 +1,161 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestHistoryTailerPartialLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	tail := &historyTailer{path: path}
+
+	if lines, err := tail.next(); err != nil || lines != nil {
+		t.Fatalf("missing file: %q, %v", lines, err)
+	}
+
+	appendFile(t, path, `{"command":"a"}`+"\n"+`{"comm`)
+	lines, err := tail.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || string(lines[0]) != `{"command":"a"}` {
+		t.Fatalf("got %q, want only the complete line", lines)
+	}
+
+	appendFile(t, path, `and":"b"}`+"\n")
+	lines, _ = tail.next()
+	if len(lines) != 1 || string(lines[0]) != `{"command":"b"}` {
+		t.Fatalf("got %q, want the finished line", lines)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"command":"c"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lines, _ = tail.next()
+	if len(lines) != 1 || string(lines[0]) != `{"command":"c"}` {
+		t.Fatalf("after truncation got %q, want reading from the start", lines)
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	query := historyRecord{OperationType: "query", Endpoint: "https://api.prod.example.com/graphql", Success: true}
+	mutation := historyRecord{OperationType: "mutation", Endpoint: "http://localhost:8080/graphql", ErrorClass: "graphql"}
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		want   []bool
+	}{
+		{"none", historyFilter{}, []bool{true, true}},
+		{"op type", historyFilter{opType: "mutation"}, []bool{false, true}},
+		{"endpoint", historyFilter{endpoint: "PROD"}, []bool{true, false}},
+		{"errors only", historyFilter{errorsOnly: true}, []bool{false, true}},
+		{"combined", historyFilter{opType: "query", errorsOnly: true}, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		for i, r := range []historyRecord{query, mutation} {
+			if got := tt.filter.match(r); got != tt.want[i] {
+				t.Errorf("%s: match(%v) = %v, want %v", tt.name, r, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestFormatHistoryLine(t *testing.T) {
+	ts := time.Date(2026, 10, 15, 3, 2, 1, 0, time.UTC)
+	got := formatHistoryLine(historyRecord{
+		Time:          ts.Format(time.RFC3339Nano),
+		Operation:     "AddBook",
+		OperationType: "mutation",
+		DurationMs:    12,
+		ErrorClass:    "graphql",
+	})
+	want := ts.Local().Format("2006-01-02 15:04:05") + "  " + fmt.Sprintf("%-40s", "mutation AddBook") + "       12ms  error (graphql)"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got := formatHistoryLine(historyRecord{Command: "endpoints list", Success: true}); !strings.Contains(got, "endpoints list") || !strings.HasSuffix(got, "ok") {
+		t.Errorf("command fallback: %q", got)
+	}
+}
+
+func TestHistoryTailCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	app, _ := inlineApp(t, WithHistoryFile(path))
+
+	for _, args := range [][]string{
+		{"query", "query Shelf { books { id } }"},
+		{"mutation", `mutation Archive { archiveBooks(ids: ["b4"]) }`},
+		{"query", "{ failing }"},
+		{"types"},
+	} {
+		if _, _, err := runApp(t, app, args...); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	stdout, _, err := runApp(t, app, "history", "tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want the 4 commands:\n%s", len(lines), stdout)
+	}
+	assertContains(t, stdout, "query Shelf", "mutation Archive", "query failing", "error (graphql)", "types")
+
+	stdout, _, _ = runApp(t, app, "history", "tail", "--op-type", "mutation", "--format", "json")
+	if n := strings.Count(stdout, "\n"); n != 1 || !strings.Contains(stdout, `"operation":"Archive"`) {
+		t.Errorf("--op-type mutation --format json:\n%s", stdout)
+	}
+
+	stdout, _, _ = runApp(t, app, "history", "tail", "--errors-only", "-n", "1")
+	if strings.Count(stdout, "\n") != 1 || !strings.Contains(stdout, "failing") {
+		t.Errorf("--errors-only -n 1:\n%s", stdout)
+	}
+
+	if _, _, err := runApp(t, app, "history", "tail", "--op-type", "insert"); err == nil {
+		t.Error("expected an error for an unknown --op-type")
+	}
+}
+
+func TestHistoryTailFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	record := NewJSONLUsageRecorder(path)
+	record(UsageEvent{Time: time.Now(), Command: "query", Operation: "Before", OperationType: "query", Success: true})
+
+	app := &cli.App{Name: "books", Commands: []*cli.Command{historyCommand(path)}}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		record(UsageEvent{Time: time.Now(), Command: "mutation", Operation: "During", OperationType: "mutation"})
+	}()
+	stdout, _ := captureOutput(t, func() {
+		if err := app.RunContext(ctx, []string{"books", "history", "tail", "--follow", "--interval", "10ms", "--errors-only"}); err != nil {
+			t.Error(err)
+		}
+	})
+	if strings.Contains(stdout, "Before") || !strings.Contains(stdout, "mutation During") {
+		t.Errorf("--follow --errors-only printed:\n%s", stdout)
+	}
+}
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,25 +6,35 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"regexp"
-	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // InlineExecutor runs GraphQL operations in-process against a schema without an HTTP server.
 // Create one with NewInlineExecutor; use Execute to run operations.
 type InlineExecutor struct {
-	srv    *handler.Server
-	enrich func(context.Context) context.Context
+	srv         *handler.Server
+	enrich      func(context.Context) context.Context
+	hintTimeout time.Duration
 }
 
 // inlineConfig holds options for NewInlineExecutor.
 type inlineConfig struct {
 	enrich      func(context.Context) context.Context
 	schemaHints bool
+	hintTimeout time.Duration
+
+	queryCacheSize int
+	apq            bool
+	apqCacheSize   int
+	transports     []graphql.Transport
 }
 
 // Option configures an InlineExecutor.
@@ -43,22 +53,85 @@ func WithSchemaHints() Option {
 	return func(o *inlineConfig) { o.schemaHints = true }
 }
 
+// WithSchemaHintTimeout bounds how long the schema hint lookups for one
+// operation may take (default: DefaultHintTimeout). Errors left when it runs
+// out are returned without a schemaHint.
+func WithSchemaHintTimeout(d time.Duration) Option {
+	return func(o *inlineConfig) { o.hintTimeout = d }
+}
+
+// WithQueryCache sets how many parsed and validated queries are kept (default
+// 1000). A size of 0 disables the cache, so every Execute parses and
+// validates its query again and executors share no state between runs.
+func WithQueryCache(size int) Option {
+	return func(o *inlineConfig) { o.queryCacheSize = size }
+}
+
+// WithAPQ enables or disables automatic persisted queries and sets how many
+// persisted queries are kept (default: enabled, 100).
+func WithAPQ(enabled bool, cacheSize int) Option {
+	return func(o *inlineConfig) { o.apq, o.apqCacheSize = enabled, cacheSize }
+}
+
+// WithTransports replaces the server's transports (default: websocket,
+// OPTIONS, GET, POST, and multipart form). Execute sends JSON POST requests,
+// so the list must include transport.POST{}.
+func WithTransports(transports ...graphql.Transport) Option {
+	return func(o *inlineConfig) { o.transports = transports }
+}
+
+// defaultTransports are the transports of gqlgen's handler.NewDefaultServer.
+func defaultTransports() []graphql.Transport {
+	return []graphql.Transport{
+		transport.Websocket{KeepAlivePingInterval: 10 * time.Second},
+		transport.Options{},
+		transport.GET{},
+		transport.POST{},
+		transport.MultipartForm{},
+	}
+}
+
 // NewInlineExecutor creates an InlineExecutor that runs GraphQL operations in-process.
 // No HTTP server is required — operations execute directly against the schema.
+// Without options the server is configured like gqlgen's NewDefaultServer.
 func NewInlineExecutor(schema graphql.ExecutableSchema, opts ...Option) *InlineExecutor {
-	cfg := &inlineConfig{}
+	cfg := &inlineConfig{
+		hintTimeout:    DefaultHintTimeout,
+		queryCacheSize: 1000,
+		apq:            true,
+		apqCacheSize:   100,
+		transports:     defaultTransports(),
+	}
 	for _, o := range opts {
 		o(cfg)
 	}
 
-	srv := handler.NewDefaultServer(schema)
+	srv := handler.New(schema)
+	for _, t := range cfg.transports {
+		srv.AddTransport(t)
+	}
+	if cfg.queryCacheSize > 0 {
+		srv.SetQueryCache(lru.New[*ast.QueryDocument](cfg.queryCacheSize))
+	}
+	// Introspection is always on: describe, types, and schema hints need it.
+	srv.Use(extension.Introspection{})
+	if cfg.apq && cfg.apqCacheSize > 0 {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](cfg.apqCacheSize)})
+	}
 
 	if cfg.schemaHints {
 		d := newSchemaHintDescriber(srv)
-		srv.SetErrorPresenter(makeSchemaHintPresenter(d))
+		srv.SetErrorPresenter(makeSchemaHintPresenter(d, cfg.hintTimeout))
 	}
 
-	return &InlineExecutor{srv: srv, enrich: cfg.enrich}
+	return &InlineExecutor{srv: srv, enrich: cfg.enrich, hintTimeout: cfg.hintTimeout}
+}
+
+// Server returns the underlying gqlgen server, for tuning not covered by the
+// options (e.g. SetParserTokenLimit or extra extensions). Changes apply to
+// later Execute calls.
+func (e *InlineExecutor) Server() *handler.Server {
+	return e.srv
 }
 
 // Execute runs a GraphQL query or mutation and returns the raw JSON response.
@@ -66,6 +139,7 @@ func (e *InlineExecutor) Execute(ctx context.Context, query string, variables ma
 	if e.enrich != nil {
 		ctx = e.enrich(ctx)
 	}
+	ctx = withHintBudget(ctx, e.hintTimeout)
 
 	body := map[string]interface{}{"query": query}
 	if variables != nil {
@@ -96,41 +170,16 @@ func (e *InlineExecutor) ExecuteFunc() func(context.Context, string, map[string]
 
 // --- schema hint error presenter ---
 
-var (
-	reOutputField   = regexp.MustCompile(`Cannot query field "[^"]+" on type "([^"]+)"`)
-	reInputField    = regexp.MustCompile(`Field "[^"]+" is not defined by type "([^"]+)"`)
-	reUnknownArg    = regexp.MustCompile(`Unknown argument "[^"]+" on field "([^.]+)\.[^"]+"`)
-	reNeedsSubfield = regexp.MustCompile(`Field "[^"]+" of type "([^"]+)" must have a selection of subfields`)
-)
-
-func makeSchemaHintPresenter(d *Describer) graphql.ErrorPresenterFunc {
+func makeSchemaHintPresenter(d *Describer, timeout time.Duration) graphql.ErrorPresenterFunc {
 	return func(ctx context.Context, err error) *gqlerror.Error {
 		gqlErr, ok := err.(*gqlerror.Error)
 		if !ok {
 			gqlErr = &gqlerror.Error{Message: err.Error()}
 		}
 
-		var typeName string
-		switch {
-		case len(reOutputField.FindStringSubmatch(gqlErr.Message)) == 2:
-			typeName = reOutputField.FindStringSubmatch(gqlErr.Message)[1]
-		case len(reInputField.FindStringSubmatch(gqlErr.Message)) == 2:
-			typeName = reInputField.FindStringSubmatch(gqlErr.Message)[1]
-		case len(reUnknownArg.FindStringSubmatch(gqlErr.Message)) == 2:
-			typeName = reUnknownArg.FindStringSubmatch(gqlErr.Message)[1]
-		case len(reNeedsSubfield.FindStringSubmatch(gqlErr.Message)) == 2:
-			raw := reNeedsSubfield.FindStringSubmatch(gqlErr.Message)[1]
-			typeName = strings.Trim(raw, "[]!")
-		}
-
-		if typeName != "" {
-			if hint, hintErr := d.Describe(ctx, typeName); hintErr == nil && hint != "" {
-				if gqlErr.Extensions == nil {
-					gqlErr.Extensions = map[string]interface{}{}
-				}
-				gqlErr.Extensions["schemaHint"] = hint
-			}
-		}
+		hctx, cancel := hintContext(ctx, timeout)
+		defer cancel()
+		gqlErr.Extensions = AttachHint(hctx, d, gqlErr.Message, gqlErr.Extensions)
 		return gqlErr
 	}
 }
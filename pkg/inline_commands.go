@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
@@ -14,9 +17,14 @@ import (
 // It provides query, mutation, describe, and types commands that run in-process
 // without needing an HTTP server.
 type InlineCommandSet struct {
-	exec   *InlineExecutor
-	tokens *TokenStore
-	login  *LoginConfig
+	exec    *InlineExecutor
+	tokens  *TokenStore
+	login   *LoginConfig
+	usage   func(UsageEvent)
+	history string
+
+	readOnly       bool
+	readOnlySource string
 }
 
 // LoginConfig configures the login/logout/whoami commands.
@@ -31,11 +39,36 @@ type LoginConfig struct {
 	// Tokens is the store where the token will be persisted.
 	// If nil, the InlineCommandSet's TokenStore is used.
 	Tokens *TokenStore
+
+	// ExtraVariables, if set, returns additional variables for the login
+	// mutation, such as a device ID from TokenStore.DeviceID or the app version.
+	// The email and password flags take precedence over keys of the same name.
+	ExtraVariables func(ctx context.Context) map[string]interface{}
+
+	// RateLimitRetries is how many times a rate-limited login is retried
+	// (default 0: fail immediately). See isRateLimited for what counts as rate
+	// limiting. Retries wait for the server's retryAfter, else back off
+	// exponentially from one second. A retryAfter over 30 seconds fails at
+	// once.
+	RateLimitRetries int
 }
 
 // CommandSetOption configures an InlineCommandSet.
 type CommandSetOption func(*InlineCommandSet)
 
+// WithReadOnly refuses to run mutations: the mutation command is hidden and
+// disabled, and the query command rejects documents that select a mutation.
+// source names what enforced it in errors (e.g. "profile analyst"); empty
+// means "config". The login command is unaffected.
+func WithReadOnly(source string) CommandSetOption {
+	return func(cs *InlineCommandSet) {
+		if source == "" {
+			source = "config"
+		}
+		cs.readOnly, cs.readOnlySource = true, source
+	}
+}
+
 // WithTokenStore attaches a TokenStore.
 // The saved token is made available for the whoami and logout commands.
 // To inject it into operations, use WithContextEnricher on the InlineExecutor.
@@ -78,10 +111,16 @@ func (cs *InlineCommandSet) Commands() []*cli.Command {
 		cs.mutationCommand(),
 		cs.describeCommand(),
 		cs.typesCommand(),
+		cs.browseCommand(),
 	}
 	if cs.login != nil {
 		cmds = append(cmds, cs.loginCommand(), cs.logoutCommand(), cs.whoamiCommand())
 	}
+	cmds = append(cmds, examplesCommand("inline", ""))
+	cmds = withUsage(cmds, usageRecorders(cs.usage, cs.history))
+	if cs.history != "" {
+		cmds = append(cmds, historyCommand(cs.history))
+	}
 	return cmds
 }
 
@@ -94,10 +133,19 @@ func (cs *InlineCommandSet) queryCommand() *cli.Command {
 		Usage:   "Execute a GraphQL query",
 		Flags:   inlineOperationFlags("toon"),
 		Action: func(c *cli.Context) error {
+			if err := checkFormats(c, NewFormatterRegistry()); err != nil {
+				return err
+			}
 			op, vars, err := readInlineOperation(c)
 			if err != nil {
 				return err
 			}
+			noteUsageOperation(c, op, "")
+			if cs.readOnly {
+				if err := checkReadOnly(cs.readOnlySource, op, ""); err != nil {
+					return err
+				}
+			}
 			raw, err := cs.exec.Execute(context.Background(), op, vars)
 			if err != nil {
 				return err
@@ -115,11 +163,19 @@ func (cs *InlineCommandSet) mutationCommand() *cli.Command {
 		Aliases: []string{"m"},
 		Usage:   "Execute a GraphQL mutation",
 		Flags:   inlineOperationFlags("json"),
+		Hidden:  cs.readOnly,
 		Action: func(c *cli.Context) error {
+			if cs.readOnly {
+				return &ReadOnlyError{Source: cs.readOnlySource, Reason: "the mutation command is disabled"}
+			}
+			if err := checkFormats(c, NewFormatterRegistry()); err != nil {
+				return err
+			}
 			op, vars, err := readInlineOperation(c)
 			if err != nil {
 				return err
 			}
+			noteUsageOperation(c, op, "")
 			raw, err := cs.exec.Execute(context.Background(), op, vars)
 			if err != nil {
 				return err
@@ -140,6 +196,15 @@ func (cs *InlineCommandSet) describeCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "args", Aliases: []string{"a"}, Usage: "Expand field argument signatures"},
 			&cli.BoolFlag{Name: "descriptions", Usage: "Include field/type descriptions"},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"i"},
+				Usage:   "Then pick a referenced type to describe next (b: back, q: quit); ignored without a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "variables-template",
+				Usage: "Print example JSON for an input object type instead of its SDL (values from RegisterValueGenerator where registered)",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() == 0 {
@@ -147,6 +212,33 @@ func (cs *InlineCommandSet) describeCommand() *cli.Command {
 			}
 			typeName := c.Args().First()
 			d := NewDescriber(cs.exec)
+			if c.Bool("variables-template") {
+				// A fixed seed gives the same template on every run.
+				tmpl, err := d.inputTemplate(context.Background(), typeName, rand.New(rand.NewSource(1)))
+				if err != nil {
+					return err
+				}
+				// Placeholders such as "<bigint>" are printed as written.
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetEscapeHTML(false)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(tmpl); err != nil {
+					return fmt.Errorf("failed to encode template: %w", err)
+				}
+				return nil
+			}
+			if c.Bool("interactive") && isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+				// The session is drawn on stderr; stdout gets the visited
+				// types at exit, e.g. for notes.
+				nav := newDescribeNavigator(d, os.Stdin, os.Stderr, c.Bool("args"), c.Bool("descriptions"))
+				fmt.Fprint(os.Stderr, describeNavHelp)
+				visited, err := nav.run(context.Background(), typeName)
+				if err != nil {
+					return err
+				}
+				fmt.Println(strings.Join(visited, "\n"))
+				return nil
+			}
 			hint, err := d.DescribeWith(context.Background(), typeName, c.Bool("args"), c.Bool("descriptions"))
 			if err != nil {
 				return err
@@ -175,7 +267,7 @@ func (cs *InlineCommandSet) typesCommand() *cli.Command {
 			}
 
 			var result map[string]interface{}
-			if err := json.Unmarshal(raw, &result); err != nil {
+			if err := decodeJSON(raw, &result); err != nil {
 				return fmt.Errorf("failed to parse response: %w", err)
 			}
 			data, _ := result["data"].(map[string]interface{})
@@ -222,6 +314,64 @@ func (cs *InlineCommandSet) typesCommand() *cli.Command {
 	}
 }
 
+// --- browse ---
+
+func (cs *InlineCommandSet) browseCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "browse",
+		Usage: "Explore the schema as an expandable tree",
+		Flags: append(browseFlags(), &cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "Re-introspect the schema instead of using the cached copy",
+		}),
+		Action: func(c *cli.Context) error {
+			result, err := cs.cachedSchema(context.Background(), c.App.Name, c.Bool("refresh"))
+			if err != nil {
+				return err
+			}
+			return runBrowse(c, result)
+		},
+	}
+}
+
+// cachedSchema returns the introspection result for the executor's schema from
+// the schema cache, introspecting and caching it when missing or when refresh
+// is set. Failing to write the cache is only a warning.
+func (cs *InlineCommandSet) cachedSchema(ctx context.Context, app string, refresh bool) (map[string]interface{}, error) {
+	cache := NewSchemaCache("")
+	key := inlineCacheKey(app)
+	if !refresh {
+		if result, err := cache.LoadSchema(key); err == nil {
+			return result, nil
+		}
+	}
+	raw, err := cs.exec.Execute(ctx, introspectionQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := decodeJSON(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := cache.SaveSchema(key, result); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to cache schema: %v\n", err)
+	}
+	return result, nil
+}
+
+// inlineCacheKey is the SchemaCache key for the schema compiled into app. It
+// includes the executable's path and modification time, so a rebuilt binary
+// never reads a schema cached by an earlier build.
+func inlineCacheKey(app string) string {
+	key := "inline:" + app
+	if path, err := os.Executable(); err == nil {
+		if fi, err := os.Stat(path); err == nil {
+			key += ":" + path + ":" + strconv.FormatInt(fi.ModTime().UnixNano(), 10)
+		}
+	}
+	return key
+}
+
 // --- login ---
 
 func (cs *InlineCommandSet) loginCommand() *cli.Command {
@@ -234,26 +384,18 @@ func (cs *InlineCommandSet) loginCommand() *cli.Command {
 		},
 		Action: func(c *cli.Context) error {
 			cfg := cs.login
-			variables := map[string]interface{}{
-				"email":    c.String("email"),
-				"password": c.String("password"),
+			variables := map[string]interface{}{}
+			if cfg.ExtraVariables != nil {
+				for k, v := range cfg.ExtraVariables(c.Context) {
+					variables[k] = v
+				}
 			}
+			variables["email"] = c.String("email")
+			variables["password"] = c.String("password")
 
-			raw, err := cs.exec.Execute(context.Background(), cfg.Mutation, variables)
+			result, err := cs.executeLogin(c.Context, variables)
 			if err != nil {
-				return fmt.Errorf("login failed: %w", err)
-			}
-
-			var result map[string]interface{}
-			if err := json.Unmarshal(raw, &result); err != nil {
-				return fmt.Errorf("failed to parse response: %w", err)
-			}
-
-			if errs, ok := result["errors"].([]interface{}); ok && len(errs) > 0 {
-				if em, ok := errs[0].(map[string]interface{}); ok {
-					return fmt.Errorf("login failed: %s", em["message"])
-				}
-				return fmt.Errorf("login failed")
+				return err
 			}
 
 			data, _ := result["data"].(map[string]interface{})
@@ -276,6 +418,78 @@ func (cs *InlineCommandSet) loginCommand() *cli.Command {
 	}
 }
 
+// executeLogin runs the login mutation, retrying up to cfg.RateLimitRetries
+// times while the server reports rate limiting.
+func (cs *InlineCommandSet) executeLogin(ctx context.Context, variables map[string]interface{}) (map[string]interface{}, error) {
+	cfg := cs.login
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		raw, err := cs.exec.Execute(ctx, cfg.Mutation, variables)
+		if err != nil {
+			return nil, fmt.Errorf("login failed: %w", err)
+		}
+
+		var result map[string]interface{}
+		if err := decodeJSON(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		errs, _ := result["errors"].([]interface{})
+		if len(errs) == 0 {
+			return result, nil
+		}
+		em, ok := errs[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("login failed")
+		}
+		limited, wait := isRateLimited(em)
+		if !limited {
+			return nil, fmt.Errorf("login failed: %s", em["message"])
+		}
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+		if attempt >= cfg.RateLimitRetries || wait > maxRateLimitWait {
+			return nil, fmt.Errorf("login failed: %s; try again in %s", em["message"], wait.Round(time.Second))
+		}
+		fmt.Fprintf(os.Stderr, "login rate limited; retrying in %s (%d/%d)\n", wait.Round(time.Second), attempt+1, cfg.RateLimitRetries)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("login failed: %w", ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// isRateLimited reports whether a GraphQL error means the login was rate
+// limited: extensions.code is RATE_LIMITED, RATE_LIMIT_EXCEEDED, or
+// TOO_MANY_REQUESTS (any case), or extensions.retryAfter is set. The wait is
+// taken from extensions.retryAfter in seconds, or 0 if absent.
+func isRateLimited(gqlErr map[string]interface{}) (bool, time.Duration) {
+	ext, _ := gqlErr["extensions"].(map[string]interface{})
+	if ext == nil {
+		return false, 0
+	}
+	var wait time.Duration
+	switch v := ext["retryAfter"].(type) {
+	case json.Number:
+		if secs, err := v.Float64(); err == nil && secs > 0 {
+			wait = time.Duration(secs * float64(time.Second))
+		}
+	case string:
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			wait = time.Duration(secs * float64(time.Second))
+		}
+	}
+	code, _ := ext["code"].(string)
+	switch strings.ToUpper(code) {
+	case "RATE_LIMITED", "RATE_LIMIT_EXCEEDED", "TOO_MANY_REQUESTS":
+		return true, wait
+	}
+	return wait > 0, wait
+}
+
 // --- logout ---
 
 func (cs *InlineCommandSet) logoutCommand() *cli.Command {
@@ -323,14 +537,16 @@ func (cs *InlineCommandSet) whoamiCommand() *cli.Command {
 // --- shared helpers ---
 
 func inlineOperationFlags(defaultFormat string) []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		&cli.StringFlag{Name: "query", Aliases: []string{"q"}, Usage: "GraphQL operation string"},
 		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "File containing the GraphQL operation"},
 		&cli.StringFlag{Name: "variables", Aliases: []string{"v"}, Usage: "Variables as JSON string"},
 		&cli.StringFlag{Name: "var-file", Usage: "File containing variables as JSON"},
 		&cli.StringFlag{Name: "format", Usage: "Output format: json, toon, table", Value: defaultFormat},
 		&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write output to a file"},
-	}
+		&cli.BoolFlag{Name: "accessible", Usage: "Screen-reader-friendly output (same as --format plain)"},
+		&cli.StringFlag{Name: "format-fallback", Usage: "Format to use if the chosen formatter fails (default: fail)", EnvVars: []string{"GQLCLI_FORMAT_FALLBACK"}},
+	}, append(append(varFlags(), sampleFlags()...), postResultFlags()...)...)
 }
 
 // readInlineOperation reads the GraphQL operation and variables from CLI flags/args.
@@ -358,15 +574,22 @@ func readInlineOperation(c *cli.Context) (string, map[string]interface{}, error)
 		if err != nil {
 			return "", nil, fmt.Errorf("failed to read variables file: %w", err)
 		}
-		if err := json.Unmarshal(b, &vars); err != nil {
+		if err := decodeJSON(b, &vars); err != nil {
 			return "", nil, fmt.Errorf("invalid variables JSON in file: %w", err)
 		}
 	case c.String("variables") != "" && c.String("variables") != "{}":
-		if err := json.Unmarshal([]byte(c.String("variables")), &vars); err != nil {
+		if err := decodeJSON([]byte(c.String("variables")), &vars); err != nil {
 			return "", nil, fmt.Errorf("invalid variables JSON: %w", err)
 		}
 	}
 
+	vars, err := applyVarFlags(c, vars, op, "")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := checkVariables(c, vars, op, ""); err != nil {
+		return "", nil, err
+	}
 	return op, vars, nil
 }
 
@@ -374,11 +597,17 @@ func readInlineOperation(c *cli.Context) (string, map[string]interface{}, error)
 // GraphQL errors are printed with their schemaHint extension if present.
 func printInlineResult(c *cli.Context, raw json.RawMessage) error {
 	var result map[string]interface{}
-	if err := json.Unmarshal(raw, &result); err != nil {
+	if err := decodeJSON(raw, &result); err != nil {
 		return err
 	}
 
 	if errs, ok := result["errors"].([]interface{}); ok && len(errs) > 0 {
+		noteUsageError(c, "graphql")
+		if c.Bool("accessible") {
+			out, _ := NewPlainFormatter().Format(map[string]interface{}{"errors": errs})
+			fmt.Println(out)
+			return nil
+		}
 		for _, e := range errs {
 			em, ok := e.(map[string]interface{})
 			if !ok {
@@ -395,22 +624,23 @@ func printInlineResult(c *cli.Context, raw json.RawMessage) error {
 		return nil
 	}
 
-	format := c.String("format")
-	reg := NewFormatterRegistry()
-	formatter, err := reg.Get(format)
+	format := selectedFormat(c)
+	notes, err := applySample(c, result)
 	if err != nil {
-		formatter, _ = reg.Get("json")
+		return err
 	}
-
-	out, err := formatter.Format(result)
+	out, err := formatResult(NewFormatterRegistry(), format, c.String("format-fallback"), result)
 	if err != nil {
 		return err
 	}
+	out = withSampleNotes(out, format, notes)
 
 	if outFile := c.String("output"); outFile != "" {
-		return os.WriteFile(outFile, []byte(out), 0644)
+		if err := os.WriteFile(outFile, []byte(out), 0644); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(out)
 	}
-
-	fmt.Println(out)
-	return nil
+	return postResult(c, out, result)
 }
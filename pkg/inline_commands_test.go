@@ -0,0 +1,160 @@
+package gqlcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wricardo/gqlcli/internal/testschema"
+)
+
+func TestInlineQueryCommand(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "query", "--format", "json", "--var", "first=2", `query($first: Int) { books(first: $first) { id title } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"books":[{"id":"b1","title":"The Dispossessed"},{"id":"b2","title":"A Wizard of Earthsea"}]}}`
+	if strings.TrimSpace(stdout) != want {
+		t.Errorf("got %s\nwant %s", stdout, want)
+	}
+}
+
+func TestInlineQueryCommandPrintsSchemaHint(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "query", `{ books { nope } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `Error: Cannot query field "nope" on type "Book".`, "Schema hint:", "type Book {")
+}
+
+func TestInlineMutationCommand(t *testing.T) {
+	app, schema := inlineApp(t)
+	stdout, _, err := runApp(t, app, "mutation",
+		"-q", `mutation($in: AddBookInput!) { addBook(input: $in) { id isbn } }`,
+		"--variables", `{"in":{"title":"Contact","authorName":"Carl Sagan","details":{"isbn":9007199254740993}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"isbn":9007199254740993`)
+
+	e := NewInlineExecutor(schema)
+	if got := execute(t, e, `{ book(id: "b5") { title } }`, nil)["data"].(map[string]interface{})["book"]; got == nil {
+		t.Error("mutation did not persist the book")
+	}
+}
+
+func TestInlineCommandsReadOnly(t *testing.T) {
+	app, _ := inlineApp(t, WithReadOnly("test"))
+	_, _, err := runApp(t, app, "mutation", `mutation { archiveBooks(ids: ["b1"]) }`)
+	var roErr *ReadOnlyError
+	if !errors.As(err, &roErr) {
+		t.Fatalf("mutation command: got %v, want a ReadOnlyError", err)
+	}
+	_, _, err = runApp(t, app, "query", `mutation { archiveBooks(ids: ["b1"]) }`)
+	if !errors.As(err, &roErr) {
+		t.Errorf("query command: got %v, want a ReadOnlyError", err)
+	}
+}
+
+func TestInlineDescribeCommand(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "describe", "--args", "Mutation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "describe/mutation-args.graphql", stdout)
+}
+
+func TestInlineTypesCommand(t *testing.T) {
+	app, _ := inlineApp(t)
+	stdout, _, err := runApp(t, app, "types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "types.txt", stdout)
+
+	stdout, _, err = runApp(t, app, "types", "--filter", "book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Types:\n  Book\nInputs:\n  AddBookInput\n  BookDetailsInput\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+}
+
+const testLoginMutation = `mutation($email: String!, $password: String!, $deviceId: String) {
+  login(email: $email, password: $password, deviceId: $deviceId)
+}`
+
+func testLoginConfig(ts *TokenStore) LoginConfig {
+	return LoginConfig{
+		Mutation: testLoginMutation,
+		Tokens:   ts,
+		ExtractToken: func(data map[string]interface{}) (string, error) {
+			token, _ := data["login"].(string)
+			if token == "" {
+				return "", fmt.Errorf("no token in %v", data)
+			}
+			return token, nil
+		},
+	}
+}
+
+func TestInlineLoginCommands(t *testing.T) {
+	ts := NewTokenStoreAt(t.TempDir())
+	cfg := testLoginConfig(ts)
+	cfg.ExtraVariables = func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"deviceId": "dev-1", "email": "ignored@example.com"}
+	}
+	app, schema := inlineApp(t, WithLogin(cfg))
+
+	stdout, _, err := runApp(t, app, "whoami")
+	if err != nil || strings.TrimSpace(stdout) != "Not logged in." {
+		t.Fatalf("whoami before login: %q, %v", stdout, err)
+	}
+
+	_, _, err = runApp(t, app, "login", "--email", "ada@example.com", "--password", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("bad password: got %v", err)
+	}
+
+	if _, _, err := runApp(t, app, "login", "-e", "ada@example.com", "-p", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := ts.Load(); token != "token-ada@example.com" {
+		t.Errorf("saved token %q", token)
+	}
+	logins := schema.Logins()
+	last := logins[len(logins)-1]
+	if last["deviceId"] != "dev-1" || last["email"] != "ada@example.com" {
+		t.Errorf("login variables %v: want the extra deviceId and the flag's email", last)
+	}
+
+	stdout, _, err = runApp(t, app, "logout")
+	if err != nil || strings.TrimSpace(stdout) != "Logged out." || ts.Exists() {
+		t.Errorf("logout: %q, %v, token still saved: %v", stdout, err, ts.Exists())
+	}
+}
+
+func TestInlineLoginRateLimitRetries(t *testing.T) {
+	ts := NewTokenStoreAt(t.TempDir())
+	cfg := testLoginConfig(ts)
+	cfg.RateLimitRetries = 2
+	app, schema := inlineApp(t, WithLogin(cfg))
+
+	_, stderr, err := runApp(t, app, "login", "-e", testschema.RateLimitedEmail, "-p", "secret")
+	if err == nil || !strings.Contains(err.Error(), "too many login attempts") {
+		t.Errorf("got %v, want the rate limit error", err)
+	}
+	if n := len(schema.Logins()); n != 3 {
+		t.Errorf("login ran %d times, want 1 + 2 retries", n)
+	}
+	if strings.Count(stderr, "login rate limited; retrying") != 2 {
+		t.Errorf("stderr: %q", stderr)
+	}
+}
@@ -0,0 +1,255 @@
+package gqlcli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestInlineExecutorQuery(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `query($g: Genre) { books(genre: $g) { title author { name } } }`, map[string]interface{}{"g": "FICTION"})
+
+	books := result["data"].(map[string]interface{})["books"].([]interface{})
+	if len(books) != 2 {
+		t.Fatalf("got %d fiction books, want 2: %v", len(books), books)
+	}
+	first := books[0].(map[string]interface{})
+	if first["title"] != "The Dispossessed" || first["author"].(map[string]interface{})["name"] != "Ursula K. Le Guin" {
+		t.Errorf("unexpected first book: %v", first)
+	}
+}
+
+func TestInlineExecutorUnionAndEnum(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `{ search(text: "sagan") { __typename ... on Author { name books { genre } } } }`, nil)
+
+	hits := result["data"].(map[string]interface{})["search"].([]interface{})
+	if len(hits) != 1 {
+		t.Fatalf("got %v", hits)
+	}
+	hit := hits[0].(map[string]interface{})
+	if hit["__typename"] != "Author" || hit["name"] != "Carl Sagan" {
+		t.Errorf("unexpected hit: %v", hit)
+	}
+	if genre := hit["books"].([]interface{})[0].(map[string]interface{})["genre"]; genre != "SCIENCE" {
+		t.Errorf("genre = %v, want SCIENCE", genre)
+	}
+}
+
+func TestInlineExecutorMutationNestedInput(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `mutation($in: AddBookInput!) { addBook(input: $in) { id genre tags pages author { name } } }`,
+		map[string]interface{}{"in": map[string]interface{}{
+			"title":      "Pale Blue Dot",
+			"authorName": "Carl Sagan",
+			"genre":      "SCIENCE",
+			"tags":       []interface{}{"space"},
+			"details":    map[string]interface{}{"pages": 429, "published": map[string]interface{}{"year": 1994}},
+		}})
+	book := result["data"].(map[string]interface{})["addBook"].(map[string]interface{})
+	if book["id"] != "b5" || book["genre"] != "SCIENCE" || book["pages"] != json.Number("429") {
+		t.Errorf("unexpected book: %v", book)
+	}
+
+	after := execute(t, e, `{ books { title } }`, nil)
+	if n := len(after["data"].(map[string]interface{})["books"].([]interface{})); n != 5 {
+		t.Errorf("got %d books after addBook, want 5", n)
+	}
+	fresh, _ := newTestExecutor(t)
+	again := execute(t, fresh, `{ books { title } }`, nil)
+	if n := len(again["data"].(map[string]interface{})["books"].([]interface{})); n != 4 {
+		t.Errorf("a new schema has %d books, want the 4 seeded", n)
+	}
+}
+
+func TestInlineExecutorUpload(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `mutation($f: Upload!) { uploadCover(bookId: "b3", file: $f) { coverUploaded } }`,
+		map[string]interface{}{"f": "cover.png"})
+	if got := result["data"].(map[string]interface{})["uploadCover"].(map[string]interface{})["coverUploaded"]; got != true {
+		t.Errorf("coverUploaded = %v", got)
+	}
+}
+
+func TestInlineExecutorFieldErrorKeepsData(t *testing.T) {
+	e, _ := newTestExecutor(t)
+	result := execute(t, e, `{ failing bigNumber }`, nil)
+
+	errs, _ := result["errors"].([]interface{})
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want one", result["errors"])
+	}
+	em := errs[0].(map[string]interface{})
+	if em["message"] != "failing always fails" || em["path"].([]interface{})[0] != "failing" {
+		t.Errorf("unexpected error: %v", em)
+	}
+	data := result["data"].(map[string]interface{})
+	if data["failing"] != nil || data["bigNumber"] != json.Number("9007199254740993") {
+		t.Errorf("unexpected data: %v", data)
+	}
+}
+
+func TestInlineExecutorContextEnricher(t *testing.T) {
+	deadline, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	e, _ := newTestExecutor(t, WithContextEnricher(func(context.Context) context.Context {
+		return deadline
+	}))
+	start := time.Now()
+	result := execute(t, e, `{ slow(ms: 5000) }`, nil)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("slow resolver ignored the enriched deadline: took %s", elapsed)
+	}
+	if errs, _ := result["errors"].([]interface{}); len(errs) != 1 {
+		t.Errorf("got %v, want a deadline error", result)
+	}
+}
+
+func TestInlineExecutorSchemaHints(t *testing.T) {
+	const bad = `{ books { nope } }`
+
+	plain, _ := newTestExecutor(t)
+	em := execute(t, plain, bad, nil)["errors"].([]interface{})[0].(map[string]interface{})
+	if ext, _ := em["extensions"].(map[string]interface{}); ext["schemaHint"] != nil {
+		t.Errorf("hint attached without WithSchemaHints: %v", ext)
+	}
+
+	hinted, _ := newTestExecutor(t, WithSchemaHints())
+	em = execute(t, hinted, bad, nil)["errors"].([]interface{})[0].(map[string]interface{})
+	hint, _ := em["extensions"].(map[string]interface{})["schemaHint"].(string)
+	assertContains(t, hint, "type Book {", "title: String!", "author: Author!")
+}
+
+// docRecorder is a server extension that records the parsed document of
+// every operation, so tests can tell a query cache hit (the same document)
+// from a fresh parse.
+type docRecorder struct{ docs []*ast.QueryDocument }
+
+func (r *docRecorder) ExtensionName() string                   { return "docRecorder" }
+func (r *docRecorder) Validate(graphql.ExecutableSchema) error { return nil }
+func (r *docRecorder) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	r.docs = append(r.docs, graphql.GetOperationContext(ctx).Doc)
+	return next(ctx)
+}
+
+func TestInlineExecutorQueryCache(t *testing.T) {
+	const query = `{ books { title } }`
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantSame bool
+	}{
+		{"default", nil, true},
+		{"sized", []Option{WithQueryCache(10)}, true},
+		{"disabled", []Option{WithQueryCache(0)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestExecutor(t, tt.opts...)
+			rec := &docRecorder{}
+			e.Server().Use(rec)
+			for i := 0; i < 2; i++ {
+				if errs := execute(t, e, query, nil)["errors"]; errs != nil {
+					t.Fatalf("run %d: %v", i+1, errs)
+				}
+			}
+			if len(rec.docs) != 2 {
+				t.Fatalf("recorded %d operations, want 2", len(rec.docs))
+			}
+			if same := rec.docs[0] == rec.docs[1]; same != tt.wantSame {
+				t.Errorf("second run reused the parsed document: %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestInlineExecutorOptionCombinations(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"defaults", nil},
+		{"no caches", []Option{WithQueryCache(0), WithAPQ(false, 0)}},
+		{"APQ with an empty cache", []Option{WithAPQ(true, 0)}},
+		{"small caches", []Option{WithQueryCache(1), WithAPQ(true, 1)}},
+		{"POST only", []Option{WithTransports(transport.POST{})}},
+		{"POST only without caches", []Option{WithTransports(transport.POST{}), WithQueryCache(0), WithAPQ(false, 0)}},
+		{"hints without a query cache", []Option{WithSchemaHints(), WithQueryCache(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestExecutor(t, tt.opts...)
+			for _, q := range []string{`{ books { title } }`, `{ books { title } }`, `{ authors { name } }`} {
+				result := execute(t, e, q, nil)
+				if result["errors"] != nil || result["data"] == nil {
+					t.Fatalf("%s: %v", q, result)
+				}
+			}
+			// Introspection stays on whatever the options.
+			if _, err := NewDescriber(e).Describe(context.Background(), "Book"); err != nil {
+				t.Errorf("describe: %v", err)
+			}
+		})
+	}
+
+	// Without the POST transport Execute's requests are refused.
+	e, _ := newTestExecutor(t, WithTransports(transport.GET{}))
+	if result := execute(t, e, `{ books { title } }`, nil); result["data"] != nil || result["errors"] == nil {
+		t.Errorf("a GET-only server answered a POST: %v", result)
+	}
+}
+
+func TestInlineExecutorAPQ(t *testing.T) {
+	const query = `{ books { title } }`
+	sum := sha256.Sum256([]byte(query))
+	persisted := map[string]interface{}{"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])}}
+	post := func(t *testing.T, e *InlineExecutor, body map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		e.Server().ServeHTTP(rr, req)
+		var result map[string]interface{}
+		if err := decodeJSON(rr.Body.Bytes(), &result); err != nil {
+			t.Fatalf("decode %s: %v", rr.Body, err)
+		}
+		return result
+	}
+	message := func(result map[string]interface{}) string {
+		errs, _ := result["errors"].([]interface{})
+		if len(errs) == 0 {
+			return ""
+		}
+		msg, _ := errs[0].(map[string]interface{})["message"].(string)
+		return msg
+	}
+
+	e, _ := newTestExecutor(t)
+	if msg := message(post(t, e, map[string]interface{}{"extensions": persisted})); msg != "PersistedQueryNotFound" {
+		t.Errorf("unknown hash: got %q, want PersistedQueryNotFound", msg)
+	}
+	if result := post(t, e, map[string]interface{}{"query": query, "extensions": persisted}); result["data"] == nil {
+		t.Fatalf("registering the query: %v", result)
+	}
+	if result := post(t, e, map[string]interface{}{"extensions": persisted}); result["data"] == nil {
+		t.Errorf("known hash: %v", result)
+	}
+
+	off, _ := newTestExecutor(t, WithAPQ(false, 0))
+	post(t, off, map[string]interface{}{"query": query, "extensions": persisted})
+	if result := post(t, off, map[string]interface{}{"extensions": persisted}); result["data"] != nil || message(result) == "" {
+		t.Errorf("a hash alone ran with APQ disabled: %v", result)
+	}
+}
@@ -0,0 +1,434 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// SavedOperation is a named GraphQL operation kept for reuse.
+type SavedOperation struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Query       string    `json:"query"`
+	Tags        []string  `json:"tags,omitempty"`
+	Saved       time.Time `json:"saved"`
+}
+
+// HasTag reports whether op is tagged with tag.
+func (op SavedOperation) HasTag(tag string) bool {
+	for _, t := range op.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// OperationStore persists saved operations in a JSON file. Unlike the schema
+// cache, saved operations are the user's own work, so they live outside the
+// cache directory and survive clearing it.
+type OperationStore struct {
+	path string
+}
+
+// DefaultOperationsPath returns $GQLCLI_OPS_FILE if set, otherwise
+// ~/.gqlcli/operations.json.
+func DefaultOperationsPath() string {
+	if path := os.Getenv("GQLCLI_OPS_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gqlcli", "operations.json")
+}
+
+// NewOperationStore creates a store persisted at path. An empty path uses
+// DefaultOperationsPath.
+func NewOperationStore(path string) *OperationStore {
+	if path == "" {
+		path = DefaultOperationsPath()
+	}
+	return &OperationStore{path: path}
+}
+
+// List returns the saved operations sorted by name. A non-empty tag keeps only
+// the operations carrying it.
+func (s *OperationStore) List(tag string) ([]SavedOperation, error) {
+	ops, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SavedOperation, 0, len(ops))
+	for _, op := range ops {
+		if tag == "" || op.HasTag(tag) {
+			out = append(out, op)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Get returns the operation called name, or nil if there is none.
+func (s *OperationStore) Get(name string) (*SavedOperation, error) {
+	ops, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := ops[name]
+	if !ok {
+		return nil, nil
+	}
+	return &op, nil
+}
+
+// Put stores op, replacing any operation with the same name.
+// Tags are validated with ValidateTag and de-duplicated.
+func (s *OperationStore) Put(op SavedOperation) error {
+	tags, err := normalizeTags(op.Tags)
+	if err != nil {
+		return err
+	}
+	op.Tags = tags
+	ops, err := s.load()
+	if err != nil {
+		return err
+	}
+	ops[op.Name] = op
+	return s.save(ops)
+}
+
+// Delete removes the operation called name and reports whether it existed.
+func (s *OperationStore) Delete(name string) (bool, error) {
+	ops, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := ops[name]; !ok {
+		return false, nil
+	}
+	delete(ops, name)
+	return true, s.save(ops)
+}
+
+// Search returns the operations whose name, description, or body contains
+// text, case-insensitively, sorted by name.
+func (s *OperationStore) Search(text string) ([]SavedOperation, error) {
+	all, err := s.List("")
+	if err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(text)
+	var out []SavedOperation
+	for _, op := range all {
+		if strings.Contains(strings.ToLower(op.Name), needle) ||
+			strings.Contains(strings.ToLower(op.Description), needle) ||
+			strings.Contains(strings.ToLower(op.Query), needle) {
+			out = append(out, op)
+		}
+	}
+	return out, nil
+}
+
+func (s *OperationStore) load() (map[string]SavedOperation, error) {
+	ops := map[string]SavedOperation{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return ops, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operations: %w", err)
+	}
+	if err := decodeJSON(data, &ops); err != nil {
+		return nil, fmt.Errorf("corrupt operations file %s: %w", s.path, err)
+	}
+	return ops, nil
+}
+
+func (s *OperationStore) save(ops map[string]SavedOperation) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create operations directory: %w", err)
+	}
+	data, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode operations: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write operations: %w", err)
+	}
+	return nil
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateTag reports whether tag is usable: lowercase letters, digits, "-"
+// and "_", starting with a letter or digit.
+func ValidateTag(tag string) error {
+	if tagPattern.MatchString(tag) {
+		return nil
+	}
+	switch {
+	case tag == "":
+		return fmt.Errorf("empty tag")
+	case strings.ContainsAny(tag, " \t"):
+		return fmt.Errorf("invalid tag %q: tags cannot contain spaces (pass several tags as separate arguments)", tag)
+	case strings.ToLower(tag) != tag:
+		return fmt.Errorf("invalid tag %q: tags must be lowercase (try %q)", tag, strings.ToLower(tag))
+	default:
+		return fmt.Errorf("invalid tag %q: use lowercase letters, digits, '-' and '_', starting with a letter or digit", tag)
+	}
+}
+
+// normalizeTags validates tags and returns them sorted without duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, t := range tags {
+		if err := ValidateTag(t); err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// GetOpsCommand returns the ops command for saving, tagging, and finding
+// named operations.
+func (b *CLIBuilder) GetOpsCommand() *cli.Command {
+	opsFileFlag := opsFileFlag()
+	formatFlag := &cli.StringFlag{
+		Name:    "format",
+		Aliases: []string{"f"},
+		Usage:   "Output format: table (default), json, json-pretty, toon, plain",
+		Value:   "table",
+	}
+	store := func(c *cli.Context) *OperationStore { return NewOperationStore(c.String("ops-file")) }
+
+	return &cli.Command{
+		Name:  "ops",
+		Usage: "Save, tag, and find named operations",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "save",
+				Usage:     "Save an operation under NAME, replacing any with the same name",
+				ArgsUsage: "NAME",
+				Flags: []cli.Flag{
+					opsFileFlag,
+					&cli.StringFlag{Name: "query", Aliases: []string{"q"}, Usage: "Operation text"},
+					&cli.StringFlag{Name: "query-file", Aliases: []string{"file"}, Usage: "Path to .graphql file containing the operation"},
+					&cli.StringFlag{Name: "description", Usage: "What the operation is for"},
+					&cli.StringSliceFlag{Name: "tag", Aliases: []string{"t"}, Usage: "Tag (repeatable)"},
+				},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return fmt.Errorf("NAME argument is required")
+					}
+					query := c.String("query")
+					if path := c.String("query-file"); path != "" {
+						data, err := os.ReadFile(path)
+						if err != nil {
+							return fmt.Errorf("failed to read query file: %w", err)
+						}
+						query = string(data)
+					}
+					if strings.TrimSpace(query) == "" {
+						return fmt.Errorf("operation is required (use --query or --query-file)")
+					}
+					return store(c).Put(SavedOperation{
+						Name:        name,
+						Description: c.String("description"),
+						Query:       query,
+						Tags:        c.StringSlice("tag"),
+						Saved:       time.Now().UTC(),
+					})
+				},
+			},
+			{
+				Name:      "tag",
+				Usage:     "Add tags to a saved operation (or remove them with --remove)",
+				ArgsUsage: "NAME TAG...",
+				Flags: []cli.Flag{
+					opsFileFlag,
+					&cli.BoolFlag{Name: "remove", Usage: "Remove the given tags instead of adding them"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("NAME and at least one TAG are required")
+					}
+					s := store(c)
+					op, err := mustGet(s, c.Args().First())
+					if err != nil {
+						return err
+					}
+					tags := c.Args().Tail()
+					for _, t := range tags {
+						if err := ValidateTag(t); err != nil {
+							return err
+						}
+					}
+					if c.Bool("remove") {
+						kept := op.Tags[:0]
+						for _, t := range op.Tags {
+							if !containsString(tags, t) {
+								kept = append(kept, t)
+							}
+						}
+						op.Tags = kept
+					} else {
+						op.Tags = append(op.Tags, tags...)
+					}
+					return s.Put(*op)
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List saved operations",
+				Flags: []cli.Flag{
+					opsFileFlag,
+					formatFlag,
+					&cli.StringFlag{Name: "tag", Aliases: []string{"t"}, Usage: "Only operations with this tag"},
+				},
+				Action: func(c *cli.Context) error {
+					if tag := c.String("tag"); tag != "" {
+						if err := ValidateTag(tag); err != nil {
+							return err
+						}
+					}
+					ops, err := store(c).List(c.String("tag"))
+					if err != nil {
+						return err
+					}
+					return b.printOperations(c, ops)
+				},
+			},
+			{
+				Name:      "search",
+				Usage:     "Find saved operations by name, description, or body",
+				ArgsUsage: "TEXT",
+				Flags:     []cli.Flag{opsFileFlag, formatFlag},
+				Action: func(c *cli.Context) error {
+					text := strings.Join(c.Args().Slice(), " ")
+					if text == "" {
+						return fmt.Errorf("TEXT argument is required")
+					}
+					ops, err := store(c).Search(text)
+					if err != nil {
+						return err
+					}
+					return b.printOperations(c, ops)
+				},
+			},
+			{
+				Name:      "delete",
+				Aliases:   []string{"rm"},
+				Usage:     "Delete saved operations",
+				ArgsUsage: "NAME...",
+				Flags:     []cli.Flag{opsFileFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return fmt.Errorf("NAME argument is required")
+					}
+					s := store(c)
+					for _, name := range c.Args().Slice() {
+						ok, err := s.Delete(name)
+						if err != nil {
+							return err
+						}
+						if !ok {
+							return fmt.Errorf("no saved operation named %q (see 'ops list')", name)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Print a saved operation's text",
+				ArgsUsage: "NAME",
+				Flags:     []cli.Flag{opsFileFlag},
+				Action: func(c *cli.Context) error {
+					op, err := mustGet(store(c), c.Args().First())
+					if err != nil {
+						return err
+					}
+					fmt.Println(strings.TrimSpace(op.Query))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// opsFileFlag selects the saved operations file for the ops command and --op.
+func opsFileFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "ops-file",
+		Usage:   "File holding saved operations (default: ~/.gqlcli/operations.json)",
+		EnvVars: []string{"GQLCLI_OPS_FILE"},
+	}
+}
+
+// mustGet returns the saved operation called name, failing when there is none.
+func mustGet(s *OperationStore, name string) (*SavedOperation, error) {
+	op, err := s.Get(name)
+	if err == nil && op == nil {
+		err = fmt.Errorf("no saved operation named %q (see 'ops list')", name)
+	}
+	return op, err
+}
+
+// savedOperationText returns the text of the operation named by --op, or ""
+// when --op is not set.
+func savedOperationText(c *cli.Context) (string, error) {
+	name := c.String("op")
+	if name == "" {
+		return "", nil
+	}
+	op, err := mustGet(NewOperationStore(c.String("ops-file")), name)
+	if err != nil {
+		return "", err
+	}
+	return op.Query, nil
+}
+
+// printOperations prints ops as rows of name, tags, and description.
+func (b *CLIBuilder) printOperations(c *cli.Context, ops []SavedOperation) error {
+	rows := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		rows = append(rows, map[string]interface{}{
+			"name":        op.Name,
+			"tags":        strings.Join(op.Tags, ","),
+			"description": op.Description,
+		})
+	}
+	formatter, err := b.formatReg.Get(c.String("format"))
+	if err != nil {
+		return err
+	}
+	out, err := formatter.Format(map[string]interface{}{"operations": rows})
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package gqlcli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOperationStore(t *testing.T) {
+	s := NewOperationStore(filepath.Join(t.TempDir(), "ops.json"))
+	for _, op := range []SavedOperation{
+		{Name: "shelf", Query: "{ books { title } }", Tags: []string{"reports", "books", "reports"}},
+		{Name: "invoice", Description: "Billing run", Query: "{ authors { name } }", Tags: []string{"billing"}},
+	} {
+		if err := s.Put(op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put(SavedOperation{Name: "bad", Query: "{ x }", Tags: []string{"Bad Tag"}}); err == nil {
+		t.Error("expected an invalid tag to be rejected")
+	}
+
+	op, err := s.Get("shelf")
+	if err != nil || op == nil {
+		t.Fatalf("Get: %v, %v", op, err)
+	}
+	if !reflect.DeepEqual(op.Tags, []string{"books", "reports"}) {
+		t.Errorf("tags = %v, want sorted without duplicates", op.Tags)
+	}
+	if list, _ := s.List("billing"); len(list) != 1 || list[0].Name != "invoice" {
+		t.Errorf("List(billing) = %v", list)
+	}
+	if found, _ := s.Search("BILLING"); len(found) != 1 || found[0].Name != "invoice" {
+		t.Errorf("Search matched %v", found)
+	}
+
+	if ok, err := s.Delete("shelf"); !ok || err != nil {
+		t.Fatalf("Delete: %v, %v", ok, err)
+	}
+	if ok, _ := s.Delete("shelf"); ok {
+		t.Error("deleted a missing operation")
+	}
+	if op, _ := s.Get("shelf"); op != nil {
+		t.Error("deleted operation is still stored")
+	}
+	if list, _ := s.List(""); len(list) != 1 {
+		t.Errorf("List after delete = %v", list)
+	}
+}
+
+func TestDefaultOperationsPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GQLCLI_OPS_FILE", "")
+	t.Setenv("GQLCLI_CACHE_DIR", filepath.Join(home, "cache"))
+	if got, want := DefaultOperationsPath(), filepath.Join(home, ".gqlcli", "operations.json"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	t.Setenv("GQLCLI_OPS_FILE", "/tmp/team-ops.json")
+	if got := DefaultOperationsPath(); got != "/tmp/team-ops.json" {
+		t.Errorf("GQLCLI_OPS_FILE ignored: %s", got)
+	}
+}
+
+func TestOpsCommands(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	t.Setenv("GQLCLI_OPS_FILE", "")
+
+	for _, args := range [][]string{
+		{"ops", "save", "--query", "query Shelf { books { title } }", "--tag", "reports", "shelf"},
+		{"ops", "save", "--query", `mutation { archiveBooks(ids: ["b4"]) }`, "--description", "Archive SPQR", "archive-spqr"},
+	} {
+		if _, _, err := runApp(t, app, args...); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	home, _ := os.UserHomeDir()
+	if _, err := os.Stat(filepath.Join(home, ".gqlcli", "operations.json")); err != nil {
+		t.Fatalf("operations were not saved under ~/.gqlcli: %v", err)
+	}
+
+	stdout, _, err := runApp(t, app, "query", "--op", "shelf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "Cosmos", "SPQR")
+
+	stdout, _, err = runApp(t, app, "mutation", "--op", "archive-spqr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"archiveBooks":1`)
+
+	stdout, _, _ = runApp(t, app, "ops", "list", "--format", "json")
+	assertContains(t, stdout, `"name":"archive-spqr"`, `"name":"shelf"`)
+
+	if _, _, err := runApp(t, app, "ops", "rm", "shelf"); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, _ = runApp(t, app, "ops", "list", "--format", "json")
+	if strings.Contains(stdout, `"shelf"`) {
+		t.Errorf("ops list after delete:\n%s", stdout)
+	}
+	if _, _, err := runApp(t, app, "query", "--op", "shelf"); err == nil || !strings.Contains(err.Error(), `no saved operation named "shelf"`) {
+		t.Errorf("query --op for a deleted operation: %v", err)
+	}
+	if _, _, err := runApp(t, app, "ops", "delete", "shelf"); err == nil {
+		t.Error("expected deleting a missing operation to fail")
+	}
+
+	// --ops-file points both ops and --op at another store.
+	teamFile := filepath.Join(t.TempDir(), "team.json")
+	if _, _, err := runApp(t, app, "ops", "save", "--ops-file", teamFile, "--query", "{ authors { name } }", "authors"); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runApp(t, app, "query", "--ops-file", teamFile, "--op", "authors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "Le Guin")
+}
@@ -0,0 +1,387 @@
+package gqlcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// PipeManifest is a reusable pipe definition, read with pipe --manifest.
+// It is JSON or YAML. Flags given on the command line override its fields.
+// Relative *File paths are resolved against the manifest's directory.
+type PipeManifest struct {
+	Query         string                 `json:"query,omitempty" yaml:"query,omitempty"`
+	QueryFile     string                 `json:"queryFile,omitempty" yaml:"queryFile,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Transform     string                 `json:"transform,omitempty" yaml:"transform,omitempty"`
+	TransformFile string                 `json:"transformFile,omitempty" yaml:"transformFile,omitempty"`
+	Mutation      string                 `json:"mutation,omitempty" yaml:"mutation,omitempty"`
+	MutationFile  string                 `json:"mutationFile,omitempty" yaml:"mutationFile,omitempty"`
+	Concurrency   int                    `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	HaltOnError   bool                   `json:"haltOnError,omitempty" yaml:"haltOnError,omitempty"`
+}
+
+// defaultPipeConcurrency is how many mutations pipe runs at once by default.
+const defaultPipeConcurrency = 4
+
+// GetPipeCommand returns the pipe command, which runs a query, turns its
+// result into variables with a template, and runs a mutation per variables object.
+func (b *CLIBuilder) GetPipeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "pipe",
+		Usage: "Run a query, transform the result, and run a mutation for each produced variables object",
+		Description: "The transform is a Go template over the query response (as in --post-result) whose output " +
+			"is JSON: one variables object, an array of them, or several objects one after another, e.g.\n\n" +
+			"   --transform '{{range .data.books}}{\"id\": {{json .id}}, \"title\": {{json .title}}}{{end}}'\n\n" +
+			"The template function json encodes a value as JSON. Each mutation's outcome is printed as a JSON " +
+			"line; failures are summarized on stderr and make the command exit non-zero.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "url",
+				Aliases: []string{"u"},
+				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
+				Value:   b.config.URL,
+				EnvVars: []string{"GRAPHQL_URL"},
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				Aliases: []string{"d"},
+				Usage:   "Enable debug mode (logs HTTP requests/responses)",
+				Value:   b.config.Debug,
+			},
+			&cli.StringFlag{Name: "manifest", Usage: "JSON or YAML file with the pipe definition (query, transform, mutation, ...)"},
+			&cli.StringFlag{Name: "query", Aliases: []string{"q"}, Usage: "Source query"},
+			&cli.StringFlag{Name: "query-file", Usage: "Read the source query from a file"},
+			&cli.StringFlag{Name: "variables", Aliases: []string{"v"}, Usage: "Variables for the source query as JSON"},
+			&cli.StringFlag{Name: "transform", Aliases: []string{"t"}, Usage: "Go template producing the mutation variables as JSON"},
+			&cli.StringFlag{Name: "transform-file", Usage: "Read the transform template from a file"},
+			&cli.StringFlag{Name: "mutation", Aliases: []string{"m"}, Usage: "Target mutation, run once per variables object"},
+			&cli.StringFlag{Name: "mutation-file", Usage: "Read the target mutation from a file"},
+			&cli.IntFlag{Name: "concurrency", Aliases: []string{"c"}, Usage: "Mutations to run at once", Value: defaultPipeConcurrency},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Print the mutation requests that would be sent, one JSON line each, without sending them"},
+			&cli.BoolFlag{Name: "halt-on-error", Usage: "Start no more mutations after the first failure"},
+			&cli.BoolFlag{
+				Name:  "strict-variables",
+				Usage: "Fail before sending any mutation when produced variables are not declared by it or required ones are missing",
+			},
+			&cli.BoolFlag{
+				Name:  "no-variable-check",
+				Usage: "Skip comparing produced variables with the mutation's declarations",
+			},
+			&cli.BoolFlag{
+				Name:  "accept-endpoint-change",
+				Usage: "Proceed (and record the new identity) when the endpoint's schema changed drastically since last use",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			b.applyReadOnly(c)
+			m, err := pipeManifestFromFlags(c)
+			if err != nil {
+				return err
+			}
+			dryRun := c.Bool("dry-run")
+			if b.config.ReadOnly && !dryRun {
+				return &ReadOnlyError{Source: readOnlySource(b.config), Reason: "pipe runs mutations; use --dry-run to preview them"}
+			}
+
+			b.config.URL = c.String("url")
+			b.config.Debug = c.Bool("debug")
+			httpClient := NewHTTPClient(b.config)
+			b.client = httpClient
+			ctx := context.Background()
+			if err := b.checkEndpointIdentity(ctx, httpClient, !dryRun, c.Bool("accept-endpoint-change")); err != nil {
+				return err
+			}
+
+			noteUsageOperation(c, m.Mutation, "")
+			noteUsageEndpoint(c, b.config.URL)
+			result, err := httpClient.Execute(ctx, ExecutionModeHTTP, QueryOptions{Query: m.Query, Variables: m.Variables})
+			if err != nil {
+				return fmt.Errorf("source query failed: %s", errorSummary(err))
+			}
+			items, err := runPipeTransform(m.Transform, result)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				fmt.Fprintln(os.Stderr, "pipe: the transform produced no variables; nothing to run")
+				return nil
+			}
+			if err := checkPipeVariables(c, m.Mutation, items); err != nil {
+				return err
+			}
+
+			if dryRun {
+				enc := json.NewEncoder(os.Stdout)
+				for _, vars := range items {
+					if err := enc.Encode(GraphQLRequest{Query: m.Mutation, Variables: vars}); err != nil {
+						return fmt.Errorf("failed to encode request: %w", err)
+					}
+				}
+				fmt.Fprintf(os.Stderr, "pipe: dry run, %d mutations not sent\n", len(items))
+				return nil
+			}
+
+			outcomes := runPipeMutations(ctx, httpClient, m.Mutation, items, m.Concurrency, m.HaltOnError)
+			failed := printPipeOutcomes(os.Stdout, os.Stderr, outcomes)
+			if failed > 0 {
+				noteUsageError(c, "graphql")
+				return cli.Exit("", 1)
+			}
+			return nil
+		},
+	}
+}
+
+// pipeManifestFromFlags loads --manifest, applies the flags that were set on
+// top, and reads any *File fields.
+func pipeManifestFromFlags(c *cli.Context) (*PipeManifest, error) {
+	m := &PipeManifest{}
+	base := ""
+	if path := c.String("manifest"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		// JSON is decoded as such so large numbers in variables stay exact;
+		// anything else is read as YAML.
+		if json.Valid(data) {
+			err = decodeJSON(data, m)
+		} else {
+			err = yaml.Unmarshal(data, m)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+		}
+		base = filepath.Dir(path)
+	}
+
+	for _, f := range []struct {
+		text, file         *string
+		textFlag, fileFlag string
+	}{
+		{&m.Query, &m.QueryFile, "query", "query-file"},
+		{&m.Transform, &m.TransformFile, "transform", "transform-file"},
+		{&m.Mutation, &m.MutationFile, "mutation", "mutation-file"},
+	} {
+		if c.IsSet(f.textFlag) {
+			*f.text, *f.file = c.String(f.textFlag), ""
+		}
+		if c.IsSet(f.fileFlag) {
+			*f.text, *f.file = "", c.String(f.fileFlag)
+		} else if *f.file != "" && base != "" && !filepath.IsAbs(*f.file) {
+			*f.file = filepath.Join(base, *f.file)
+		}
+		if *f.file != "" {
+			data, err := os.ReadFile(*f.file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s file: %w", f.textFlag, err)
+			}
+			*f.text = string(data)
+		}
+		if strings.TrimSpace(*f.text) == "" {
+			return nil, fmt.Errorf("%s is required (use --%s, --%s, or the manifest)", f.textFlag, f.textFlag, f.fileFlag)
+		}
+	}
+
+	if c.IsSet("variables") {
+		m.Variables = nil
+		if err := decodeJSON([]byte(c.String("variables")), &m.Variables); err != nil {
+			return nil, fmt.Errorf("invalid variables JSON: %w", err)
+		}
+	}
+	if c.IsSet("concurrency") || m.Concurrency == 0 {
+		m.Concurrency = c.Int("concurrency")
+	}
+	if m.Concurrency < 1 {
+		return nil, fmt.Errorf("--concurrency must be at least 1")
+	}
+	if c.IsSet("halt-on-error") {
+		m.HaltOnError = c.Bool("halt-on-error")
+	}
+	return m, nil
+}
+
+// checkPipeVariables runs the checkVariables comparison on every variables
+// object the transform produced, so a typo in the template is reported once
+// before any mutation is sent rather than as a failure per item.
+func checkPipeVariables(c *cli.Context, mutation string, items []map[string]interface{}) error {
+	if c.Bool("no-variable-check") {
+		return nil
+	}
+	defs, ok := variableDefinitions(mutation, "")
+	if !ok {
+		return nil
+	}
+	var problems []string
+	affected := map[string]int{}
+	for _, vars := range items {
+		for _, p := range variableProblems(defs, vars) {
+			if affected[p] == 0 {
+				problems = append(problems, p)
+			}
+			affected[p]++
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	for i, p := range problems {
+		problems[i] = fmt.Sprintf("%s (%d of %d items)", p, affected[p], len(items))
+	}
+	if c.Bool("strict-variables") {
+		return fmt.Errorf("produced variables don't match the mutation (--strict-variables):\n  %s", strings.Join(problems, "\n  "))
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, "warning: "+p)
+	}
+	return nil
+}
+
+// runPipeTransform executes tmpl over result and decodes its output as a
+// sequence of JSON values, each a variables object or an array of them.
+func runPipeTransform(tmpl string, result map[string]interface{}) ([]map[string]interface{}, error) {
+	t, err := template.New("transform").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transform: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, result); err != nil {
+		return nil, fmt.Errorf("transform failed: %w", err)
+	}
+
+	var items []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			return items, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("transform output is not JSON: %w\nOutput: %s", err, buf.String())
+		}
+		list, isList := v.([]interface{})
+		if !isList {
+			list = []interface{}{v}
+		}
+		for _, item := range list {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("transform output item %d is not a JSON object: %v", len(items)+1, item)
+			}
+			items = append(items, obj)
+		}
+	}
+}
+
+// pipeOutcome is the result of the mutation for one variables object.
+type pipeOutcome struct {
+	result  map[string]interface{}
+	err     error
+	skipped bool // not started because of --halt-on-error
+}
+
+// runPipeMutations runs mutation once per items entry, at most concurrency at
+// a time. With halt, no new mutation starts after one fails; those already
+// running are allowed to finish.
+func runPipeMutations(ctx context.Context, client *HTTPClient, mutation string, items []map[string]interface{}, concurrency int, halt bool) []pipeOutcome {
+	outcomes := make([]pipeOutcome, len(items))
+	sem := make(chan struct{}, concurrency)
+	var halted atomic.Bool
+	var wg sync.WaitGroup
+	for i, vars := range items {
+		sem <- struct{}{}
+		if halted.Load() {
+			<-sem
+			outcomes[i].skipped = true
+			continue
+		}
+		wg.Add(1)
+		go func(i int, vars map[string]interface{}) {
+			defer func() { <-sem; wg.Done() }()
+			result, err := client.ExecuteMutation(ctx, ExecutionModeHTTP, MutationOptions{Mutation: mutation, Variables: vars})
+			outcomes[i] = pipeOutcome{result: result, err: err}
+			if err != nil && halt {
+				halted.Store(true)
+			}
+		}(i, vars)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// printPipeOutcomes writes one JSON line per item to out and a summary with
+// each failure to summary. It returns the number of failed items.
+func printPipeOutcomes(out, summary io.Writer, outcomes []pipeOutcome) int {
+	enc := json.NewEncoder(out)
+	var failures []string
+	skipped := 0
+	for i, o := range outcomes {
+		line := map[string]interface{}{"item": i + 1}
+		switch {
+		case o.skipped:
+			skipped++
+			line["skipped"] = true
+		case o.err != nil:
+			msg := errorSummary(o.err)
+			failures = append(failures, fmt.Sprintf("  item %d: %s", i+1, msg))
+			line["error"] = msg
+			if o.result != nil {
+				line["errors"] = o.result["errors"]
+			}
+		default:
+			line["data"] = o.result["data"]
+		}
+		_ = enc.Encode(line)
+	}
+
+	ok := len(outcomes) - len(failures) - skipped
+	fmt.Fprintf(summary, "pipe: %d items: %d succeeded, %d failed", len(outcomes), ok, len(failures))
+	if skipped > 0 {
+		fmt.Fprintf(summary, ", %d skipped after an error", skipped)
+	}
+	fmt.Fprintln(summary)
+	for _, f := range failures {
+		fmt.Fprintln(summary, f)
+	}
+	return len(failures)
+}
+
+// errorSummary returns the GraphQL error messages of err joined on one line,
+// or err's own message for other errors.
+func errorSummary(err error) string {
+	var gqlErr *GraphQLResponseError
+	if !errors.As(err, &gqlErr) {
+		return err.Error()
+	}
+	errs, _ := gqlErr.Response["errors"].([]interface{})
+	var msgs []string
+	for _, e := range errs {
+		if em, ok := e.(map[string]interface{}); ok {
+			if msg, _ := em["message"].(string); msg != "" {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,229 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestPipeMutationsShareClient runs many mutations through one HTTPClient at
+// once. Every request is redirected and every mutation fails validation, so
+// the goroutines warn about the redirect and look up schema hints together.
+// Run with -race (make test-race) to check they don't race.
+func TestPipeMutationsShareClient(t *testing.T) {
+	backend := newTestServer(t)
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", http.RedirectHandler("/graphql/", http.StatusPermanentRedirect))
+	mux.HandleFunc("/graphql/", func(w http.ResponseWriter, r *http.Request) {
+		backend.Config.Handler.ServeHTTP(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	items := make([]map[string]interface{}, 16)
+	for i := range items {
+		items[i] = map[string]interface{}{"title": fmt.Sprintf("Book %d", i+1)}
+	}
+	const mutation = `mutation($title: String!) { addBook(input: {title: $title, authorName: "Carl Sagan"}) { id titel } }`
+	client := NewHTTPClient(&Config{URL: srv.URL + "/graphql", FollowRedirects: true})
+
+	var outcomes []pipeOutcome
+	_, stderr := captureOutput(t, func() {
+		outcomes = runPipeMutations(context.Background(), client, mutation, items, 8, false)
+	})
+	for i, o := range outcomes {
+		if o.result == nil {
+			t.Fatalf("item %d: got %v, want the validation error", i+1, o.err)
+		}
+		ext, _ := o.result["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})
+		if hint, _ := ext["schemaHint"].(string); !strings.Contains(hint, "type Book {") {
+			t.Errorf("item %d: hint %q", i+1, hint)
+		}
+	}
+	if n := strings.Count(stderr, "warning: endpoint redirected"); n != 1 {
+		t.Errorf("warned about the redirect %d times, want once:\n%s", n, stderr)
+	}
+}
+
+// TestHTTPClientConcurrentUse calls the parts of HTTPClient that keep state
+// from several goroutines released at once. Locks inside resty can hide
+// races between whole operations from the race detector; this exercises the
+// state directly.
+func TestHTTPClientConcurrentUse(t *testing.T) {
+	client := NewHTTPClient(&Config{URL: "http://localhost/graphql"})
+	start := make(chan struct{})
+	describers := make([]*Describer, 16)
+	var wg sync.WaitGroup
+	_, stderr := captureOutput(t, func() {
+		for i := range describers {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				client.warnRedirect("http://localhost/graphql/")
+				describers[i] = client.getDescriber()
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+	})
+	for i, d := range describers {
+		if d != describers[0] {
+			t.Errorf("goroutine %d got its own Describer", i)
+		}
+	}
+	if n := strings.Count(stderr, "warning: endpoint redirected"); n != 1 {
+		t.Errorf("warned %d times, want once", n)
+	}
+}
+
+// TestPipeMutationsRefreshToken has every goroutine's first attempt rejected
+// as UNAUTHENTICATED, so they refresh the shared token while others send it.
+func TestPipeMutationsRefreshToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Write([]byte(`{"data":null,"errors":[{"message":"expired","extensions":{"code":"UNAUTHENTICATED"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(&Config{URL: srv.URL, Token: "stale", HintTimeout: -1,
+		OnUnauthorized: func(context.Context) (string, error) { return "fresh", nil }})
+	items := make([]map[string]interface{}, 16)
+	for i := range items {
+		items[i] = map[string]interface{}{"n": i}
+	}
+	for i, o := range runPipeMutations(context.Background(), client, `mutation { ok }`, items, 8, false) {
+		if o.err != nil {
+			t.Errorf("item %d: %v", i+1, o.err)
+		}
+	}
+}
+
+func TestPipe(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	const (
+		query     = `{ books(genre: SCIENCE) { title author { name } } }`
+		transform = `{{range .data.books}}{"input": {"title": {{json .title}}, "authorName": {{json .author.name}}}}{{end}}`
+		mutation  = `mutation($input: AddBookInput!) { addBook(input: $input) { id title } }`
+	)
+
+	stdout, stderr, err := runApp(t, app, "pipe", "-q", query, "-t", transform, "-m", mutation, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"title":"Cosmos"`, `"authorName":"Carl Sagan"`)
+	assertContains(t, stderr, "dry run")
+	sent := ts.Requests()
+
+	stdout, stderr, err = runApp(t, app, "pipe", "-q", query, "-t", transform, "-m", mutation)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, stderr)
+	}
+	if ts.Requests() == sent {
+		t.Error("no mutation was sent")
+	}
+	assertContains(t, stdout, `"addBook"`)
+	assertContains(t, stderr, "succeeded, 0 failed")
+
+	// The same pipeline from a manifest, with the mutation in a file next to it.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "add.graphql"), []byte(mutation), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := fmt.Sprintf(`{"query": %q, "transform": %q, "mutationFile": "add.graphql", "concurrency": 2}`, query, transform)
+	if err := os.WriteFile(filepath.Join(dir, "pipe.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runApp(t, app, "pipe", "--manifest", filepath.Join(dir, "pipe.json"), "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"title":"Cosmos"`, "addBook(input: $input)")
+
+	// And as YAML, with block scalars for the documents.
+	yamlManifest := "query: |\n  " + query + "\n" +
+		"transform: |\n  " + transform + "\n" +
+		"mutationFile: add.graphql\n" +
+		"haltOnError: true\n"
+	if err := os.WriteFile(filepath.Join(dir, "pipe.yaml"), []byte(yamlManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runApp(t, app, "pipe", "--manifest", filepath.Join(dir, "pipe.yaml"), "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, `"title":"Cosmos"`, "addBook(input: $input)")
+}
+
+func TestPipeChecksVariables(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	const (
+		query = `{ books { title author { name } } }`
+		// $Title is a typo for $title, and no item has $authorName.
+		transform = `{{range .data.books}}{"Title": {{json .title}}}{{end}}`
+		mutation  = `mutation($title: String!, $authorName: String!) { addBook(input: {title: $title, authorName: $authorName}) { id } }`
+	)
+
+	_, stderr, err := runApp(t, app, "pipe", "-q", query, "-t", transform, "-m", mutation, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stderr,
+		`warning: variable "Title" is not declared by the operation and will be ignored; did you mean $title (String!)? (4 of 4 items)`,
+		"warning: missing required variable $authorName: String! (4 of 4 items)")
+	if n := strings.Count(stderr, "warning: "); n != 3 {
+		t.Errorf("got %d warnings, want one per problem:\n%s", n, stderr)
+	}
+
+	before := bookCount(t, ts)
+	_, _, err = runApp(t, app, "pipe", "--strict-variables", "-q", query, "-t", transform, "-m", mutation)
+	if err == nil || !strings.Contains(err.Error(), "--strict-variables") {
+		t.Fatalf("expected a strict variables error, got %v", err)
+	}
+	if got := bookCount(t, ts); got != before {
+		t.Errorf("strict check sent mutations: %d books, want %d", got, before)
+	}
+
+	_, stderr, err = runApp(t, app, "pipe", "--no-variable-check", "-q", query, "-t", transform, "-m", mutation, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stderr, "warning:") {
+		t.Errorf("unexpected warning with --no-variable-check:\n%s", stderr)
+	}
+}
+
+func TestPipeFailuresAreSummarized(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	const (
+		query     = `{ books { title } }`
+		transform = `{{range .data.books}}{"title": {{json .title}}}{{end}}`
+		// authorName is missing, so every item fails.
+		mutation = `mutation($title: String!) { addBook(input: {title: $title}) { id } }`
+	)
+
+	stdout, stderr, err := runApp(t, app, "pipe", "-q", query, "-t", transform, "-m", mutation, "-c", "1")
+	if err == nil {
+		t.Fatal("expected a non-zero exit")
+	}
+	assertContains(t, stderr, "pipe: 4 items: 0 succeeded, 4 failed", "item 4:")
+	if n := strings.Count(stdout, `"error"`); n != 4 {
+		t.Errorf("got %d error lines, want 4:\n%s", n, stdout)
+	}
+
+	_, stderr, _ = runApp(t, app, "pipe", "-q", query, "-t", transform, "-m", mutation, "-c", "1", "--halt-on-error")
+	assertContains(t, stderr, "0 succeeded, 1 failed, 3 skipped after an error")
+}
@@ -0,0 +1,68 @@
+package gqlcli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// ReadOnlyError is returned when read-only mode refuses an operation.
+type ReadOnlyError struct {
+	// Source is what enabled read-only mode, e.g. "--read-only" or a profile name.
+	Source string
+	// Reason says what was refused.
+	Reason string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("read-only mode (enforced by %s): %s", e.Source, e.Reason)
+}
+
+// checkReadOnly parses query and refuses it if it could run a mutation: the
+// operation selected by operationName is a mutation, or no operation is
+// selected and any operation in a multi-operation document is one. Documents
+// that don't parse are refused too, since they can't be verified.
+func checkReadOnly(source, query, operationName string) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return &ReadOnlyError{Source: source, Reason: fmt.Sprintf("cannot verify the operation is not a mutation: %v", err)}
+	}
+	for _, op := range doc.Operations {
+		selected := operationName == "" || op.Name == operationName
+		if op.Operation == ast.Mutation && selected {
+			name := op.Name
+			if name == "" {
+				name = "(anonymous)"
+			}
+			return &ReadOnlyError{Source: source, Reason: fmt.Sprintf("refusing to send mutation %s", name)}
+		}
+	}
+	return nil
+}
+
+// readOnlyFlag is the global --read-only flag added by RegisterCommands.
+var readOnlyFlag = &cli.BoolFlag{
+	Name:    "read-only",
+	Usage:   "Refuse to send mutations (for shared read-only credentials)",
+	EnvVars: []string{"GQLCLI_READ_ONLY"},
+}
+
+// applyReadOnly turns on read-only mode in b.config when --read-only is set.
+// Read-only mode enabled in the Config is never turned off by the flag.
+func (b *CLIBuilder) applyReadOnly(c *cli.Context) {
+	if b.config.ReadOnly || !c.Bool("read-only") {
+		return
+	}
+	b.config.ReadOnly = true
+	b.config.ReadOnlySource = "--read-only"
+}
+
+// readOnlySource returns the Source to report for cfg, defaulting to "config".
+func readOnlySource(cfg *Config) string {
+	if cfg.ReadOnlySource != "" {
+		return cfg.ReadOnlySource
+	}
+	return "config"
+}
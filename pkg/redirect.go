@@ -0,0 +1,146 @@
+package gqlcli
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// maxRedirects bounds the redirect chain followed for one operation.
+const maxRedirects = 10
+
+// noRedirectPolicy stops resty from following redirects itself: the default
+// client turns a redirected POST into a GET, which lands on the playground
+// page instead of the API. executeOperation handles redirects explicitly.
+var noRedirectPolicy = resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+})
+
+// redirectTarget returns the absolute Location of a redirect response, or ""
+// if resp is not a redirect.
+func redirectTarget(resp *resty.Response, from string) (string, error) {
+	switch resp.StatusCode() {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return "", nil
+	}
+	loc := resp.Header().Get("Location")
+	if loc == "" {
+		return "", fmt.Errorf("endpoint responded %s without a Location header", resp.Status())
+	}
+	base, err := url.Parse(from)
+	if err != nil {
+		return "", err
+	}
+	target, err := base.Parse(loc)
+	if err != nil {
+		return "", fmt.Errorf("endpoint redirected to an invalid location %q: %w", loc, err)
+	}
+	return target.String(), nil
+}
+
+// checkRedirectOrigin refuses a redirect that leaves the origin of from, so
+// the operation and its Authorization header only go where the user pointed
+// them. An http to https upgrade on the same host is allowed; a downgrade to
+// http never is.
+func checkRedirectOrigin(from, to string) error {
+	f, err := url.Parse(from)
+	if err != nil {
+		return err
+	}
+	t, err := url.Parse(to)
+	if err != nil {
+		return err
+	}
+	if f.Scheme == "https" && t.Scheme != "https" {
+		return fmt.Errorf("endpoint redirected from https to %s; refusing to re-send the operation without TLS — update your --url", to)
+	}
+	sameOrigin := f.Scheme == t.Scheme && f.Port() == t.Port()
+	upgrade := f.Scheme == "http" && t.Scheme == "https" && f.Port() == "" && t.Port() == ""
+	if !strings.EqualFold(f.Hostname(), t.Hostname()) || !sameOrigin && !upgrade {
+		return fmt.Errorf("endpoint redirected to another origin (%s); refusing to re-send the operation and its credentials there — update your --url", to)
+	}
+	return nil
+}
+
+// warnRedirect prints a one-time warning that queries are being re-sent to target.
+func (c *HTTPClient) warnRedirect(target string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.warnedRedirect == target {
+		return
+	}
+	c.warnedRedirect = target
+	fmt.Fprintf(os.Stderr, "warning: endpoint redirected to %s — update your --url\n", target)
+}
+
+// isMutationOperation reports whether the operation selected by operationName
+// (or the first one) in query is a mutation. Unparseable documents count as
+// mutations so they are never re-sent without consent.
+func isMutationOperation(query, operationName string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return true
+	}
+	op := doc.Operations[0]
+	if operationName != "" {
+		if named := doc.Operations.ForName(operationName); named != nil {
+			op = named
+		}
+	}
+	return op.Operation == ast.Mutation
+}
+
+// playgroundMarkers appear in the HTML of GraphiQL, GraphQL Playground, Apollo
+// Sandbox, and Altair pages.
+var playgroundMarkers = []string{"graphiql", "graphql-playground", "graphql playground", "embeddable-sandbox", "apollo sandbox", "altair"}
+
+// htmlResponseError explains a response that is an HTML page rather than JSON,
+// or returns nil if body doesn't look like HTML.
+func htmlResponseError(resp *resty.Response, endpoint string) error {
+	body := bytes.TrimSpace(resp.Body())
+	if !strings.Contains(resp.Header().Get("Content-Type"), "text/html") && !bytes.HasPrefix(body, []byte("<")) {
+		return nil
+	}
+	lower := strings.ToLower(string(body))
+	for _, marker := range playgroundMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%s looks like the GraphQL playground page, not the API; the API endpoint is probably at %s",
+				endpoint, strings.Join(apiEndpointGuesses(endpoint), " or "))
+		}
+	}
+	return fmt.Errorf("endpoint responded %s with an HTML page instead of JSON — is %s the GraphQL API endpoint?", resp.Status(), endpoint)
+}
+
+// apiEndpointGuesses returns likely API URLs next to a playground URL:
+// sibling /query and /graphql paths, replacing a playground-looking last segment.
+func apiEndpointGuesses(endpoint string) []string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return []string{endpoint}
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		switch strings.ToLower(path[i+1:]) {
+		case "playground", "graphiql", "sandbox", "altair", "explorer", "query", "graphql":
+			path = path[:i]
+		}
+	}
+	var guesses []string
+	for _, suffix := range []string{"/query", "/graphql"} {
+		g := *u
+		g.Path, g.RawQuery, g.Fragment = path+suffix, "", ""
+		if s := g.String(); s != endpoint {
+			guesses = append(guesses, s)
+		}
+	}
+	return guesses
+}
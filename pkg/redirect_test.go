@@ -0,0 +1,130 @@
+package gqlcli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckRedirectOrigin(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  string
+	}{
+		{"https://api.example.com/graphql", "https://api.example.com/graphql/", ""},
+		{"http://api.example.com/graphql", "https://api.example.com/graphql", ""},
+		{"http://localhost:8080/graphql", "http://LOCALHOST:8080/query", ""},
+		{"https://api.example.com/graphql", "http://api.example.com/graphql", "without TLS"},
+		{"https://api.example.com/graphql", "https://evil.example.net/graphql", "another origin"},
+		{"http://localhost:8080/graphql", "http://localhost:9090/graphql", "another origin"},
+		{"http://localhost:8080/graphql", "https://localhost:8443/graphql", "another origin"},
+	}
+	for _, tt := range tests {
+		err := checkRedirectOrigin(tt.from, tt.to)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s -> %s: unexpected error %v", tt.from, tt.to, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s -> %s: got %v, want an error containing %q", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
+
+// authRecorder is a GraphQL endpoint that records the Authorization header
+// of each request it receives.
+type authRecorder struct {
+	mu   sync.Mutex
+	auth []string
+}
+
+func (a *authRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.auth = append(a.auth, r.Header.Get("Authorization"))
+	a.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"data":{"ok":true}}`))
+}
+
+func TestRedirectSameOriginKeepsToken(t *testing.T) {
+	api := &authRecorder{}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql/", api)
+	mux.Handle("/graphql", http.RedirectHandler("/graphql/", http.StatusPermanentRedirect))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := NewHTTPClient(&Config{URL: srv.URL + "/graphql", Token: "s3cret", HintTimeout: -1})
+	_, stderr := captureOutput(t, func() {
+		if _, err := c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ ok }"}); err != nil {
+			t.Error(err)
+		}
+	})
+	if len(api.auth) != 1 || api.auth[0] != "Bearer s3cret" {
+		t.Errorf("API saw Authorization %q, want the token once", api.auth)
+	}
+	assertContains(t, stderr, "warning: endpoint redirected to "+srv.URL+"/graphql/")
+}
+
+func TestRedirectCrossOriginRefused(t *testing.T) {
+	other := &authRecorder{}
+	otherSrv := httptest.NewServer(other)
+	defer otherSrv.Close()
+	srv := httptest.NewServer(http.RedirectHandler(otherSrv.URL+"/graphql", http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	c := NewHTTPClient(&Config{URL: srv.URL, Token: "s3cret", HintTimeout: -1})
+	_, err := c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: "{ ok }"})
+	if err == nil || !strings.Contains(err.Error(), "another origin") {
+		t.Errorf("got %v, want the cross-origin redirect refused", err)
+	}
+	if len(other.auth) != 0 {
+		t.Errorf("the other origin received %d requests (Authorization %q)", len(other.auth), other.auth)
+	}
+}
+
+func TestHTTPClientHasNoDefaultAuthorization(t *testing.T) {
+	c := NewHTTPClient(&Config{URL: "http://localhost/graphql", Token: "s3cret"})
+	if got := c.client.Header.Get("Authorization"); got != "" {
+		t.Errorf("client default Authorization = %q; it must be set per request", got)
+	}
+}
+
+// With persisted queries the first request carries only a hash, so whether
+// the operation is a mutation must come from the query text, not the body.
+func TestRedirectPersistedQueries(t *testing.T) {
+	api := &authRecorder{}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql/", api)
+	mux.Handle("/graphql", http.RedirectHandler("/graphql/", http.StatusTemporaryRedirect))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		query   string
+		follow  bool
+		wantErr string
+	}{
+		{"{ ok }", false, ""},
+		{"mutation { ok }", false, "--follow-redirects"},
+		{"mutation { ok }", true, ""},
+	}
+	for _, tt := range tests {
+		c := NewHTTPClient(&Config{URL: srv.URL + "/graphql", PersistedQueries: true, FollowRedirects: tt.follow, HintTimeout: -1})
+		var err error
+		captureOutput(t, func() {
+			_, err = c.Execute(context.Background(), ExecutionModeHTTP, QueryOptions{Query: tt.query})
+		})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%q (follow %v): unexpected error %v", tt.query, tt.follow, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%q (follow %v): got %v, want an error containing %q", tt.query, tt.follow, err, tt.wantErr)
+		}
+	}
+	if len(api.auth) != 2 {
+		t.Errorf("API received %d requests, want the query and the followed mutation", len(api.auth))
+	}
+}
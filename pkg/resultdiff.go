@@ -0,0 +1,108 @@
+package gqlcli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// resultDiffFlags are the query flags that print how a result differs from
+// another one instead of printing the result.
+func resultDiffFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "diff-prev",
+			Usage: "Print the differences from the previous --diff-prev run of this query and variables against this endpoint",
+		},
+		&cli.StringFlag{
+			Name:  "compare-url",
+			Usage: "Also run the query against this endpoint and print the differences (--url is the old side)",
+		},
+		&cli.StringFlag{
+			Name:  "diff-format",
+			Usage: "Format for --diff-prev and --compare-url: text (default), markdown, json-patch",
+			Value: "text",
+		},
+		&cli.IntFlag{
+			Name:  "diff-context",
+			Usage: "Unchanged siblings shown around each change (-1 shows all)",
+			Value: 2,
+		},
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disable colored diff output",
+		},
+	}
+}
+
+// diffingResults reports whether c asks for a diff instead of the result.
+func diffingResults(c *cli.Context) bool {
+	return c.Bool("diff-prev") || c.String("compare-url") != ""
+}
+
+// checkResultDiffFlags validates the diff flags before the query is sent.
+func checkResultDiffFlags(c *cli.Context) error {
+	if c.Bool("diff-prev") && c.String("compare-url") != "" {
+		return fmt.Errorf("use either --diff-prev or --compare-url, not both")
+	}
+	if !containsString(DiffFormats, c.String("diff-format")) {
+		return fmt.Errorf("unknown diff format %q (valid: %s)", c.String("diff-format"), strings.Join(DiffFormats, ", "))
+	}
+	if url := c.String("compare-url"); url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("--compare-url must start with http:// or https://")
+	}
+	return nil
+}
+
+// printResultDiff writes how result differs from the previous result of the
+// same operation (--diff-prev) or from the result of --compare-url. Responses
+// carrying GraphQL errors are compared like any other.
+func (b *CLIBuilder) printResultDiff(c *cli.Context, opts QueryOptions, result map[string]interface{}) error {
+	var old, new map[string]interface{}
+	if url := c.String("compare-url"); url != "" {
+		cfg := *b.config
+		cfg.URL = url
+		other, err := NewHTTPClient(&cfg).Execute(context.Background(), ExecutionModeHTTP, opts)
+		var gqlErr *GraphQLResponseError
+		if err != nil && !errors.As(err, &gqlErr) {
+			return fmt.Errorf("--compare-url: %w", err)
+		}
+		old, new = result, other
+	} else {
+		cache := NewSchemaCache(b.config.CacheDir)
+		key := resultKey(opts)
+		prev, loadErr := cache.LoadResult(b.config.URL, key)
+		if err := cache.SaveResult(b.config.URL, key, result); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to save result for --diff-prev: %v\n", err)
+		}
+		if loadErr != nil {
+			fmt.Fprintln(os.Stderr, "no previous result for this query; saved this one for the next --diff-prev")
+			return b.outputResult(c, result)
+		}
+		old, new = prev, result
+	}
+
+	out, err := RenderDiff(old, new, DiffOptions{
+		Format:  c.String("diff-format"),
+		Color:   !c.Bool("no-color") && !c.Bool("accessible") && isTerminal(os.Stdout),
+		Context: c.Int("diff-context"),
+	})
+	if err != nil {
+		return err
+	}
+	return b.writeOutput(c, strings.TrimSuffix(out, "\n"))
+}
+
+// resultKey identifies an operation and its variables for SaveResult.
+func resultKey(opts QueryOptions) string {
+	vars, _ := json.Marshal(opts.Variables)
+	sum := sha256.Sum256([]byte(opts.OperationName + "\x00" + opts.Query + "\x00" + string(vars)))
+	return hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,71 @@
+package gqlcli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures retryWithBackoff.
+type RetryPolicy struct {
+	Attempts  int           // total attempts including the first (default: 1)
+	BaseDelay time.Duration // delay before the second attempt, doubled each retry (default: 500ms)
+	MaxDelay  time.Duration // upper bound for a single delay (default: 10s)
+}
+
+// httpStatusError is an unsuccessful HTTP response without a usable body.
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *httpStatusError) Error() string { return "server responded " + e.Status }
+
+// isTransient reports whether err is worth retrying: a network failure, a 5xx
+// response, or 429 Too Many Requests. Everything else, including GraphQL
+// errors returned by the server and other 4xx responses, is deterministic.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// retryWithBackoff calls fn until it succeeds, returns a non-transient error,
+// or the policy's attempts are exhausted. The last error is returned.
+func retryWithBackoff(ctx context.Context, p RetryPolicy, fn func() error) error {
+	if p.Attempts < 1 {
+		p.Attempts = 1
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = 10 * time.Second
+	}
+
+	delay := p.BaseDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= p.Attempts || !isTransient(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package gqlcli
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// sampleStrategies are the accepted --sample strategies.
+var sampleStrategies = []string{"first", "last", "random", "even"}
+
+// sampleFlags are the --sample flags shared by the HTTP and inline operation commands.
+func sampleFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name: "sample",
+			Usage: "Reduce every array in the result to N items before formatting, as N[:STRATEGY] " +
+				"with STRATEGY first (default), last, random, or even (evenly spaced)",
+		},
+		&cli.Int64Flag{
+			Name:  "sample-seed",
+			Usage: "Seed for --sample N:random, to get the same items on every run (default: time-based)",
+		},
+	}
+}
+
+type sampleSpec struct {
+	n        int
+	strategy string
+}
+
+func parseSampleSpec(s string) (sampleSpec, error) {
+	count, strategy, _ := strings.Cut(s, ":")
+	if strategy == "" {
+		strategy = "first"
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return sampleSpec{}, fmt.Errorf("--sample %q: expected a positive item count, e.g. 10 or 10:random", s)
+	}
+	if !containsString(sampleStrategies, strategy) {
+		return sampleSpec{}, fmt.Errorf("--sample %q: unknown strategy %q (valid: %s)", s, strategy, strings.Join(sampleStrategies, ", "))
+	}
+	return sampleSpec{n: n, strategy: strategy}, nil
+}
+
+// sampleNote records how much of the arrays at one path was kept.
+type sampleNote struct {
+	Path         string
+	Shown, Total int
+	sampled      bool // some array at Path was longer than N
+}
+
+// applySample reduces the arrays in result's data per --sample and records
+// what was dropped under extensions.sample. It returns the notes for
+// formats that don't show extensions (see withSampleNotes); none when
+// --sample is unset or nothing was larger than N.
+func applySample(c *cli.Context, result map[string]interface{}) ([]sampleNote, error) {
+	if c.String("sample") == "" {
+		return nil, nil
+	}
+	spec, err := parseSampleSpec(c.String("sample"))
+	if err != nil {
+		return nil, err
+	}
+	seed := c.Int64("sample-seed")
+	if !c.IsSet("sample-seed") {
+		seed = time.Now().UnixNano()
+	}
+	s := &sampler{spec: spec, rng: rand.New(rand.NewSource(seed)), notes: map[string]*sampleNote{}}
+
+	data, ok := result["data"]
+	if !ok {
+		return nil, nil
+	}
+	result["data"] = s.walk("", data)
+
+	var notes []sampleNote
+	var arrays []interface{}
+	for _, path := range s.order {
+		if n := s.notes[path]; n.sampled {
+			notes = append(notes, *n)
+			arrays = append(arrays, map[string]interface{}{"path": path, "shown": n.Shown, "total": n.Total})
+		}
+	}
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	ext, _ := result["extensions"].(map[string]interface{})
+	if ext == nil {
+		ext = map[string]interface{}{}
+		result["extensions"] = ext
+	}
+	ext["sample"] = map[string]interface{}{"strategy": spec.strategy, "arrays": arrays}
+	return notes, nil
+}
+
+type sampler struct {
+	spec  sampleSpec
+	rng   *rand.Rand
+	notes map[string]*sampleNote
+	order []string
+}
+
+// walk samples arrays depth-first. Arrays nested in array elements share a
+// path ("books[].tags"), and their counts are summed into one note.
+func (s *sampler) walk(path string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		// Sorted so --sample-seed picks the same items on every run.
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			val[k] = s.walk(p, val[k])
+		}
+		return val
+	case []interface{}:
+		note := s.notes[path]
+		if note == nil {
+			note = &sampleNote{Path: path}
+			s.notes[path] = note
+			s.order = append(s.order, path)
+		}
+		kept := val
+		if len(val) > s.spec.n {
+			kept = s.pick(val)
+			note.sampled = true
+		}
+		note.Shown += len(kept)
+		note.Total += len(val)
+		for i, child := range kept {
+			kept[i] = s.walk(path+"[]", child)
+		}
+		return kept
+	default:
+		return v
+	}
+}
+
+func (s *sampler) pick(items []interface{}) []interface{} {
+	n, total := s.spec.n, len(items)
+	out := make([]interface{}, 0, n)
+	switch s.spec.strategy {
+	case "last":
+		return append(out, items[total-n:]...)
+	case "random":
+		idx := s.rng.Perm(total)[:n]
+		sort.Ints(idx)
+		for _, i := range idx {
+			out = append(out, items[i])
+		}
+		return out
+	case "even":
+		if n == 1 {
+			return append(out, items[0])
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, items[i*(total-1)/(n-1)])
+		}
+		return out
+	default:
+		return append(out, items[:n]...)
+	}
+}
+
+// withSampleNotes appends "(showing N of M items)" lines to output from
+// formats that don't render extensions. CSV stays machine-readable, so its
+// notes go to stderr instead.
+func withSampleNotes(output, format string, notes []sampleNote) string {
+	if len(notes) == 0 {
+		return output
+	}
+	var lines []string
+	for _, n := range notes {
+		lines = append(lines, fmt.Sprintf("(showing %s of %s items at %s)", formatCount(n.Shown), formatCount(n.Total), n.Path))
+	}
+	switch format {
+	case "table", "llm", "toon":
+		return strings.TrimRight(output, "\n") + "\n\n" + strings.Join(lines, "\n")
+	case "csv":
+		for _, l := range lines {
+			fmt.Fprintln(os.Stderr, l)
+		}
+	}
+	return output
+}
+
+// formatCount writes n with thousands separators, e.g. 52,318.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,198 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseSampleSpec(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    sampleSpec
+		wantErr string
+	}{
+		{"10", sampleSpec{n: 10, strategy: "first"}, ""},
+		{"3:last", sampleSpec{n: 3, strategy: "last"}, ""},
+		{"5:random", sampleSpec{n: 5, strategy: "random"}, ""},
+		{"2:even", sampleSpec{n: 2, strategy: "even"}, ""},
+		{"1:", sampleSpec{n: 1, strategy: "first"}, ""},
+		{"0", sampleSpec{}, "expected a positive item count"},
+		{"-2", sampleSpec{}, "expected a positive item count"},
+		{"ten", sampleSpec{}, "expected a positive item count"},
+		{"3:middle", sampleSpec{}, `unknown strategy "middle" (valid: first, last, random, even)`},
+	}
+	for _, tt := range tests {
+		got, err := parseSampleSpec(tt.in)
+		switch {
+		case tt.wantErr == "" && (err != nil || got != tt.want):
+			t.Errorf("%q: got %+v, %v; want %+v", tt.in, got, err, tt.want)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%q: got %v, want an error containing %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSamplerPick(t *testing.T) {
+	items := func(n int) []interface{} {
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = i + 1
+		}
+		return out
+	}
+	tests := []struct {
+		strategy string
+		n, total int
+		want     []interface{}
+	}{
+		{"first", 3, 10, []interface{}{1, 2, 3}},
+		{"last", 3, 10, []interface{}{8, 9, 10}},
+		{"even", 3, 10, []interface{}{1, 5, 10}},
+		{"even", 4, 7, []interface{}{1, 3, 5, 7}},
+		{"even", 1, 10, []interface{}{1}},
+		{"even", 2, 3, []interface{}{1, 3}},
+	}
+	for _, tt := range tests {
+		s := &sampler{spec: sampleSpec{n: tt.n, strategy: tt.strategy}}
+		if got := s.pick(items(tt.total)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %d of %d: got %v, want %v", tt.strategy, tt.n, tt.total, got, tt.want)
+		}
+	}
+
+	// random keeps n distinct items in their original order, the same ones
+	// for the same seed.
+	pick := func(seed int64) []interface{} {
+		s := &sampler{spec: sampleSpec{n: 4, strategy: "random"}, rng: rand.New(rand.NewSource(seed))}
+		return s.pick(items(20))
+	}
+	got := pick(7)
+	ints := make([]int, len(got))
+	for i, v := range got {
+		ints[i] = v.(int)
+	}
+	if len(ints) != 4 || !sort.IntsAreSorted(ints) || ints[0] == ints[1] || ints[1] == ints[2] || ints[2] == ints[3] {
+		t.Errorf("random: got %v, want 4 distinct items in order", got)
+	}
+	if again := pick(7); !reflect.DeepEqual(again, got) {
+		t.Errorf("random with the same seed: got %v then %v", got, again)
+	}
+}
+
+func TestSampleInline(t *testing.T) {
+	const query = `{ authors { name books { title tags } } }`
+
+	t.Run("extensions", func(t *testing.T) {
+		app, _ := inlineApp(t)
+		stdout, _, err := runApp(t, app, "query", "--format", "json", "--sample", "1", query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result struct {
+			Data struct {
+				Authors []struct {
+					Name  string
+					Books []struct{ Tags []string }
+				}
+			}
+			Extensions map[string]interface{}
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("%v\n%s", err, stdout)
+		}
+		if a := result.Data.Authors; len(a) != 1 || a[0].Name != "Ursula K. Le Guin" || len(a[0].Books) != 1 || len(a[0].Books[0].Tags) != 1 {
+			t.Errorf("got %+v, want one author with one book with one tag", a)
+		}
+		// Nested arrays are counted under one path, inside the kept items only.
+		want := map[string]interface{}{
+			"strategy": "first",
+			"arrays": []interface{}{
+				map[string]interface{}{"path": "authors", "shown": 1.0, "total": 3.0},
+				map[string]interface{}{"path": "authors[].books", "shown": 1.0, "total": 2.0},
+				map[string]interface{}{"path": "authors[].books[].tags", "shown": 1.0, "total": 2.0},
+			},
+		}
+		if !reflect.DeepEqual(result.Extensions["sample"], want) {
+			t.Errorf("extensions.sample = %v\nwant %v", result.Extensions["sample"], want)
+		}
+	})
+
+	t.Run("strategies", func(t *testing.T) {
+		app, _ := inlineApp(t)
+		for strategy, want := range map[string]string{
+			"first": `"books":[{"title":"The Dispossessed"}]`,
+			"last":  `"books":[{"title":"SPQR"}]`,
+			"even":  `"books":[{"title":"The Dispossessed"},{"title":"SPQR"}]`,
+		} {
+			n := "1"
+			if strategy == "even" {
+				n = "2"
+			}
+			stdout, _, err := runApp(t, app, "query", "--format", "json", "--sample", n+":"+strategy, "{ books { title } }")
+			if err != nil {
+				t.Fatalf("%s: %v", strategy, err)
+			}
+			assertContains(t, stdout, want, `"strategy":"`+strategy+`"`)
+		}
+
+		first, _, _ := runApp(t, app, "query", "--format", "json", "--sample", "2:random", "--sample-seed", "3", "{ books { id } }")
+		second, _, _ := runApp(t, app, "query", "--format", "json", "--sample", "2:random", "--sample-seed", "3", "{ books { id } }")
+		if first != second || strings.Count(first, `"id"`) != 2 {
+			t.Errorf("--sample-seed should repeat the same 2 books:\n%s\n%s", first, second)
+		}
+	})
+
+	t.Run("notes", func(t *testing.T) {
+		app, _ := inlineApp(t)
+		const note = "(showing 1 of 3 items at authors)"
+		for _, format := range []string{"table", "llm", "toon"} {
+			stdout, _, err := runApp(t, app, "query", "--format", format, "--sample", "1", query)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			assertContains(t, stdout, note, "(showing 1 of 2 items at authors[].books)")
+		}
+		// CSV output stays parseable: its notes go to stderr.
+		stdout, stderr, err := runApp(t, app, "query", "--format", "csv", "--sample", "1", query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(stdout, "showing") {
+			t.Errorf("csv output has notes:\n%s", stdout)
+		}
+		assertContains(t, stderr, note)
+	})
+
+	t.Run("nothing sampled", func(t *testing.T) {
+		app, _ := inlineApp(t)
+		stdout, _, err := runApp(t, app, "query", "--format", "table", "--sample", "10", query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(stdout, "showing") {
+			t.Errorf("unexpected notes when every array fits:\n%s", stdout)
+		}
+		stdout, _, _ = runApp(t, app, "query", "--format", "json", "--sample", "10", query)
+		if strings.Contains(stdout, `"sample"`) {
+			t.Errorf("unexpected extensions.sample when every array fits:\n%s", stdout)
+		}
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		app, _ := inlineApp(t)
+		if _, _, err := runApp(t, app, "query", "--sample", "2:middle", query); err == nil || !strings.Contains(err.Error(), "unknown strategy") {
+			t.Errorf("got %v, want an unknown strategy error", err)
+		}
+	})
+}
+
+func TestFormatCount(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", 52318: "52,318", 1234567: "1,234,567"} {
+		if got := formatCount(n); got != want {
+			t.Errorf("formatCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
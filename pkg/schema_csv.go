@@ -0,0 +1,166 @@
+package gqlcli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaCSV holds the rows of the csv schema export, without headers. Rows are
+// sorted by type, then field, then argument, so exports of the same schema
+// are byte-identical.
+type schemaCSV struct {
+	types  [][]string
+	fields [][]string
+	args   [][]string
+}
+
+var (
+	typesCSVHeader  = []string{"type", "kind", "description"}
+	fieldsCSVHeader = []string{"type", "field", "return_type", "nullable", "deprecated", "deprecation_reason", "description"}
+	argsCSVHeader   = []string{"type", "field", "arg", "arg_type", "default_value", "description"}
+	// combinedCSVHeader is the header of the single-file export: a record
+	// column ("type", "field", or "arg") followed by the union of the columns.
+	combinedCSVHeader = []string{"record", "type", "field", "arg", "kind", "return_type", "nullable",
+		"deprecated", "deprecation_reason", "default_value", "description"}
+)
+
+// buildSchemaCSV flattens an introspection result into csv rows. Input object
+// fields are listed with the fields; introspection types (__*) are skipped.
+func buildSchemaCSV(result map[string]interface{}) (*schemaCSV, error) {
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid introspection response")
+	}
+	types, ok := schema["types"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid types in schema")
+	}
+
+	out := &schemaCSV{}
+	for _, t := range types {
+		tm, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typeName, _ := tm["name"].(string)
+		if typeName == "" || strings.HasPrefix(typeName, "__") {
+			continue
+		}
+		kind, _ := tm["kind"].(string)
+		desc, _ := tm["description"].(string)
+		out.types = append(out.types, []string{typeName, kind, desc})
+
+		fields, _ := tm["fields"].([]interface{})
+		if inputs, ok := tm["inputFields"].([]interface{}); ok {
+			fields = append(fields, inputs...)
+		}
+		for _, f := range fields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fieldName, _ := fm["name"].(string)
+			deprecated, _ := fm["isDeprecated"].(bool)
+			reason, _ := fm["deprecationReason"].(string)
+			fieldDesc, _ := fm["description"].(string)
+			out.fields = append(out.fields, []string{
+				typeName, fieldName, formatTypeRef(fm["type"]), strconv.FormatBool(!isNonNull(fm["type"])),
+				strconv.FormatBool(deprecated), reason, fieldDesc,
+			})
+
+			args, _ := fm["args"].([]interface{})
+			for _, a := range args {
+				am, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				argName, _ := am["name"].(string)
+				def, _ := am["defaultValue"].(string)
+				argDesc, _ := am["description"].(string)
+				out.args = append(out.args, []string{typeName, fieldName, argName, formatTypeRef(am["type"]), def, argDesc})
+			}
+		}
+	}
+
+	for _, rows := range [][][]string{out.types, out.fields, out.args} {
+		sortCSVRows(rows)
+	}
+	return out, nil
+}
+
+// isNonNull reports whether an introspection type reference is NON_NULL.
+func isNonNull(typeRef interface{}) bool {
+	tm, _ := typeRef.(map[string]interface{})
+	kind, _ := tm["kind"].(string)
+	return kind == "NON_NULL"
+}
+
+// sortCSVRows sorts rows by their columns from left to right.
+func sortCSVRows(rows [][]string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k := range rows[i] {
+			if rows[i][k] != rows[j][k] {
+				return rows[i][k] < rows[j][k]
+			}
+		}
+		return false
+	})
+}
+
+// combined returns the single-file export: types, then fields, then args,
+// each tagged in the record column. Like CSVFormatter, it has no trailing
+// newline.
+func (s *schemaCSV) combined() string {
+	rows := [][]string{combinedCSVHeader}
+	for _, r := range s.types {
+		rows = append(rows, []string{"type", r[0], "", "", r[1], "", "", "", "", "", r[2]})
+	}
+	for _, r := range s.fields {
+		rows = append(rows, []string{"field", r[0], r[1], "", "", r[2], r[3], r[4], r[5], "", r[6]})
+	}
+	for _, r := range s.args {
+		rows = append(rows, []string{"arg", r[0], r[1], r[2], "", r[3], "", "", "", r[4], r[5]})
+	}
+	return strings.TrimSuffix(encodeCSV(rows), "\n")
+}
+
+// writeDir writes types.csv, fields.csv, and args.csv to dir and returns
+// their paths. Unchanged files are left untouched.
+func (s *schemaCSV) writeDir(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	files := []struct {
+		name   string
+		header []string
+		rows   [][]string
+	}{
+		{"types.csv", typesCSVHeader, s.types},
+		{"fields.csv", fieldsCSVHeader, s.fields},
+		{"args.csv", argsCSVHeader, s.args},
+	}
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := writeFileIfChanged(path, []byte(encodeCSV(append([][]string{f.header}, f.rows...)))); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// encodeCSV writes rows as RFC 4180 CSV, quoting fields with commas, quotes,
+// or newlines (descriptions often have them).
+func encodeCSV(rows [][]string) string {
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	// Writing to a strings.Builder cannot fail.
+	_ = w.WriteAll(rows)
+	return buf.String()
+}
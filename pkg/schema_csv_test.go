@@ -0,0 +1,71 @@
+package gqlcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaCSVGolden(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+
+	stdout, _, err := runApp(t, app, "introspect", "--format", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "schema-csv/combined.csv", stdout)
+
+	dir := t.TempDir()
+	stdout, _, err = runApp(t, app, "introspect", "--format", "csv", "--output-dir", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "wrote "+filepath.Join(dir, "types.csv"))
+	for _, name := range []string{"types.csv", "fields.csv", "args.csv"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "schema-csv/"+name, string(data))
+	}
+
+	// A second export of the same schema leaves the files untouched.
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"types.csv", "fields.csv", "args.csv"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := runApp(t, app, "introspect", "--format", "csv", "--output-dir", dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"types.csv", "fields.csv", "args.csv"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || !info.ModTime().Equal(old) {
+			t.Errorf("%s was rewritten", name)
+		}
+	}
+}
+
+func TestSchemaCSVFlagConflicts(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	dir := t.TempDir()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"--format", "csv", "--output-dir", dir, "--output", filepath.Join(dir, "schema.csv")}, "not both"},
+		{[]string{"--format", "csv", "--split-output", dir}, "use --output-dir"},
+		{[]string{"--format", "sdl", "--output-dir", dir}, `--output-dir supports the csv format, not "sdl"`},
+	}
+	for _, tt := range tests {
+		_, _, err := runApp(t, app, append([]string{"introspect"}, tt.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: got %v, want an error containing %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package gqlcli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// schemaKindOrder is the order in which kinds appear in rendered schemas.
+var schemaKindOrder = []string{"OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "SCALAR"}
+
+// schemaBlock is the rendered definition of a single type.
+type schemaBlock struct {
+	Name string
+	Kind string
+	Text string
+}
+
+// renderSchemaBlocks renders every non-builtin type of an introspection result
+// as a self-contained block, grouped by kind and sorted by name within a kind.
+// format is "sdl" or "llm"; llm blocks are the type sections LLMFormatter
+// prints for the whole schema.
+func renderSchemaBlocks(result map[string]interface{}, format string) ([]schemaBlock, error) {
+	schema, ok := unwrapSchema(result).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid introspection response")
+	}
+	render := func(t map[string]interface{}) string { return FormatTypeSDL(t, true, false) }
+	if format == "llm" {
+		render = NewLLMFormatter().formatSchemaType
+	}
+	return schemaTypeBlocks(schema, render)
+}
+
+// schemaTypeBlocks renders each non-builtin type of schema with render, in
+// schemaKindOrder and by name within a kind.
+func schemaTypeBlocks(schema map[string]interface{}, render func(map[string]interface{}) string) ([]schemaBlock, error) {
+	types, ok := schema["types"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid types in schema")
+	}
+
+	byKind := map[string][]map[string]interface{}{}
+	for _, t := range types {
+		tm, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := tm["name"].(string)
+		kind, _ := tm["kind"].(string)
+		if name == "" || strings.HasPrefix(name, "__") {
+			continue
+		}
+		byKind[kind] = append(byKind[kind], tm)
+	}
+
+	var blocks []schemaBlock
+	for _, kind := range schemaKindOrder {
+		list := byKind[kind]
+		sort.Slice(list, func(i, j int) bool {
+			return list[i]["name"].(string) < list[j]["name"].(string)
+		})
+		for _, tm := range list {
+			blocks = append(blocks, schemaBlock{Name: tm["name"].(string), Kind: kind, Text: render(tm)})
+		}
+	}
+	return blocks, nil
+}
+
+// joinSchemaBlocks concatenates blocks separated by blank lines.
+func joinSchemaBlocks(blocks []schemaBlock) string {
+	parts := make([]string, len(blocks))
+	for i, b := range blocks {
+		parts[i] = b.Text
+	}
+	return strings.Join(parts, "\n")
+}
+
+// partitionSchemaBlocks assigns each block to one of n buckets by a hash of its
+// type name, where n is the smallest power of two for which no bucket holding
+// more than one type exceeds size characters. A type's bucket depends only on
+// its name and n, so adding, removing, or changing a type rewrites only its own
+// chunk until the schema outgrows n. Buckets keep the order of blocks; some may
+// be empty.
+func partitionSchemaBlocks(blocks []schemaBlock, size int) [][]schemaBlock {
+	for n := 1; ; n *= 2 {
+		buckets := make([][]schemaBlock, n)
+		lens := make([]int, n)
+		fits := true
+		for _, b := range blocks {
+			h := fnv.New32a()
+			h.Write([]byte(b.Name))
+			i := int(h.Sum32() % uint32(n))
+			buckets[i] = append(buckets[i], b)
+			lens[i] += len(b.Text) + 1
+			if len(buckets[i]) > 1 && lens[i] > size {
+				fits = false
+			}
+		}
+		// Past 4 buckets per type, colliding large types share a chunk
+		// rather than doubling forever.
+		if fits || n >= 4*len(blocks) {
+			return buckets
+		}
+	}
+}
+
+// SchemaChunk describes one file written by writeSchemaChunks.
+type SchemaChunk struct {
+	File   string   `json:"file"`
+	Types  []string `json:"types"`
+	Size   int      `json:"size"`
+	SHA256 string   `json:"sha256"`
+}
+
+// SchemaChunkManifest is written as manifest.json next to the chunk files.
+type SchemaChunkManifest struct {
+	Format    string        `json:"format"`
+	ChunkSize int           `json:"chunkSize"`
+	Chunks    []SchemaChunk `json:"chunks"`
+	// Index maps every type name to the file holding its definition.
+	Index map[string]string `json:"index"`
+}
+
+// writeSchemaChunks writes the rendered schema to dir as chunk files plus
+// manifest.json and index.md, which map each type to its chunk. Chunks are
+// numbered by partitionSchemaBlocks bucket, so numbers may have gaps. Files are
+// only rewritten when their content changes, and chunk files no longer
+// produced are removed.
+func writeSchemaChunks(dir string, blocks []schemaBlock, format string, size int) (*SchemaChunkManifest, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	ext := ".graphql"
+	if format == "llm" {
+		ext = ".md"
+	}
+
+	buckets := partitionSchemaBlocks(blocks, size)
+	manifest := &SchemaChunkManifest{Format: format, ChunkSize: size, Index: map[string]string{}}
+	written := map[string]bool{}
+	kinds := map[string]string{}
+	for i, chunk := range buckets {
+		if len(chunk) == 0 {
+			continue
+		}
+		file := fmt.Sprintf("chunk-%03d%s", i+1, ext)
+		names := make([]string, len(chunk))
+		for j, b := range chunk {
+			names[j] = b.Name
+			manifest.Index[b.Name] = file
+			kinds[b.Name] = b.Kind
+		}
+
+		var buf strings.Builder
+		// The header names only this chunk's types so a change elsewhere leaves it unchanged.
+		fmt.Fprintf(&buf, "# Schema chunk %d of %d (index.md lists the chunk of every type)\n", i+1, len(buckets))
+		fmt.Fprintf(&buf, "# Types in this chunk: %s\n\n", strings.Join(names, ", "))
+		buf.WriteString(joinSchemaBlocks(chunk))
+		content := buf.String()
+
+		if err := writeFileIfChanged(filepath.Join(dir, file), []byte(content)); err != nil {
+			return nil, err
+		}
+		written[file] = true
+
+		sum := sha256.Sum256([]byte(content))
+		manifest.Chunks = append(manifest.Chunks, SchemaChunk{
+			File:   file,
+			Types:  names,
+			Size:   len(content),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(dir, "chunk-*"+ext))
+	for _, path := range stale {
+		if !written[filepath.Base(path)] {
+			if err := os.Remove(path); err != nil {
+				return nil, fmt.Errorf("failed to remove stale chunk: %w", err)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(manifest.Index))
+	for name := range manifest.Index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var index strings.Builder
+	index.WriteString("# Schema index\n\n| Type | Kind | Chunk |\n| --- | --- | --- |\n")
+	for _, name := range names {
+		fmt.Fprintf(&index, "| %s | %s | %s |\n", name, strings.ToLower(kinds[name]), manifest.Index[name])
+	}
+	if err := writeFileIfChanged(filepath.Join(dir, "index.md"), []byte(index.String())); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeFileIfChanged(filepath.Join(dir, "manifest.json"), append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeFileIfChanged leaves path untouched when it already holds data, so file
+// modification times only move for chunks whose content changed.
+func writeFileIfChanged(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	ts := newTestServer(t)
+	app := httpApp(t, ts.URL)
+	dir := t.TempDir()
+
+	const size = 400
+	stdout, _, err := runApp(t, app, "introspect", "--format", "llm", "--split-output", dir, "--chunk-size", "400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "chunks")
+	manifest := readManifest(t, dir)
+
+	whole, _, err := runApp(t, app, "introspect", "--format", "llm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, chunk := range manifest.Chunks {
+		data, err := os.ReadFile(filepath.Join(dir, chunk.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := string(data)
+		if len(chunk.Types) > 1 && chunk.Size > size+300 {
+			t.Errorf("%s holds %d types in %d characters", chunk.File, len(chunk.Types), chunk.Size)
+		}
+		for _, name := range chunk.Types {
+			if seen[name] {
+				t.Errorf("%s is in more than one chunk", name)
+			}
+			seen[name] = true
+			if manifest.Index[name] != chunk.File {
+				t.Errorf("index maps %s to %q, want %s", name, manifest.Index[name], chunk.File)
+			}
+			// Each section is exactly what the llm formatter prints for the type.
+			section := llmSection(t, body, name)
+			if !strings.Contains(whole, section) {
+				t.Errorf("%s: section differs from introspect --format llm:\n%s", name, section)
+			}
+		}
+	}
+	for _, name := range []string{"Book", "AddBookInput", "Genre", "BigInt", "SearchResult", "Query"} {
+		if !seen[name] {
+			t.Errorf("%s is in no chunk", name)
+		}
+	}
+	if len(seen) != len(manifest.Index) {
+		t.Errorf("index has %d types, chunks %d", len(manifest.Index), len(seen))
+	}
+	index, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(index), "| Book | object | "+manifest.Index["Book"]+" |")
+}
+
+func TestSplitOutputIsStable(t *testing.T) {
+	ts := newTestServer(t)
+	dir := t.TempDir()
+	result, err := NewHTTPClient(&Config{URL: ts.URL}).Introspect(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(result map[string]interface{}) map[string]string {
+		t.Helper()
+		blocks, err := renderSchemaBlocks(result, "sdl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writeSchemaChunks(dir, blocks, "sdl", 1500); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]string{}
+		paths, _ := filepath.Glob(filepath.Join(dir, "chunk-*"))
+		for _, p := range paths {
+			data, _ := os.ReadFile(p)
+			files[filepath.Base(p)] = string(data)
+		}
+		return files
+	}
+
+	before := write(result)
+	if again := write(result); !equalStringMaps(before, again) {
+		t.Fatal("re-running on the same schema changed the chunks")
+	}
+
+	// Add one small type: only the chunk it lands in may change.
+	schema := unwrapSchema(result).(map[string]interface{})
+	schema["types"] = append(schema["types"].([]interface{}), map[string]interface{}{
+		"kind": "ENUM", "name": "Format", "enumValues": []interface{}{map[string]interface{}{"name": "EBOOK"}},
+	})
+	after := write(result)
+	home := readManifest(t, dir).Index["Format"]
+	if len(after) != len(before) {
+		t.Skipf("the added type changed the bucket count (%d to %d)", len(before), len(after))
+	}
+	for file, content := range after {
+		if file != home && content != before[file] {
+			t.Errorf("%s changed although Format went to %s", file, home)
+		}
+	}
+	if after[home] == before[home] {
+		t.Errorf("%s does not include the added type", home)
+	}
+}
+
+func readManifest(t *testing.T, dir string) SchemaChunkManifest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m SchemaChunkManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// llmSection returns the "### name (...)" section of body.
+func llmSection(t *testing.T, body, name string) string {
+	t.Helper()
+	start := strings.Index(body, "### "+name+" (")
+	if start < 0 {
+		t.Fatalf("no section for %s", name)
+	}
+	rest := body[start:]
+	if end := strings.Index(rest[1:], "\n### "); end >= 0 {
+		rest = rest[:end+1]
+	}
+	return rest
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
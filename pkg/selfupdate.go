@@ -0,0 +1,493 @@
+package gqlcli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/urfave/cli/v2"
+)
+
+// updateAvailableExit is the exit code of self-update --check-only when a
+// newer release exists; 0 means up to date and 1 is an error.
+const updateAvailableExit = 2
+
+// GetSelfUpdateCommand returns the self-update command, which installs a
+// release of Config.ReleaseRepo over the running binary.
+func (b *CLIBuilder) GetSelfUpdateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "self-update",
+		Usage: "Update this binary to the latest GitHub release",
+		Description: "Downloads the release binary for this platform, verifies it against the " +
+			"release's SHA-256 checksums (and their ed25519 signature when a public key is " +
+			"configured), and swaps it into place, restoring the old binary if anything fails. " +
+			"Binaries installed by Homebrew, go install, or Nix are left alone: update them with " +
+			"the package manager. --check-only exits 0 when up to date and 2 when an update is available.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "check-only", Usage: "Only report whether a newer release exists (exit 2 if so)"},
+			&cli.StringFlag{Name: "version", Usage: "Install this release tag (e.g. v1.2.3) instead of the latest, including older ones"},
+			&cli.StringFlag{Name: "public-key", Usage: "Base64 ed25519 key; require a valid checksums signature", Value: b.config.ReleasePublicKey},
+		},
+		Action: func(c *cli.Context) error {
+			ctx := context.Background()
+			u := newSelfUpdater(b.config.ReleaseRepo, c.App.Name)
+			current := c.App.Version
+
+			rel, err := u.release(ctx, c.String("version"))
+			if err != nil {
+				return err
+			}
+			pinned := c.String("version") != ""
+			newer := compareVersions(rel.Tag, current) > 0
+			if !pinned && !newer || pinned && compareVersions(rel.Tag, current) == 0 {
+				fmt.Printf("%s %s is up to date\n", u.binary, displayVersion(current))
+				return nil
+			}
+			if c.Bool("check-only") {
+				fmt.Printf("update available: %s -> %s\n", displayVersion(current), rel.Tag)
+				return cli.Exit("", updateAvailableExit)
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("cannot locate the running binary: %w", err)
+			}
+			if exe, err = filepath.EvalSymlinks(exe); err != nil {
+				return fmt.Errorf("cannot locate the running binary: %w", err)
+			}
+			if manager, hint := managedInstall(exe); manager != "" {
+				return fmt.Errorf("%s was installed by %s; update it with:\n  %s", exe, manager, hint)
+			}
+
+			var key ed25519.PublicKey
+			if s := c.String("public-key"); s != "" {
+				raw, err := base64.StdEncoding.DecodeString(s)
+				if err != nil || len(raw) != ed25519.PublicKeySize {
+					return fmt.Errorf("--public-key: expected a base64 ed25519 public key")
+				}
+				key = raw
+			}
+
+			bin, asset, err := u.download(ctx, rel, key)
+			if err != nil {
+				return err
+			}
+			if err := replaceExecutable(exe, bin); err != nil {
+				return err
+			}
+			fmt.Printf("updated %s from %s to %s (%s)\n", exe, displayVersion(current), rel.Tag, asset)
+			return nil
+		},
+	}
+}
+
+// newSelfUpdater returns an updater for repo's releases. It talks to the
+// GitHub API at GITHUB_API_URL (for GitHub Enterprise) and sends GITHUB_TOKEN
+// when set, to avoid anonymous rate limits.
+func newSelfUpdater(repo, binary string) *selfUpdater {
+	api := os.Getenv("GITHUB_API_URL")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	return &selfUpdater{
+		repo:   repo,
+		binary: binary,
+		client: resty.New().SetTimeout(5 * time.Minute).SetBaseURL(strings.TrimRight(api, "/")),
+		token:  os.Getenv("GITHUB_TOKEN"),
+	}
+}
+
+type selfUpdater struct {
+	repo   string
+	binary string
+	client *resty.Client
+	// token is only sent to the API host: asset URLs point at other hosts
+	// (github.com, its CDN, or wherever a release links) that must not see it.
+	token string
+}
+
+// request starts a request for target, a path under the API or an absolute
+// URL, with the token when target is on the API host.
+func (u *selfUpdater) request(ctx context.Context, target string) *resty.Request {
+	req := u.client.R().SetContext(ctx)
+	if u.token == "" {
+		return req
+	}
+	t, err := url.Parse(target)
+	if err != nil {
+		return req
+	}
+	if api, err := url.Parse(u.client.BaseURL); err == nil && (t.Host == "" || strings.EqualFold(t.Host, api.Host)) {
+		req.SetAuthToken(u.token)
+	}
+	return req
+}
+
+type githubRelease struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the asset called name, or "".
+func (r *githubRelease) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+// release fetches the release tagged version, or the latest one.
+func (u *selfUpdater) release(ctx context.Context, version string) (*githubRelease, error) {
+	path := "/repos/" + u.repo + "/releases/latest"
+	if version != "" {
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		path = "/repos/" + u.repo + "/releases/tags/" + version
+	}
+	resp, err := u.request(ctx, path).SetHeader("Accept", "application/vnd.github+json").Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release: %w", err)
+	}
+	if resp.IsError() {
+		if version != "" && resp.StatusCode() == 404 {
+			return nil, fmt.Errorf("no release %s in %s", version, u.repo)
+		}
+		return nil, fmt.Errorf("failed to fetch release from %s: HTTP %d", u.repo, resp.StatusCode())
+	}
+	var rel githubRelease
+	if err := decodeJSON(resp.Body(), &rel); err != nil {
+		return nil, fmt.Errorf("invalid release JSON: %w", err)
+	}
+	return &rel, nil
+}
+
+// download fetches this platform's asset from rel, verifies it against the
+// published checksums (signed by key, if given), and returns the binary.
+func (u *selfUpdater) download(ctx context.Context, rel *githubRelease, key ed25519.PublicKey) ([]byte, string, error) {
+	asset, err := u.platformAsset(rel)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := u.fetch(ctx, rel.assetURL(asset))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+
+	sumsName := "checksums.txt"
+	if rel.assetURL(sumsName) == "" {
+		sumsName = asset + ".sha256"
+	}
+	if rel.assetURL(sumsName) == "" {
+		return nil, "", fmt.Errorf("release %s publishes no checksums for %s; refusing to install an unverified binary", rel.Tag, asset)
+	}
+	sums, err := u.fetch(ctx, rel.assetURL(sumsName))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", sumsName, err)
+	}
+	if key != nil {
+		sigName := sumsName + ".sig"
+		if rel.assetURL(sigName) == "" {
+			return nil, "", fmt.Errorf("release %s has no %s to check against --public-key", rel.Tag, sigName)
+		}
+		sig, err := u.fetch(ctx, rel.assetURL(sigName))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to download %s: %w", sigName, err)
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+			sig = decoded
+		}
+		if !ed25519.Verify(key, sums, sig) {
+			return nil, "", fmt.Errorf("%s: signature does not match --public-key", sigName)
+		}
+	}
+	want, ok := checksumFor(sums, asset)
+	if !ok {
+		return nil, "", fmt.Errorf("%s has no checksum for %s", sumsName, asset)
+	}
+	got := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(got[:]), want) {
+		return nil, "", fmt.Errorf("checksum mismatch for %s: expected %s, got %x", asset, want, got)
+	}
+
+	bin, err := extractBinary(asset, data, u.binary)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", asset, err)
+	}
+	return bin, asset, nil
+}
+
+func (u *selfUpdater) fetch(ctx context.Context, assetURL string) ([]byte, error) {
+	resp, err := u.request(ctx, assetURL).SetHeader("Accept", "application/octet-stream").Get(assetURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode())
+	}
+	return resp.Body(), nil
+}
+
+// platformAsset picks the release asset for this OS and architecture, named
+// like "gqlcli_linux_amd64", "gqlcli_1.2.3_Darwin_x86_64.tar.gz", or
+// "gqlcli_windows_arm64.zip".
+func (u *selfUpdater) platformAsset(rel *githubRelease) (string, error) {
+	arches := []string{runtime.GOARCH}
+	switch runtime.GOARCH {
+	case "amd64":
+		arches = append(arches, "x86_64")
+	case "arm64":
+		arches = append(arches, "aarch64")
+	}
+	var matches []string
+	for _, a := range rel.Assets {
+		name := strings.ToLower(a.Name)
+		if !strings.HasPrefix(name, u.binary+"_") || strings.HasSuffix(name, ".sha256") ||
+			strings.HasSuffix(name, ".sig") || !strings.Contains(name, "_"+runtime.GOOS) {
+			continue
+		}
+		for _, arch := range arches {
+			if strings.Contains(name, "_"+arch) {
+				matches = append(matches, a.Name)
+				break
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("release %s has no %s binary for %s/%s", rel.Tag, u.binary, runtime.GOOS, runtime.GOARCH)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("release %s has several %s/%s assets (%s); install one with --version or manually",
+		rel.Tag, runtime.GOOS, runtime.GOARCH, strings.Join(matches, ", "))
+}
+
+// checksumFor finds asset's SHA-256 in a sha256sum-style file ("HEX  name"
+// per line), or returns the bare hash of a single-asset .sha256 file.
+func checksumFor(sums []byte, asset string) (string, bool) {
+	lines := strings.Split(strings.TrimSpace(string(sums)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return fields[0], true
+		}
+	}
+	if len(lines) == 1 {
+		if fields := strings.Fields(lines[0]); len(fields) == 1 {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+// extractBinary returns the executable from a .tar.gz or .zip asset, or the
+// asset itself when it is a bare binary.
+func extractBinary(asset string, data []byte, binary string) ([]byte, error) {
+	want := binary
+	if runtime.GOOS == "windows" {
+		want += ".exe"
+	}
+	switch {
+	case strings.HasSuffix(asset, ".tar.gz") || strings.HasSuffix(asset, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if h.Typeflag == tar.TypeReg && filepath.Base(h.Name) == want {
+				return io.ReadAll(tr)
+			}
+		}
+		return nil, fmt.Errorf("archive has no %s", want)
+	case strings.HasSuffix(asset, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == want {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("archive has no %s", want)
+	}
+	return data, nil
+}
+
+// managedInstall reports the package manager that installed exe, with the
+// command to update it, or "" if the binary isn't managed.
+func managedInstall(exe string) (manager, hint string) {
+	slash := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(slash, "/Cellar/") || strings.Contains(slash, "/homebrew/") || strings.Contains(slash, "/linuxbrew/"):
+		return "Homebrew", "brew upgrade " + strings.TrimSuffix(filepath.Base(exe), ".exe")
+	case strings.HasPrefix(slash, "/nix/store/"):
+		return "Nix", "update it through your Nix profile or flake"
+	}
+
+	dir := filepath.Dir(exe)
+	goBins := []string{os.Getenv("GOBIN")}
+	for _, p := range filepath.SplitList(os.Getenv("GOPATH")) {
+		goBins = append(goBins, filepath.Join(p, "bin"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		goBins = append(goBins, filepath.Join(home, "go", "bin"))
+	}
+	for _, d := range goBins {
+		if d != "" && filepath.Clean(d) == dir {
+			pkg := "<module>/cmd/" + filepath.Base(exe)
+			if info, ok := debug.ReadBuildInfo(); ok && info.Path != "" {
+				pkg = info.Path
+			}
+			return "go install", "go install " + pkg + "@latest"
+		}
+	}
+	return "", ""
+}
+
+// renameFile is os.Rename; tests replace it to fail the swap part-way.
+var renameFile = os.Rename
+
+// replaceExecutable swaps bin in for exe. The new binary is written next to
+// exe and must run "--version" successfully before the swap; the old binary
+// is moved aside and restored if the new one can't be put in place.
+func replaceExecutable(exe string, bin []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (try again with permission to replace %s): %w", dir, exe, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpName, info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, tmpName, "--version").CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return fmt.Errorf("downloaded binary does not run (%v); keeping the current one", err)
+	}
+
+	old := exe + ".old"
+	os.Remove(old)
+	if err := renameFile(exe, old); err != nil {
+		return fmt.Errorf("failed to move the current binary aside: %w", err)
+	}
+	if err := renameFile(tmpName, exe); err != nil {
+		if rerr := renameFile(old, exe); rerr != nil {
+			return fmt.Errorf("failed to install the new binary (%v) and to restore the old one from %s: %w", err, old, rerr)
+		}
+		return fmt.Errorf("failed to install the new binary; kept the current one: %w", err)
+	}
+	// Windows can't remove a running executable; the leftover is replaced
+	// by the next update.
+	os.Remove(old)
+	return nil
+}
+
+// compareVersions compares "vX.Y.Z" style versions numerically, ignoring a
+// leading "v" and any pre-release or build suffix. A version that doesn't
+// parse (such as "dev") is older than any that does.
+func compareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+func displayVersion(v string) string {
+	if v == "" {
+		return "(unknown version)"
+	}
+	if _, ok := parseVersion(v); ok && !strings.HasPrefix(v, "v") {
+		return "v" + v
+	}
+	return v
+}
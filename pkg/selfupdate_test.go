@@ -0,0 +1,535 @@
+package gqlcli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"1.2.3", "v1.2.3", 0},
+		{"v1.2.10", "v1.2.9", 1},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v2.0.0", "v10.0.0", -1},
+		{"v1.2", "v1.2.0", 0},
+		{"v1", "v1.0.1", -1},
+		{"v1.2.3-rc.1", "v1.2.3", 0},
+		{"v1.2.3+build.7", "v1.2.2", 1},
+		{"dev", "v0.0.1", -1},
+		{"v0.0.1", "", 1},
+		{"dev", "", 0},
+		{"v1.2.3.4", "v1.2.3", -1},
+		{"v1.x", "v1.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestChecksumFor(t *testing.T) {
+	const sums = "aaa  tool_linux_amd64\nbbb *tool_darwin_arm64.tar.gz\n\nccc  tool_linux_amd64.sig\n"
+	tests := []struct {
+		name, sums, asset string
+		want              string
+		ok                bool
+	}{
+		{"sha256sum line", sums, "tool_linux_amd64", "aaa", true},
+		{"binary mode marker", sums, "tool_darwin_arm64.tar.gz", "bbb", true},
+		{"missing asset", sums, "tool_windows_amd64.zip", "", false},
+		{"no prefix matches", sums, "linux_amd64", "", false},
+		{"bare .sha256 file", "ddd\n", "tool_linux_amd64", "ddd", true},
+		{"single line for another asset", "eee  other", "tool_linux_amd64", "", false},
+		{"empty", "", "tool_linux_amd64", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := checksumFor([]byte(tt.sums), tt.asset)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPlatformAsset(t *testing.T) {
+	goos, arch := runtime.GOOS, runtime.GOARCH
+	alias := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[arch]
+	other := "plan9"
+	if goos == other {
+		other = "linux"
+	}
+
+	tests := []struct {
+		name    string
+		assets  []string
+		want    string
+		wantErr string
+	}{
+		{"bare binary", []string{"tool_" + goos + "_" + arch, "tool_" + other + "_" + arch}, "tool_" + goos + "_" + arch, ""},
+		{"archive with version", []string{"checksums.txt", "tool_1.2.0_" + goos + "_" + arch + ".tar.gz"}, "tool_1.2.0_" + goos + "_" + arch + ".tar.gz", ""},
+		{"checksum and signature files skipped", []string{"tool_" + goos + "_" + arch + ".sha256", "tool_" + goos + "_" + arch + ".sig", "tool_" + goos + "_" + arch + ".zip"}, "tool_" + goos + "_" + arch + ".zip", ""},
+		{"other binaries skipped", []string{"toolkit_" + goos + "_" + arch, "other_" + goos + "_" + arch}, "", "has no tool binary"},
+		{"other platforms only", []string{"tool_" + other + "_" + arch}, "", "has no tool binary"},
+		{"several matches", []string{"tool_" + goos + "_" + arch, "tool_" + goos + "_" + arch + ".tar.gz"}, "", "several"},
+	}
+	if alias != "" {
+		tests = append(tests, struct {
+			name    string
+			assets  []string
+			want    string
+			wantErr string
+		}{"uname arch alias, any case", []string{"tool_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + alias + ".tar.gz"}, "tool_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + alias + ".tar.gz", ""})
+	}
+
+	u := &selfUpdater{binary: "tool"}
+	for _, tt := range tests {
+		rel := &githubRelease{Tag: "v1.2.0"}
+		for _, name := range tt.assets {
+			rel.Assets = append(rel.Assets, struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			}{Name: name})
+		}
+		got, err := u.platformAsset(rel)
+		switch {
+		case tt.wantErr == "" && (err != nil || got != tt.want):
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, tt.want)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %q, %v; want an error containing %q", tt.name, got, err, tt.wantErr)
+		}
+	}
+}
+
+// tarGz and zipArchive build release archives holding files by name.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractBinary(t *testing.T) {
+	exe := "tool"
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	tests := []struct {
+		name, asset string
+		data        []byte
+		want        string
+		wantErr     string
+	}{
+		{"bare binary", "tool_linux_amd64", []byte("BIN"), "BIN", ""},
+		{"tar.gz in a directory", "tool.tar.gz", tarGz(t, map[string]string{"README.md": "docs", "tool_1.2.0/" + exe: "BIN"}), "BIN", ""},
+		{"tgz", "tool.tgz", tarGz(t, map[string]string{exe: "BIN"}), "BIN", ""},
+		{"zip", "tool.zip", zipArchive(t, map[string]string{"LICENSE": "MIT", exe: "BIN"}), "BIN", ""},
+		{"tar.gz without the binary", "tool.tar.gz", tarGz(t, map[string]string{"toolkit": "BIN"}), "", "archive has no " + exe},
+		{"zip without the binary", "tool.zip", zipArchive(t, map[string]string{"README.md": "docs"}), "", "archive has no " + exe},
+		{"corrupt tar.gz", "tool.tar.gz", []byte("this is not gzip data"), "", "gzip"},
+		{"corrupt zip", "tool.zip", []byte("not zip"), "", "zip"},
+	}
+	for _, tt := range tests {
+		got, err := extractBinary(tt.asset, tt.data, "tool")
+		switch {
+		case tt.wantErr == "" && (err != nil || string(got) != tt.want):
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, tt.want)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestManagedInstall(t *testing.T) {
+	gobin := t.TempDir()
+	gopath := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("GOBIN", gobin)
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		exe, manager, hint string
+	}{
+		{"/usr/local/Cellar/tool/1.2.0/bin/tool", "Homebrew", "brew upgrade tool"},
+		{"/opt/homebrew/bin/tool", "Homebrew", "brew upgrade tool"},
+		{"/home/linuxbrew/.linuxbrew/bin/tool", "Homebrew", "brew upgrade tool"},
+		{"/nix/store/abc-tool-1.2.0/bin/tool", "Nix", "Nix profile"},
+		{filepath.Join(gobin, "tool"), "go install", "go install "},
+		{filepath.Join(gopath, "bin", "tool"), "go install", "@latest"},
+		{filepath.Join(home, "go", "bin", "tool"), "go install", "@latest"},
+		{"/usr/local/bin/tool", "", ""},
+		{filepath.Join(gobin, "sub", "tool"), "", ""},
+	}
+	for _, tt := range tests {
+		manager, hint := managedInstall(tt.exe)
+		if manager != tt.manager || !strings.Contains(hint, tt.hint) {
+			t.Errorf("%s: got %q, %q; want %q with a hint containing %q", tt.exe, manager, hint, tt.manager, tt.hint)
+		}
+	}
+}
+
+// script returns a shell script binary that prints out, or fails.
+func script(out string, fail bool) []byte {
+	if fail {
+		return []byte("#!/bin/sh\necho broken >&2\nexit 1\n")
+	}
+	return []byte("#!/bin/sh\necho " + out + "\n")
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binaries are shell scripts")
+	}
+	errRename := errors.New("rename refused")
+	tests := []struct {
+		name    string
+		bin     []byte
+		rename  func(from, to string) error
+		want    string // contents of exe afterwards
+		wantErr string
+	}{
+		{name: "swapped", bin: script("new", false), want: "new"},
+		{name: "new binary does not run", bin: script("new", true), want: "old", wantErr: "does not run"},
+		{
+			name: "install fails, old binary restored",
+			bin:  script("new", false),
+			rename: func(from, to string) error {
+				if strings.Contains(filepath.Base(from), ".new-") {
+					return errRename
+				}
+				return os.Rename(from, to)
+			},
+			want:    "old",
+			wantErr: "kept the current one",
+		},
+		{
+			name: "moving aside fails",
+			bin:  script("new", false),
+			rename: func(from, to string) error {
+				return errRename
+			},
+			want:    "old",
+			wantErr: "failed to move the current binary aside",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rename != nil {
+				renameFile = tt.rename
+				t.Cleanup(func() { renameFile = os.Rename })
+			}
+			dir := t.TempDir()
+			exe := filepath.Join(dir, "tool")
+			if err := os.WriteFile(exe, script("old", false), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := replaceExecutable(exe, tt.bin)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+			data, err := os.ReadFile(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "echo "+tt.want) {
+				t.Errorf("exe is now %q, want the %s binary", data, tt.want)
+			}
+			if info, err := os.Stat(exe); err != nil || info.Mode().Perm()&0o111 == 0 {
+				t.Errorf("exe is not executable: %v %v", info.Mode(), err)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("left behind %v, want only tool", names)
+			}
+		})
+	}
+}
+
+// releaseFixture is a GitHub API serving one release of "acme/tool", whose
+// assets are served by a separate download host. It records the
+// Authorization header each host receives.
+type releaseFixture struct {
+	api, files *httptest.Server
+
+	mu        sync.Mutex
+	apiAuth   []string
+	filesAuth []string
+}
+
+// newReleaseFixture publishes tag with assets. Only tag (as latest or by
+// name) is found.
+func newReleaseFixture(t *testing.T, tag string, assets map[string][]byte) *releaseFixture {
+	t.Helper()
+	f := &releaseFixture{}
+	f.files = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.filesAuth = append(f.filesAuth, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(f.files.Close)
+
+	rel := map[string]interface{}{"tag_name": tag}
+	var list []map[string]string
+	for name := range assets {
+		list = append(list, map[string]string{"name": name, "browser_download_url": f.files.URL + "/download/" + name})
+	}
+	rel["assets"] = list
+	f.api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.apiAuth = append(f.apiAuth, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+		switch r.URL.Path {
+		case "/repos/acme/tool/releases/latest", "/repos/acme/tool/releases/tags/" + tag:
+			json.NewEncoder(w).Encode(rel)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.api.Close)
+	t.Setenv("GITHUB_API_URL", f.api.URL)
+	return f
+}
+
+// platformRelease returns release assets for this platform: the binary
+// (which prints version) as tool_<os>_<arch> and its checksums.txt.
+func platformRelease(version string) (map[string][]byte, string) {
+	asset := fmt.Sprintf("tool_%s_%s", runtime.GOOS, runtime.GOARCH)
+	bin := script(version, false)
+	sum := sha256.Sum256(bin)
+	return map[string][]byte{
+		asset:           bin,
+		"checksums.txt": []byte(hex.EncodeToString(sum[:]) + "  " + asset + "\n"),
+	}, asset
+}
+
+func TestSelfUpdateRelease(t *testing.T) {
+	assets, _ := platformRelease("v1.2.0")
+	newReleaseFixture(t, "v1.2.0", assets)
+	u := newSelfUpdater("acme/tool", "tool")
+	ctx := context.Background()
+
+	for _, version := range []string{"", "v1.2.0", "1.2.0"} {
+		rel, err := u.release(ctx, version)
+		if err != nil {
+			t.Fatalf("%q: %v", version, err)
+		}
+		if rel.Tag != "v1.2.0" || len(rel.Assets) != 2 {
+			t.Errorf("%q: got %s with %d assets", version, rel.Tag, len(rel.Assets))
+		}
+	}
+	if _, err := u.release(ctx, "v9.9.9"); err == nil || !strings.Contains(err.Error(), "no release v9.9.9 in acme/tool") {
+		t.Errorf("got %v, want a missing release error", err)
+	}
+	missing := newSelfUpdater("acme/gone", "tool")
+	if _, err := missing.release(ctx, ""); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("got %v, want an HTTP error", err)
+	}
+}
+
+func TestSelfUpdateDownload(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	good, asset := platformRelease("v1.2.0")
+	with := func(extra map[string][]byte, drop ...string) map[string][]byte {
+		out := map[string][]byte{}
+		for k, v := range good {
+			out[k] = v
+		}
+		for _, k := range drop {
+			delete(out, k)
+		}
+		for k, v := range extra {
+			out[k] = v
+		}
+		return out
+	}
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, good["checksums.txt"])))
+	sum := sha256.Sum256(good[asset])
+
+	tests := []struct {
+		name    string
+		assets  map[string][]byte
+		key     ed25519.PublicKey
+		wantErr string
+	}{
+		{name: "checksums.txt", assets: good},
+		{name: "per-asset .sha256", assets: with(map[string][]byte{asset + ".sha256": []byte(hex.EncodeToString(sum[:]) + "\n")}, "checksums.txt")},
+		{name: "signed", assets: with(map[string][]byte{"checksums.txt.sig": sig}), key: pub},
+		{name: "signature ignored without a key", assets: with(map[string][]byte{"checksums.txt.sig": []byte("bogus")})},
+		{name: "wrong key", assets: with(map[string][]byte{"checksums.txt.sig": sig}), key: otherPub, wantErr: "signature does not match"},
+		{name: "key without signature", assets: good, key: pub, wantErr: "has no checksums.txt.sig"},
+		{name: "no checksums", assets: with(nil, "checksums.txt"), wantErr: "refusing to install an unverified binary"},
+		{name: "checksum mismatch", assets: with(map[string][]byte{asset: []byte("tampered")}), wantErr: "checksum mismatch"},
+		{name: "asset not in checksums", assets: with(map[string][]byte{"checksums.txt": []byte("abc  other\ndef  another\n")}), wantErr: "has no checksum for " + asset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newReleaseFixture(t, "v1.2.0", tt.assets)
+			u := newSelfUpdater("acme/tool", "tool")
+			rel, err := u.release(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			bin, got, err := u.download(context.Background(), rel, tt.key)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+			case tt.wantErr == "" && (got != asset || !bytes.Equal(bin, good[asset])):
+				t.Errorf("got asset %s (%q), want %s", got, bin, asset)
+			}
+		})
+	}
+}
+
+func TestSelfUpdateTokenOnlyForAPI(t *testing.T) {
+	assets, _ := platformRelease("v1.2.0")
+	f := newReleaseFixture(t, "v1.2.0", assets)
+	t.Setenv("GITHUB_TOKEN", "s3cret")
+	u := newSelfUpdater("acme/tool", "tool")
+
+	rel, err := u.release(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := u.download(context.Background(), rel, nil); err != nil {
+		t.Fatal(err)
+	}
+	// An asset URL on the API host is an API download and gets the token.
+	if _, err := u.fetch(context.Background(), f.api.URL+"/repos/acme/tool/releases/latest"); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.apiAuth) != 2 || f.apiAuth[0] != "Bearer s3cret" || f.apiAuth[1] != "Bearer s3cret" {
+		t.Errorf("API host saw Authorization %q, want the token on every request", f.apiAuth)
+	}
+	if len(f.filesAuth) != 2 || f.filesAuth[0] != "" || f.filesAuth[1] != "" {
+		t.Errorf("download host saw Authorization %q, want none", f.filesAuth)
+	}
+}
+
+// selfUpdateApp is an HTTP CLI at version whose self-update reads the
+// acme/tool release fixture.
+func selfUpdateApp(t *testing.T, version string) *cli.App {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: "http://localhost/graphql", CacheDir: t.TempDir(), ReleaseRepo: "acme/tool"}
+	app := &cli.App{Name: "tool", Version: version}
+	NewCLIBuilder(cfg).RegisterCommands(app)
+	return app
+}
+
+func TestSelfUpdateCommand(t *testing.T) {
+	assets, _ := platformRelease("v1.2.0")
+	newReleaseFixture(t, "v1.2.0", assets)
+
+	stdout, _, err := runApp(t, selfUpdateApp(t, "1.0.0"), "self-update", "--check-only")
+	var exit cli.ExitCoder
+	if !errors.As(err, &exit) || exit.ExitCode() != updateAvailableExit {
+		t.Errorf("got %v, want exit code %d", err, updateAvailableExit)
+	}
+	assertContains(t, stdout, "update available: v1.0.0 -> v1.2.0")
+
+	stdout, _, err = runApp(t, selfUpdateApp(t, "v1.2.0"), "self-update", "--check-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "tool v1.2.0 is up to date")
+
+	if _, _, err := runApp(t, selfUpdateApp(t, "v1.2.0"), "self-update", "--version", "v1.1.0"); err == nil || !strings.Contains(err.Error(), "no release v1.1.0") {
+		t.Errorf("got %v, want the pinned release looked up", err)
+	}
+
+	// The test binary stands in for a go install'ed one, which is refused
+	// before anything is downloaded or replaced.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOBIN", filepath.Dir(exe))
+	_, _, err = runApp(t, selfUpdateApp(t, "1.0.0"), "self-update")
+	if err == nil || !strings.Contains(err.Error(), "was installed by go install; update it with:") {
+		t.Errorf("got %v, want the go install refusal", err)
+	}
+}
@@ -0,0 +1,138 @@
+package gqlcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/urfave/cli/v2"
+)
+
+// postResultFlags configure the result sink shared by the operation commands.
+func postResultFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.GenericFlag{
+			Name: "post-result",
+			Usage: "POST the output to URL after printing it (repeatable). The URL is a Go template " +
+				"over the response, e.g. 'https://hooks/x?status={{.data.health.status | urlquery}}'",
+			Value: &varList{},
+		},
+		&cli.BoolFlag{
+			Name:  "post-raw",
+			Usage: "POST the raw JSON response instead of the formatted output",
+		},
+		&cli.StringFlag{
+			Name:  "post-content-type",
+			Usage: "Content-Type for --post-result (default: application/json for JSON bodies, text/plain otherwise)",
+		},
+		&cli.GenericFlag{
+			Name:  "post-header",
+			Usage: "Extra header for --post-result as 'Name: value' (repeatable)",
+			Value: &varList{},
+		},
+		&cli.IntFlag{
+			Name:  "post-retries",
+			Usage: "Delivery attempts per URL for network errors, 429, and 5xx responses",
+			Value: 3,
+		},
+		&cli.BoolFlag{
+			Name:  "post-best-effort",
+			Usage: "Warn instead of exiting non-zero when delivery fails",
+		},
+	}
+}
+
+// postResult delivers output (or the raw result with --post-raw) to every
+// --post-result URL. It runs after the normal output has been written, so a
+// delivery failure never hides the result.
+func postResult(c *cli.Context, output string, result map[string]interface{}) error {
+	urls, _ := c.Generic("post-result").(*varList)
+	if urls == nil || len(*urls) == 0 {
+		return nil
+	}
+
+	body := []byte(output)
+	jsonBody := json.Valid(body)
+	if c.Bool("post-raw") {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+		body, jsonBody = raw, true
+	}
+	contentType := c.String("post-content-type")
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+		if jsonBody {
+			contentType = "application/json"
+		}
+	}
+	headers := map[string]string{"Content-Type": contentType}
+	if extra, ok := c.Generic("post-header").(*varList); ok {
+		for _, h := range *extra {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return fmt.Errorf("--post-header %q: expected 'Name: value'", h)
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	client := resty.New().SetTimeout(30 * time.Second)
+	policy := RetryPolicy{Attempts: c.Int("post-retries")}
+	for _, tmpl := range *urls {
+		target, err := expandResultURL(tmpl, result)
+		if err == nil {
+			err = retryWithBackoff(c.Context, policy, func() error {
+				return postOnce(c.Context, client, target, headers, body)
+			})
+		}
+		if err == nil {
+			continue
+		}
+		if c.Bool("post-best-effort") {
+			fmt.Fprintf(os.Stderr, "warning: failed to post result to %s: %v\n", tmpl, err)
+			continue
+		}
+		return fmt.Errorf("failed to post result to %s: %w", tmpl, err)
+	}
+	return nil
+}
+
+// expandResultURL executes tmpl as a Go template with the response as data.
+// Referencing a field that is missing from the response is an error.
+func expandResultURL(tmpl string, result map[string]interface{}) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+	t, err := template.New("url").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, result); err != nil {
+		return "", fmt.Errorf("invalid URL template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func postOnce(ctx context.Context, client *resty.Client, url string, headers map[string]string, body []byte) error {
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeaders(headers).
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.IsSuccess() {
+		return nil
+	}
+	return &httpStatusError{StatusCode: resp.StatusCode(), Status: resp.Status()}
+}
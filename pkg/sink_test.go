@@ -0,0 +1,207 @@
+package gqlcli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sinkRequest is one delivery received by a resultSink.
+type sinkRequest struct {
+	uri    string
+	header http.Header
+	body   string
+}
+
+// resultSink is a --post-result endpoint that records what it receives and
+// answers with the given status codes in turn, then 204.
+type resultSink struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []sinkRequest
+	statuses []int
+}
+
+func newResultSink(t *testing.T, statuses ...int) *resultSink {
+	t.Helper()
+	s := &resultSink{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, sinkRequest{uri: r.URL.RequestURI(), header: r.Header.Clone(), body: string(body)})
+		status := http.StatusNoContent
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *resultSink) received() []sinkRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkRequest(nil), s.requests...)
+}
+
+func TestPostResultDelivery(t *testing.T) {
+	ts := newTestServer(t)
+	const query = `{ books(genre: SCIENCE) { title } }`
+
+	t.Run("templated URL, headers, formatted body", func(t *testing.T) {
+		sink := newResultSink(t)
+		stdout, _, err := runApp(t, httpApp(t, ts.URL), "query", "--format", "json",
+			"--post-result", sink.URL+"/hook?title={{(index .data.books 0).title | urlquery}}&n={{len .data.books}}",
+			"--post-header", "X-Run: 7", "--post-header", "Authorization:Bearer hook",
+			query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := sink.received()
+		if len(got) != 1 {
+			t.Fatalf("sink received %d requests, want 1", len(got))
+		}
+		if want := "/hook?title=Cosmos&n=1"; got[0].uri != want {
+			t.Errorf("URL %q, want %q", got[0].uri, want)
+		}
+		for name, want := range map[string]string{"X-Run": "7", "Authorization": "Bearer hook", "Content-Type": "application/json"} {
+			if v := got[0].header.Get(name); v != want {
+				t.Errorf("%s: %q, want %q", name, v, want)
+			}
+		}
+		if strings.TrimSpace(got[0].body) != strings.TrimSpace(stdout) {
+			t.Errorf("body %q, want the printed output %q", got[0].body, stdout)
+		}
+	})
+
+	t.Run("content types", func(t *testing.T) {
+		tests := []struct {
+			args     []string
+			wantType string
+			wantBody string
+		}{
+			{[]string{"--format", "table"}, "text/plain; charset=utf-8", "Cosmos"},
+			{[]string{"--format", "table", "--post-raw"}, "application/json", `{"data":{"books":[{"title":"Cosmos"}]}}`},
+			{[]string{"--format", "json", "--post-content-type", "application/x-ndjson"}, "application/x-ndjson", `"Cosmos"`},
+		}
+		for _, tt := range tests {
+			sink := newResultSink(t)
+			args := append(append([]string{"query"}, tt.args...), "--post-result", sink.URL, query)
+			if _, _, err := runApp(t, httpApp(t, ts.URL), args...); err != nil {
+				t.Fatalf("%v: %v", tt.args, err)
+			}
+			got := sink.received()
+			if len(got) != 1 {
+				t.Fatalf("%v: sink received %d requests, want 1", tt.args, len(got))
+			}
+			if ct := got[0].header.Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("%v: Content-Type %q, want %q", tt.args, ct, tt.wantType)
+			}
+			assertContains(t, got[0].body, tt.wantBody)
+		}
+	})
+
+	t.Run("every URL receives the result", func(t *testing.T) {
+		first, second := newResultSink(t), newResultSink(t)
+		if _, _, err := runApp(t, httpApp(t, ts.URL), "query", "--post-result", first.URL, "--post-result", second.URL+"/b", query); err != nil {
+			t.Fatal(err)
+		}
+		if len(first.received()) != 1 || len(second.received()) != 1 {
+			t.Errorf("sinks received %d and %d requests, want 1 each", len(first.received()), len(second.received()))
+		}
+	})
+}
+
+func TestPostResultRetries(t *testing.T) {
+	ts := newTestServer(t)
+	const query = `{ books { id } }`
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		args      []string
+		wantCalls int
+		wantErr   string
+	}{
+		{"transient failure retried", []int{503}, []string{"--post-retries", "2"}, 2, ""},
+		{"429 retried", []int{429}, []string{"--post-retries", "2"}, 2, ""},
+		{"attempts exhausted", []int{500, 500}, []string{"--post-retries", "2"}, 2, "server responded 500"},
+		{"client error not retried", []int{400}, []string{"--post-retries", "3"}, 1, "server responded 400"},
+		{"single attempt", []int{502}, []string{"--post-retries", "1"}, 1, "server responded 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newResultSink(t, tt.statuses...)
+			args := append(append([]string{"query", "--format", "json"}, tt.args...), "--post-result", sink.URL, query)
+			stdout, _, err := runApp(t, httpApp(t, ts.URL), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+			if n := len(sink.received()); n != tt.wantCalls {
+				t.Errorf("sink received %d requests, want %d", n, tt.wantCalls)
+			}
+			// A failed delivery never hides the result.
+			assertContains(t, stdout, `"books"`)
+		})
+	}
+}
+
+func TestPostResultBestEffort(t *testing.T) {
+	ts := newTestServer(t)
+	sink := newResultSink(t, 500)
+
+	stdout, stderr, err := runApp(t, httpApp(t, ts.URL), "query", "--post-retries", "1", "--post-best-effort",
+		"--post-result", sink.URL+"/{{.data.books | len}}", "{ books { id } }")
+	if err != nil {
+		t.Fatalf("got %v, want exit 0 with --post-best-effort", err)
+	}
+	assertContains(t, stdout, `"books"`)
+	// The warning names the URL template, not the expanded URL.
+	assertContains(t, stderr, "warning: failed to post result to "+sink.URL+"/{{.data.books | len}}: server responded 500")
+
+	// Without it the same failure is the command's error.
+	sink = newResultSink(t, 500)
+	if _, _, err := runApp(t, httpApp(t, ts.URL), "query", "--post-retries", "1", "--post-result", sink.URL, "{ books { id } }"); err == nil {
+		t.Error("expected a non-zero exit without --post-best-effort")
+	}
+}
+
+func TestPostResultInvalidFlags(t *testing.T) {
+	ts := newTestServer(t)
+	sink := newResultSink(t)
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"--post-result", sink.URL + "/{{.data.missing}}"}, "invalid URL template"},
+		{[]string{"--post-result", sink.URL + "/{{.data"}, "invalid URL template"},
+		{[]string{"--post-result", sink.URL, "--post-header", "no colon"}, `--post-header "no colon"`},
+		{[]string{"--post-result", sink.URL, "--post-header", ": value"}, "expected 'Name: value'"},
+	}
+	for _, tt := range tests {
+		args := append(append([]string{"query"}, tt.args...), "{ books { id } }")
+		if _, _, err := runApp(t, httpApp(t, ts.URL), args...); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: got %v, want an error containing %q", tt.args, err, tt.wantErr)
+		}
+	}
+	if n := len(sink.received()); n != 0 {
+		t.Errorf("sink received %d requests, want none", n)
+	}
+
+	// Expanding the URL is part of delivery, so --post-best-effort turns a
+	// bad template into a warning as well.
+	_, stderr, err := runApp(t, httpApp(t, ts.URL), "query", "--post-best-effort", "--post-result", sink.URL+"/{{.data.missing}}", "{ books { id } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stderr, "warning: failed to post result", "invalid URL template")
+}
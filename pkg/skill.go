@@ -1,10 +1,12 @@
 package gqlcli
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 )
@@ -18,8 +20,12 @@ func (b *CLIBuilder) GetInstallSkillCommand() *cli.Command {
 		Name:  "install-skill",
 		Usage: "Install the gqlcli Claude Code skill to ~/.claude/skills/gqlcli/",
 		Description: "Installs the gqlcli skill for Claude Code so that Claude knows how to use " +
-			"gqlcli. Skips installation if the skill is already present.",
+			"gqlcli. The skill is generated from this binary's commands, so re-running it after " +
+			"an upgrade updates an installed skill to match. Examples using commands or flags " +
+			"this program doesn't register are left out.",
 		Action: func(c *cli.Context) error {
+			content, _ := renderSkill(c.App, b.formatReg)
+
 			home, err := os.UserHomeDir()
 			if err != nil {
 				return fmt.Errorf("could not determine home directory: %w", err)
@@ -28,8 +34,9 @@ func (b *CLIBuilder) GetInstallSkillCommand() *cli.Command {
 			skillDir := filepath.Join(home, ".claude", "skills", "gqlcli")
 			skillFile := filepath.Join(skillDir, "SKILL.md")
 
-			if _, err := os.Stat(skillFile); err == nil {
-				fmt.Println("skill already installed at", skillFile)
+			existing, err := os.ReadFile(skillFile)
+			if err == nil && bytes.Equal(existing, content) {
+				fmt.Println("skill already up to date at", skillFile)
 				return nil
 			}
 
@@ -37,12 +44,199 @@ func (b *CLIBuilder) GetInstallSkillCommand() *cli.Command {
 				return fmt.Errorf("could not create skill directory: %w", err)
 			}
 
-			if err := os.WriteFile(skillFile, skillMD, 0644); err != nil {
+			if err := os.WriteFile(skillFile, content, 0644); err != nil {
 				return fmt.Errorf("could not write skill file: %w", err)
 			}
 
-			fmt.Println("skill installed at", skillFile)
+			if existing != nil {
+				fmt.Println("skill updated at", skillFile)
+			} else {
+				fmt.Println("skill installed at", skillFile)
+			}
 			return nil
 		},
 	}
 }
+
+// renderSkill generates SKILL.md for app: the embedded manual with the build's
+// version stamped under the title, example lines that use commands or flags
+// app lacks removed, and a command reference built from the catalog. The
+// removed lines are also returned as problems, which the tests require to be
+// empty for the full gqlcli command set.
+func renderSkill(app *cli.App, formats FormatterRegistry) ([]byte, []string) {
+	lines := strings.Split(string(skillMD), "\n")
+	drop := make(map[int]bool)
+	var problems []string
+	for _, st := range skillStatements(lines) {
+		if msg := checkSkillStatement(app, st); msg != "" {
+			problems = append(problems, fmt.Sprintf("line %d: %s", st.start+1, msg))
+			for i := st.start; i <= st.end; i++ {
+				drop[i] = true
+			}
+		}
+	}
+
+	cat := BuildCatalog(app)
+	var out strings.Builder
+	titled := false
+	for i, line := range lines {
+		if drop[i] {
+			continue
+		}
+		out.WriteString(line)
+		out.WriteString("\n")
+		if !titled && strings.HasPrefix(line, "# ") {
+			titled = true
+			if cat.Version != "" {
+				fmt.Fprintf(&out, "\nGenerated for %s %s. Run `%s capabilities` to check what this build supports.\n",
+					app.Name, cat.Version, app.Name)
+			}
+		}
+	}
+
+	text := strings.TrimRight(out.String(), "\n")
+	out.Reset()
+	out.WriteString(text)
+	fmt.Fprintf(&out, "\n\n## Command reference\n\nFormats: %s\n\n", strings.Join(formats.List(), ", "))
+	for _, cmd := range cat.Commands {
+		fmt.Fprintf(&out, "- `%s %s`", app.Name, cmd.Path)
+		if cmd.Usage != "" {
+			fmt.Fprintf(&out, " — %s", cmd.Usage)
+		}
+		if len(cmd.Flags) > 0 {
+			out.WriteString(" (")
+			for i, f := range cmd.Flags {
+				if i > 0 {
+					out.WriteString(", ")
+				}
+				fmt.Fprintf(&out, "`--%s`", f)
+			}
+			out.WriteString(")")
+		}
+		out.WriteString("\n")
+	}
+	return []byte(out.String()), problems
+}
+
+// skillStatement is a command line (with its backslash continuations) or a
+// bare flag line inside a fenced code block of the skill.
+type skillStatement struct {
+	start, end int      // line indexes, inclusive
+	words      []string // shell words, without the program name and comments
+	bare       bool     // a flag line without a command, e.g. "--debug  # ..."
+}
+
+func skillStatements(lines []string) []skillStatement {
+	var out []skillStatement
+	inCode := false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "gqlcli "):
+			st := skillStatement{start: i, end: i}
+			text := trimmed
+			for strings.HasSuffix(strings.TrimSpace(lines[st.end]), "\\") && st.end+1 < len(lines) {
+				st.end++
+				text += " " + strings.TrimSpace(lines[st.end])
+			}
+			st.words = shellWords(text)[1:]
+			out = append(out, st)
+			i = st.end
+		case strings.HasPrefix(trimmed, "-"):
+			out = append(out, skillStatement{start: i, end: i, words: shellWords(trimmed), bare: true})
+		}
+	}
+	return out
+}
+
+// checkSkillStatement returns why st can't run against app, or "".
+func checkSkillStatement(app *cli.App, st skillStatement) string {
+	var cmd *cli.Command
+	var path []string
+	flagsFrom := 0
+	if !st.bare {
+		for i, w := range st.words {
+			if strings.HasPrefix(w, "-") {
+				break
+			}
+			next := findCommand(app, strings.Join(append(path, w), " "))
+			if next == nil {
+				if i == 0 {
+					return fmt.Sprintf("unknown command %q", w)
+				}
+				break
+			}
+			cmd, path, flagsFrom = next, append(path, w), i+1
+		}
+	}
+	for _, w := range st.words[flagsFrom:] {
+		if !strings.HasPrefix(w, "-") || w == "-" || w == "--" {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if cmd != nil {
+			if !commandHasFlag(cmd, name) {
+				return fmt.Sprintf("%s: unknown flag %s", strings.Join(path, " "), w)
+			}
+			continue
+		}
+		if !appHasFlag(app.Commands, name) {
+			return fmt.Sprintf("unknown flag %s", w)
+		}
+	}
+	return ""
+}
+
+func appHasFlag(cmds []*cli.Command, name string) bool {
+	for _, cmd := range cmds {
+		if commandHasFlag(cmd, name) || appHasFlag(cmd.Subcommands, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellWords splits s on unquoted whitespace, dropping quotes, a trailing
+// line-continuation backslash, and anything after an unquoted #.
+func shellWords(s string) []string {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			return words
+		case r == '\\':
+			// line continuation
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words
+}
@@ -0,0 +1,61 @@
+package gqlcli
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// fullApp registers every gqlcli command, as cmd/gqlcli does with history enabled.
+func fullApp(t *testing.T) *cli.App {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: "http://localhost:8080/graphql", Format: "toon", CacheDir: t.TempDir(), ReleaseRepo: "wricardo/gqlcli"}
+	app := &cli.App{Name: "gqlcli", Version: "1.2.3"}
+	NewCLIBuilder(cfg).WithHistoryFile(t.TempDir() + "/history.jsonl").RegisterCommands(app)
+	return app
+}
+
+func TestSkillFeaturesAreInCatalog(t *testing.T) {
+	app := fullApp(t)
+	formats := NewFormatterRegistry()
+	content, problems := renderSkill(app, formats)
+	if len(problems) > 0 {
+		t.Errorf("skill refers to missing commands or flags:\n  %s", strings.Join(problems, "\n  "))
+	}
+	skill := string(content)
+	assertContains(t, skill, "Generated for gqlcli 1.2.3")
+
+	// Formats named in the examples are registered.
+	for _, m := range regexp.MustCompile(`(?:-f|--format) ([a-z-]+)`).FindAllStringSubmatch(string(skillMD), -1) {
+		if _, err := formats.Get(m[1]); err != nil {
+			t.Errorf("skill uses format %q: %v", m[1], err)
+		}
+	}
+
+	// The command reference lists every command in the catalog.
+	for _, cmd := range BuildCatalog(app).Commands {
+		if !strings.Contains(skill, "- `gqlcli "+cmd.Path+"`") {
+			t.Errorf("command reference lacks %q", cmd.Path)
+		}
+	}
+}
+
+func TestSkillDropsMissingCommands(t *testing.T) {
+	app := &cli.App{Name: "gqlcli"}
+	b := NewCLIBuilder(&Config{Format: "toon"})
+	app.Commands = []*cli.Command{b.GetQueryCommand(), b.GetInstallSkillCommand()}
+
+	content, problems := renderSkill(app, NewFormatterRegistry())
+	skill := string(content)
+	if len(problems) == 0 {
+		t.Fatal("expected problems for the commands this app lacks")
+	}
+	assertContains(t, strings.Join(problems, "\n"), `unknown command "queries"`, `unknown command "mutation"`)
+	if strings.Contains(skill, "gqlcli queries --filter") || strings.Contains(skill, "gqlcli mutation \\") {
+		t.Errorf("lines for missing commands were kept:\n%s", skill)
+	}
+	assertContains(t, skill, "gqlcli query --query-file ./getUser.graphql --variables-file ./vars.json")
+}
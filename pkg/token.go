@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims holds JWT token claims parsed from a saved token.
@@ -19,7 +20,8 @@ type Claims struct {
 }
 
 // TokenStore persists a JWT token on disk.
-// Tokens are stored at {dir}/token with 0600 permissions.
+// Tokens are stored at {dir}/token with 0600 permissions, next to the device ID
+// returned by DeviceID.
 // Create with NewTokenStore (uses ~/.{appName}/token) or NewTokenStoreAt for a custom path.
 type TokenStore struct {
 	dir string
@@ -80,6 +82,49 @@ func (s *TokenStore) Exists() bool {
 	return err == nil
 }
 
+// DeviceID returns a stable identifier for this machine, stored at {dir}/device_id.
+// A random UUID is generated on first use; unlike the token it survives Clear,
+// so the backend keeps recognising the device across logins.
+func (s *TokenStore) DeviceID() (string, error) {
+	path := filepath.Join(s.dir, "device_id")
+	if data, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read device id: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create token directory: %w", err)
+	}
+	// Write to a temporary file and link it into place so concurrent first runs
+	// agree on one ID: the loser of the race reads the winner's file.
+	id := uuid.NewString()
+	tmp, err := os.CreateTemp(s.dir, "device_id.*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write device id: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(id)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write device id: %w", err)
+	}
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if data, rerr := os.ReadFile(path); rerr == nil && strings.TrimSpace(string(data)) != "" {
+			return strings.TrimSpace(string(data)), nil
+		}
+		return "", fmt.Errorf("failed to write device id: %w", err)
+	}
+	return id, nil
+}
+
 // ParseClaims parses JWT claims from a token string without validating the signature.
 // This is safe for reading tokens that your own application issued and saved.
 func (s *TokenStore) ParseClaims(tokenString string) (*Claims, error) {
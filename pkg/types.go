@@ -1,6 +1,9 @@
 package gqlcli
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Config holds the CLI configuration
 type Config struct {
@@ -12,9 +15,57 @@ type Config struct {
 	Token string // Bearer token for requests
 	Auth  AuthConfig
 
+	// OnUnauthorized is called when the server answers with an UNAUTHENTICATED
+	// error code. It returns a fresh token, and the operation is retried once
+	// with it. See RegisterErrorCodeHandler.
+	OnUnauthorized func(ctx context.Context) (string, error)
+
 	// HTTP client settings
 	Timeout int  // Request timeout in seconds (default: 30)
 	Debug   bool // Enable debug logging (logs requests/responses)
+
+	// HintTimeout bounds the schema hint lookups made for one failed response
+	// (default: DefaultHintTimeout). A negative value disables hints.
+	HintTimeout time.Duration
+
+	// RetryMutations lets error-code handlers re-send a mutation whose response
+	// carried data (it may already have run in part). Mutations that came back
+	// without data are retried either way.
+	RetryMutations bool
+
+	// PersistedQueries sends each operation as an automatic persisted query:
+	// its SHA-256 hash first, and the full text only if the server answers
+	// PERSISTED_QUERY_NOT_FOUND.
+	PersistedQueries bool
+
+	// FollowRedirects re-sends mutations to the location an endpoint redirects
+	// to. Queries are always re-sent (with a warning); without this, a
+	// redirected mutation fails with an error naming the new location.
+	FollowRedirects bool
+
+	// ReadOnly refuses to send mutations: the mutation command is hidden and
+	// disabled, and every operation is parsed before it is sent. ReadOnlySource
+	// names what enabled it (e.g. a profile) in the resulting errors.
+	ReadOnly       bool
+	ReadOnlySource string
+
+	// DryRunField is the variable or input field the mutation command's
+	// --server-dry-run sets to true (default: "dryRun").
+	DryRunField string
+
+	// CacheDir is where introspection results are cached (default: ~/.gqlcli/cache)
+	CacheDir string
+	// CacheTTL is how long error hints and other type lookups trust entries
+	// in CacheDir (default: DefaultCacheTTL). A negative value ignores the cache.
+	CacheTTL time.Duration
+
+	// ReleaseRepo is the GitHub repository ("owner/name") self-update installs
+	// releases from. The command is only registered when it is set, so apps
+	// built on this package never replace themselves with another binary.
+	ReleaseRepo string
+	// ReleasePublicKey is a base64 ed25519 public key. When set, self-update
+	// also requires a valid signature of the release's checksum file.
+	ReleasePublicKey string
 }
 
 // AuthConfig holds authentication configuration
@@ -41,9 +92,10 @@ type MutationOptions struct {
 
 // GraphQLRequest is the standard GraphQL request format
 type GraphQLRequest struct {
-	Query         string                 `json:"query"`
+	Query         string                 `json:"query,omitempty"`
 	Variables     map[string]interface{} `json:"variables,omitempty"`
 	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
 }
 
 // GraphQLResponse is the standard GraphQL response format
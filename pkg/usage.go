@@ -0,0 +1,275 @@
+package gqlcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// UsageEvent describes one command invocation. It never carries flag values,
+// variables, or operation text — only names — so it is safe to log.
+type UsageEvent struct {
+	Time time.Time
+	// Command is the full command path, e.g. "query" or "endpoints list".
+	Command string
+	// Operation is the GraphQL operation name, or the first root field for
+	// anonymous operations. Empty for commands that don't run an operation.
+	Operation string
+	// OperationType is "query", "mutation", or "subscription", when known.
+	OperationType string
+	// Endpoint is the URL the operation was sent to, without credentials or
+	// query string; empty for inline commands.
+	Endpoint string
+	Duration time.Duration
+	Success  bool
+	// ErrorClass is empty on success, otherwise one of "graphql" (the response
+	// contained errors), "canceled", "exit" (the command exited non-zero), or "error".
+	ErrorClass string
+	// Flags lists the names of the flags that were set, sorted as declared.
+	Flags []string
+	// DryRun is set when the mutation was sent with --server-dry-run, asking
+	// the server not to persist anything.
+	DryRun bool
+}
+
+// WithUsageRecorder calls fn after every command with a UsageEvent. Storage and
+// transport are left to fn; see NewJSONLUsageRecorder for a local file recorder.
+// Without this option no events are built.
+func WithUsageRecorder(fn func(event UsageEvent)) CommandSetOption {
+	return func(cs *InlineCommandSet) { cs.usage = fn }
+}
+
+// WithUsageRecorder calls fn after every command registered by RegisterCommands.
+// See the CommandSetOption of the same name.
+func (b *CLIBuilder) WithUsageRecorder(fn func(event UsageEvent)) *CLIBuilder {
+	b.usage = fn
+	return b
+}
+
+// WithHistoryFile records every command to the JSONL log at path (see
+// NewJSONLUsageRecorder), alongside any WithUsageRecorder function, and adds
+// a history command that reads it back.
+func WithHistoryFile(path string) CommandSetOption {
+	return func(cs *InlineCommandSet) { cs.history = path }
+}
+
+// WithHistoryFile records every command registered by RegisterCommands to
+// path and adds the history command. See the CommandSetOption of the same name.
+func (b *CLIBuilder) WithHistoryFile(path string) *CLIBuilder {
+	b.history = path
+	return b
+}
+
+// usageRecorders returns a recorder calling fn and then, if historyPath is
+// set, the JSONL recorder for it; nil when there is neither.
+func usageRecorders(fn func(UsageEvent), historyPath string) func(UsageEvent) {
+	if historyPath == "" {
+		return fn
+	}
+	history := NewJSONLUsageRecorder(historyPath)
+	if fn == nil {
+		return history
+	}
+	return func(event UsageEvent) {
+		fn(event)
+		history(event)
+	}
+}
+
+// NewJSONLUsageRecorder returns a recorder that appends each event as a JSON
+// line to path, creating the file and its directory as needed. Each line is a
+// single O_APPEND write, so several processes can share one file without
+// interleaving records. Write failures are ignored so recording can never
+// break a command.
+func NewJSONLUsageRecorder(path string) func(UsageEvent) {
+	var mu sync.Mutex
+	return func(event UsageEvent) {
+		line, err := json.Marshal(map[string]interface{}{
+			"time":          event.Time.UTC().Format(time.RFC3339Nano),
+			"command":       event.Command,
+			"operation":     event.Operation,
+			"operationType": event.OperationType,
+			"endpoint":      event.Endpoint,
+			"durationMs":    event.Duration.Milliseconds(),
+			"success":       event.Success,
+			"errorClass":    event.ErrorClass,
+			"flags":         event.Flags,
+			"dryRun":        event.DryRun,
+		})
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		_, _ = f.Write(append(line, '\n'))
+	}
+}
+
+// usageState collects what an action learns about its invocation (the
+// operation, a GraphQL error that was printed rather than returned).
+type usageState struct {
+	operation     string
+	operationType string
+	endpoint      string
+	errorClass    string
+	dryRun        bool
+}
+
+type usageStateKey struct{}
+
+// noteUsageOperation records the operation run by the current command.
+// It is a no-op when no usage recorder is installed.
+func noteUsageOperation(c *cli.Context, query, operationName string) {
+	if st, ok := c.Context.Value(usageStateKey{}).(*usageState); ok {
+		st.operation, st.operationType = operationLabel(query, operationName)
+	}
+}
+
+// noteUsageEndpoint records the endpoint the current command talks to,
+// without credentials, query string, or fragment, which may carry tokens.
+func noteUsageEndpoint(c *cli.Context, endpoint string) {
+	if st, ok := c.Context.Value(usageStateKey{}).(*usageState); ok {
+		if u, err := url.Parse(endpoint); err == nil {
+			u.User, u.RawQuery, u.Fragment, u.RawFragment = nil, "", "", ""
+			endpoint = u.String()
+		} else {
+			endpoint = ""
+		}
+		st.endpoint = endpoint
+	}
+}
+
+// noteUsageDryRun records that the current command's mutation was a server
+// dry run.
+func noteUsageDryRun(c *cli.Context) {
+	if st, ok := c.Context.Value(usageStateKey{}).(*usageState); ok {
+		st.dryRun = true
+	}
+}
+
+// noteUsageError records an error class for a failure the command reports
+// without returning an error, such as GraphQL errors in the response.
+func noteUsageError(c *cli.Context, class string) {
+	if st, ok := c.Context.Value(usageStateKey{}).(*usageState); ok {
+		st.errorClass = class
+	}
+}
+
+// operationLabel returns operationName, else the name of the first operation
+// in query, else the first root field of an anonymous operation, along with
+// the operation's type. Both are empty when query doesn't parse.
+func operationLabel(query, operationName string) (label, opType string) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return operationName, ""
+	}
+	op := doc.Operations[0]
+	if operationName != "" {
+		if named := doc.Operations.ForName(operationName); named != nil {
+			op = named
+		}
+		return operationName, string(op.Operation)
+	}
+	if op.Name != "" {
+		return op.Name, string(op.Operation)
+	}
+	for _, sel := range op.SelectionSet {
+		if f, ok := sel.(*ast.Field); ok {
+			return f.Name, string(op.Operation)
+		}
+	}
+	return "", string(op.Operation)
+}
+
+// withUsage wraps the actions of cmds and their subcommands so fn receives a
+// UsageEvent after each run. A nil fn leaves the commands untouched.
+func withUsage(cmds []*cli.Command, fn func(UsageEvent)) []*cli.Command {
+	if fn == nil {
+		return cmds
+	}
+	for _, cmd := range cmds {
+		withUsage(cmd.Subcommands, fn)
+		if cmd.Action == nil {
+			continue
+		}
+		action := cmd.Action
+		cmd.Action = func(c *cli.Context) error {
+			st := &usageState{}
+			c.Context = context.WithValue(c.Context, usageStateKey{}, st)
+			start := time.Now()
+			err := action(c)
+
+			event := UsageEvent{
+				Time:          start,
+				Command:       commandPath(c),
+				Operation:     st.operation,
+				OperationType: st.operationType,
+				Endpoint:      st.endpoint,
+				Duration:      time.Since(start),
+				ErrorClass:    st.errorClass,
+				Flags:         setFlagNames(c),
+				DryRun:        st.dryRun,
+			}
+			if event.ErrorClass == "" {
+				event.ErrorClass = usageErrorClass(err)
+			}
+			event.Success = event.ErrorClass == ""
+			fn(event)
+			return err
+		}
+	}
+	return cmds
+}
+
+// commandPath returns the running command's path without the app name,
+// e.g. "endpoints list".
+func commandPath(c *cli.Context) string {
+	return strings.TrimPrefix(c.Command.HelpName, c.App.Name+" ")
+}
+
+// setFlagNames returns the primary names of the flags set on the command line
+// or through their environment variables.
+func setFlagNames(c *cli.Context) []string {
+	names := []string{}
+	for _, f := range c.Command.Flags {
+		if name := f.Names()[0]; c.IsSet(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func usageErrorClass(err error) string {
+	var gqlErr *GraphQLResponseError
+	var exit cli.ExitCoder
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &gqlErr):
+		return "graphql"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.As(err, &exit):
+		return "exit"
+	default:
+		return "error"
+	}
+}
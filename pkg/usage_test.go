@@ -0,0 +1,100 @@
+package gqlcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestUsageEventsCarryNoValues(t *testing.T) {
+	const secret = "s3cr3t-value"
+	var events []UsageEvent
+	record := func(e UsageEvent) { events = append(events, e) }
+	history := filepath.Join(t.TempDir(), "history.jsonl")
+
+	inline, _ := inlineApp(t,
+		WithLogin(testLoginConfig(NewTokenStoreAt(t.TempDir()))),
+		WithUsageRecorder(record),
+		WithHistoryFile(history),
+	)
+	inlineRuns := [][]string{
+		{"login", "--email", "ada@example.com", "--password", secret},
+		// Succeeds; the saved token is "token-ada@example.com".
+		{"login", "--email", "ada@example.com", "--password", "secret"},
+		{"whoami"},
+		{"query", "--variables", `{"id":"` + secret + `"}`, "query Book($id: ID!) { book(id: $id) { id } }"},
+		{"query", "--var", "id=" + secret, "query($id: ID!) { book(id: $id) { id } }"},
+		{"query", `{ book(id: "` + secret + `") { id } }`},
+		{"mutation", `mutation { addBook(input: {title: "` + secret + `", authorName: "x"}) { id } }`},
+	}
+	for _, args := range inlineRuns {
+		runApp(t, inline, args...)
+	}
+
+	ts := newTestServer(t)
+	t.Setenv("HOME", t.TempDir())
+	host := strings.TrimPrefix(ts.URL, "http://")
+	cfg := &Config{URL: "http://user:" + secret + "@" + host + "/?key=" + secret, Format: "json", CacheDir: t.TempDir()}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).WithUsageRecorder(record).WithHistoryFile(history).RegisterCommands(app)
+	httpRuns := [][]string{
+		{"query", "--variables", `{"id":"` + secret + `"}`, "query Book($id: ID!) { book(id: $id) { id } }"},
+		{"query", "--url", ts.URL + "/#" + secret, "--var", "id=" + secret, "query($id: ID!) { book(id: $id) { id } }"},
+		{"mutation", `mutation { addBook(input: {title: "` + secret + `", authorName: "x"}) { id } }`},
+	}
+	for _, args := range httpRuns {
+		runApp(t, app, args...)
+	}
+
+	if len(events) != len(inlineRuns)+len(httpRuns) {
+		t.Fatalf("got %d events, want %d", len(events), len(inlineRuns)+len(httpRuns))
+	}
+	logged, err := os.ReadFile(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sensitive := range []string{secret, "token-ada", "ada@example.com"} {
+		for i, e := range events {
+			if dump := fmt.Sprintf("%#v", e); strings.Contains(dump, sensitive) {
+				t.Errorf("event %d carries %q: %s", i, sensitive, dump)
+			}
+		}
+		if strings.Contains(string(logged), sensitive) {
+			t.Errorf("history log carries %q:\n%s", sensitive, logged)
+		}
+	}
+
+	// Names are still recorded.
+	if got := events[0].Flags; !reflect.DeepEqual(got, []string{"email", "password"}) {
+		t.Errorf("login flags = %v", got)
+	}
+	if got := events[3].Operation; got != "Book" {
+		t.Errorf("operation = %q, want Book", got)
+	}
+	if got := events[len(inlineRuns)].Endpoint; got != "http://"+host+"/" {
+		t.Errorf("endpoint = %q, want it without credentials or query", got)
+	}
+}
+
+func TestWithoutUsageRecorderIsNoOp(t *testing.T) {
+	action := func(*cli.Context) error { return nil }
+	cmds := []*cli.Command{{Name: "x", Action: action, Subcommands: []*cli.Command{{Name: "y", Action: action}}}}
+	withUsage(cmds, nil)
+	for _, cmd := range []*cli.Command{cmds[0], cmds[0].Subcommands[0]} {
+		if reflect.ValueOf(cmd.Action).Pointer() != reflect.ValueOf(action).Pointer() {
+			t.Errorf("%s was wrapped without a recorder", cmd.Name)
+		}
+	}
+
+	app, _ := inlineApp(t)
+	for _, cmd := range app.Commands {
+		if cmd.Name == "history" {
+			t.Error("history command mounted without WithHistoryFile")
+		}
+	}
+}
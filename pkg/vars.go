@@ -0,0 +1,344 @@
+package gqlcli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// varList collects repeated --var and --var-file-lines flags. Unlike
+// cli.StringSliceFlag it does not split values on commas.
+type varList []string
+
+func (v *varList) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func (v *varList) String() string { return strings.Join(*v, " ") }
+
+// varFlags are the --var and --var-file-lines flags shared by the HTTP and
+// inline operation commands.
+func varFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.GenericFlag{
+			Name:  "var",
+			Usage: "Set one variable as NAME=VALUE, coerced to its declared type; repeat for list variables",
+			Value: &varList{},
+		},
+		&cli.GenericFlag{
+			Name:  "var-file-lines",
+			Usage: "Set a list variable as NAME=PATH, one element per line (blank lines and # comments skipped)",
+			Value: &varList{},
+		},
+		&cli.BoolFlag{
+			Name:  "strict-variables",
+			Usage: "Fail instead of warning when variables are not declared by the operation or required ones are missing",
+		},
+		&cli.BoolFlag{
+			Name:  "no-variable-check",
+			Usage: "Skip comparing variables with the operation's declarations (for servers that inject variables of their own)",
+		},
+	}
+}
+
+// checkVariables compares vars with the variables declared by the operation
+// and reports provided keys it doesn't declare, with the closest declared
+// name when one is likely meant (e.g. "ID" for $id), and required variables
+// that are missing, with their types. Problems are printed as warnings, or
+// returned as an error with --strict-variables. Nothing is checked with
+// --no-variable-check or when query does not parse.
+func checkVariables(c *cli.Context, vars map[string]interface{}, query, operationName string) error {
+	if c.Bool("no-variable-check") {
+		return nil
+	}
+	defs, ok := variableDefinitions(query, operationName)
+	if !ok {
+		return nil
+	}
+	problems := variableProblems(defs, vars)
+	if len(problems) == 0 {
+		return nil
+	}
+	if c.Bool("strict-variables") {
+		return fmt.Errorf("variables don't match the operation (--strict-variables):\n  %s", strings.Join(problems, "\n  "))
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, "warning: "+p)
+	}
+	return nil
+}
+
+func variableProblems(defs ast.VariableDefinitionList, vars map[string]interface{}) []string {
+	var problems []string
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if defs.ForName(name) != nil {
+			continue
+		}
+		p := fmt.Sprintf("variable %q is not declared by the operation and will be ignored", name)
+		if v := closestVariable(defs, name); v != nil {
+			p += fmt.Sprintf("; did you mean $%s (%s)?", v.Variable, v.Type.String())
+		}
+		problems = append(problems, p)
+	}
+	for _, v := range defs {
+		if !v.Type.NonNull || v.DefaultValue != nil {
+			continue
+		}
+		if _, ok := vars[v.Variable]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required variable $%s: %s", v.Variable, v.Type.String()))
+		}
+	}
+	return problems
+}
+
+// closestVariable returns the declared variable name is likely a typo of: a
+// case-insensitive match, else the nearest one within a small edit distance.
+// It returns nil when none is close enough.
+func closestVariable(defs ast.VariableDefinitionList, name string) *ast.VariableDefinition {
+	for _, v := range defs {
+		if strings.EqualFold(v.Variable, name) {
+			return v
+		}
+	}
+	var best *ast.VariableDefinition
+	maxDist := min(len(name)/3+1, 3)
+	for _, v := range defs {
+		if d := editDistance(strings.ToLower(v.Variable), strings.ToLower(name)); d <= maxDist {
+			best, maxDist = v, d-1
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// applyVarFlags merges --var and --var-file-lines into vars (the result of
+// --variables or --variables-file, possibly nil). Values are coerced to the
+// type declared for the variable in query. For list-typed variables each flag
+// appends an element, including to a list or single value given in
+// --variables; otherwise a later value replaces an earlier one. Variables not declared in the operation
+// are list-typed only if their current value is already a list.
+func applyVarFlags(c *cli.Context, vars map[string]interface{}, query, operationName string) (map[string]interface{}, error) {
+	assignments, _ := c.Generic("var").(*varList)
+	fileLines, _ := c.Generic("var-file-lines").(*varList)
+	if (assignments == nil || len(*assignments) == 0) && (fileLines == nil || len(*fileLines) == 0) {
+		return vars, nil
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	decls := declaredVariables(query, operationName)
+
+	if assignments != nil {
+		for _, a := range *assignments {
+			name, raw, ok := strings.Cut(a, "=")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("--var %q: expected NAME=VALUE", a)
+			}
+			decl := decls[name]
+			value, err := coerceVarValue(raw, decl)
+			if err != nil {
+				return nil, fmt.Errorf("--var %s: %w", name, err)
+			}
+			if isListVar(decl, vars[name]) {
+				vars[name] = append(asList(vars[name]), value)
+			} else {
+				vars[name] = value
+			}
+		}
+	}
+
+	if fileLines != nil {
+		for _, a := range *fileLines {
+			name, path, ok := strings.Cut(a, "=")
+			if !ok || name == "" || path == "" {
+				return nil, fmt.Errorf("--var-file-lines %q: expected NAME=PATH", a)
+			}
+			decl := decls[name]
+			if decl != nil && decl.Elem == nil {
+				return nil, fmt.Errorf("--var-file-lines %s: variable is declared as %s, not a list", name, decl.String())
+			}
+			lines, err := readVarLines(path)
+			if err != nil {
+				return nil, fmt.Errorf("--var-file-lines %s: %w", name, err)
+			}
+			list := asList(vars[name])
+			for i, line := range lines {
+				value, err := coerceVarValue(line, decl)
+				if err != nil {
+					return nil, fmt.Errorf("--var-file-lines %s: line %d: %w", name, i+1, err)
+				}
+				list = append(list, value)
+			}
+			if list == nil {
+				list = []interface{}{}
+			}
+			vars[name] = list
+		}
+	}
+	return vars, nil
+}
+
+// declaredVariables returns the variable types declared by the named operation
+// in query (or its first operation). It returns nil when query does not parse,
+// in which case values are passed as strings.
+func declaredVariables(query, operationName string) map[string]*ast.Type {
+	defs, ok := variableDefinitions(query, operationName)
+	if !ok {
+		return nil
+	}
+	decls := make(map[string]*ast.Type, len(defs))
+	for _, v := range defs {
+		decls[v.Variable] = v.Type
+	}
+	return decls
+}
+
+// variableDefinitions returns the variable definitions of the named operation
+// in query (or its first operation), and false when query does not parse.
+func variableDefinitions(query, operationName string) (ast.VariableDefinitionList, bool) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return nil, false
+	}
+	op := doc.Operations[0]
+	if operationName != "" {
+		if named := doc.Operations.ForName(operationName); named != nil {
+			op = named
+		}
+	}
+	return op.VariableDefinitions, true
+}
+
+// asList returns the list that flags for a list-typed variable append to: a
+// single value from --variables becomes its first element, as GraphQL input
+// coercion would treat it.
+func asList(current interface{}) []interface{} {
+	switch v := current.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func isListVar(decl *ast.Type, current interface{}) bool {
+	if decl != nil {
+		return decl.Elem != nil
+	}
+	_, ok := current.([]interface{})
+	return ok
+}
+
+// jsonInt and jsonNumber are JSON's number grammar. Values are sent with the
+// digits given, so the strconv parsers' extra syntax (+5, 1_000, 0x1p-2, NaN,
+// Inf) is refused rather than passed on as invalid JSON.
+var (
+	jsonInt    = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// coerceVarValue converts raw to the named type inside decl (its element type
+// for lists). Built-in scalars are parsed; other types accept JSON objects and
+// arrays and are otherwise passed as strings, which also covers enums.
+func coerceVarValue(raw string, decl *ast.Type) (interface{}, error) {
+	named := ""
+	for t := decl; t != nil; t = t.Elem {
+		named = t.NamedType
+	}
+	switch named {
+	case "String", "ID", "":
+		return raw, nil
+	case "Int":
+		// GraphQL's Int is a signed 32-bit integer; larger ones need a custom scalar.
+		if !jsonInt.MatchString(raw) {
+			return nil, fmt.Errorf("%q is not an Int", raw)
+		}
+		if _, err := strconv.ParseInt(raw, 10, 32); errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("%s is out of range for Int (32-bit)", raw)
+		} else if err != nil {
+			return nil, fmt.Errorf("%q is not an Int", raw)
+		}
+		return json.Number(raw), nil
+	case "Float":
+		if !jsonNumber.MatchString(raw) {
+			return nil, fmt.Errorf("%q is not a Float", raw)
+		}
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%s is out of range for Float", raw)
+		}
+		return json.Number(raw), nil
+	case "Boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a Boolean", raw)
+		}
+		return b, nil
+	default:
+		if trimmed := strings.TrimSpace(raw); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var v interface{}
+			if err := decodeJSON([]byte(trimmed), &v); err != nil {
+				return nil, fmt.Errorf("invalid JSON for %s: %w", named, err)
+			}
+			return v, nil
+		}
+		return raw, nil
+	}
+}
+
+// readVarLines returns the trimmed non-blank lines of path, skipping # comments.
+func readVarLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
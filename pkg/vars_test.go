@@ -0,0 +1,361 @@
+package gqlcli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// applyVars runs applyVarFlags over a copy of vars with the given --var and
+// --var-file-lines arguments.
+func applyVars(t *testing.T, query string, vars map[string]interface{}, args ...string) (map[string]interface{}, error) {
+	t.Helper()
+	var got map[string]interface{}
+	var applyErr error
+	app := &cli.App{Name: "t", Commands: []*cli.Command{{
+		Name:  "q",
+		Flags: varFlags(),
+		Action: func(c *cli.Context) error {
+			var in map[string]interface{}
+			if vars != nil {
+				in = map[string]interface{}{}
+				for k, v := range vars {
+					in[k] = v
+				}
+			}
+			got, applyErr = applyVarFlags(c, in, query, "")
+			return nil
+		},
+	}}}
+	if err := app.Run(append([]string{"t", "q"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	return got, applyErr
+}
+
+func TestVarFlagsPrecedence(t *testing.T) {
+	const listQuery = `mutation($ids: [ID!]!) { archiveBooks(ids: $ids) }`
+	const intQuery = `query($first: Int) { books(first: $first) { id } }`
+	lines := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(lines, []byte("b3\n# skipped\n\nb4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		args  []string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "--variables alone is untouched",
+			query: intQuery,
+			vars:  map[string]interface{}{"first": json.Number("1")},
+			want:  map[string]interface{}{"first": json.Number("1")},
+		},
+		{
+			name:  "--var replaces a scalar from --variables",
+			query: intQuery,
+			vars:  map[string]interface{}{"first": json.Number("1")},
+			args:  []string{"--var", "first=2"},
+			want:  map[string]interface{}{"first": json.Number("2")},
+		},
+		{
+			name:  "the last --var wins for a scalar",
+			query: intQuery,
+			args:  []string{"--var", "first=2", "--var", "first=3"},
+			want:  map[string]interface{}{"first": json.Number("3")},
+		},
+		{
+			name:  "--var appends to a list from --variables",
+			query: listQuery,
+			vars:  map[string]interface{}{"ids": []interface{}{"b1"}},
+			args:  []string{"--var", "ids=b2"},
+			want:  map[string]interface{}{"ids": []interface{}{"b1", "b2"}},
+		},
+		{
+			name:  "--var appends to a single value from --variables",
+			query: listQuery,
+			vars:  map[string]interface{}{"ids": "b1"},
+			args:  []string{"--var", "ids=b2"},
+			want:  map[string]interface{}{"ids": []interface{}{"b1", "b2"}},
+		},
+		{
+			name:  "--var-file-lines appends after --var",
+			query: listQuery,
+			vars:  map[string]interface{}{"ids": "b1"},
+			args:  []string{"--var-file-lines", "ids=" + lines, "--var", "ids=b2"},
+			want:  map[string]interface{}{"ids": []interface{}{"b1", "b2", "b3", "b4"}},
+		},
+		{
+			name:  "undeclared variables append only to a list",
+			query: "not graphql",
+			vars:  map[string]interface{}{"tags": []interface{}{"a"}, "name": "x"},
+			args:  []string{"--var", "tags=b", "--var", "name=y"},
+			want:  map[string]interface{}{"tags": []interface{}{"a", "b"}, "name": "y"},
+		},
+		{
+			name:  "Int bounds",
+			query: `query($a: Int, $b: Int) { books(first: $a) { id } }`,
+			args:  []string{"--var", "a=2147483647", "--var", "b=-2147483648"},
+			want:  map[string]interface{}{"a": json.Number("2147483647"), "b": json.Number("-2147483648")},
+		},
+		{
+			name:  "Float keeps the digits given",
+			query: `query($a: Float, $b: Float, $c: Float) { books { id } }`,
+			args:  []string{"--var", "a=0.10000000000000000001", "--var", "b=-1.5E+3", "--var", "c=7"},
+			want:  map[string]interface{}{"a": json.Number("0.10000000000000000001"), "b": json.Number("-1.5E+3"), "c": json.Number("7")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyVars(t, tt.query, tt.vars, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVarFlagsErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		args  []string
+		want  string
+	}{
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=2147483648"}, "out of range for Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=-2147483649"}, "out of range for Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=1.5"}, "is not an Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=+5"}, "is not an Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=007"}, "is not an Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=1_000"}, "is not an Int"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n=0x10"}, "is not an Int"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=x"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=+5"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=NaN"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=Inf"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=-Infinity"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=0x1p-2"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=1_000"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=.5"}, "is not a Float"},
+		{`query($f: Float) { books { id } }`, []string{"--var", "f=1e400"}, "out of range for Float"},
+		{`query($b: Boolean) { books { id } }`, []string{"--var", "b=maybe"}, "is not a Boolean"},
+		{`query($n: Int) { books { id } }`, []string{"--var", "n"}, "expected NAME=VALUE"},
+		{`query($n: Int) { books { id } }`, []string{"--var-file-lines", "n=ids.txt"}, "not a list"},
+	}
+	for _, tt := range tests {
+		_, err := applyVars(t, tt.query, nil, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got %v, want an error containing %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestVarFlagsReachServer checks a single --variables value and --var
+// elements arrive as one list.
+func TestVarFlagsReachServer(t *testing.T) {
+	ts := newTestServer(t)
+	for label, app := range map[string]*cli.App{"http": httpApp(t, ts.URL), "inline": func() *cli.App { a, _ := inlineApp(t); return a }()} {
+		stdout, _, err := runApp(t, app, "mutation", "--variables", `{"ids": "b1"}`, "--var", "ids=b2",
+			`mutation($ids: [ID!]!) { archiveBooks(ids: $ids) }`)
+		if err != nil {
+			t.Fatalf("%s: %v", label, err)
+		}
+		assertContains(t, stdout, `"archiveBooks":2`)
+	}
+}
+
+func TestVariableProblems(t *testing.T) {
+	const op = `query($id: ID!, $first: Int = 10, $genre: Genre, $title: String!) { books { id } }`
+	tests := []struct {
+		name string
+		vars map[string]interface{}
+		want []string
+	}{
+		{
+			name: "all declared",
+			vars: map[string]interface{}{"id": "b1", "title": "x", "genre": "SCIENCE"},
+		},
+		{
+			name: "required with a default may be omitted, null counts as given",
+			vars: map[string]interface{}{"id": nil, "title": "x"},
+		},
+		{
+			name: "case mismatch",
+			vars: map[string]interface{}{"ID": "b1", "title": "x"},
+			want: []string{
+				`variable "ID" is not declared by the operation and will be ignored; did you mean $id (ID!)?`,
+				"missing required variable $id: ID!",
+			},
+		},
+		{
+			name: "typo within the edit distance",
+			vars: map[string]interface{}{"id": "b1", "titel": "x", "frist": 2},
+			want: []string{
+				`variable "frist" is not declared by the operation and will be ignored; did you mean $first (Int)?`,
+				`variable "titel" is not declared by the operation and will be ignored; did you mean $title (String!)?`,
+				"missing required variable $title: String!",
+			},
+		},
+		{
+			name: "unrelated names get no suggestion",
+			vars: map[string]interface{}{"id": "b1", "title": "x", "limit": 5},
+			want: []string{`variable "limit" is not declared by the operation and will be ignored`},
+		},
+		{
+			name: "nothing given",
+			want: []string{"missing required variable $id: ID!", "missing required variable $title: String!"},
+		},
+	}
+	defs, ok := variableDefinitions(op, "")
+	if !ok {
+		t.Fatal("operation did not parse")
+	}
+	for _, tt := range tests {
+		if got := variableProblems(defs, tt.vars); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClosestVariable(t *testing.T) {
+	defs, _ := variableDefinitions(`query($id: ID, $bookId: ID, $author: String, $authorName: String) { books { id } }`, "")
+	tests := map[string]string{
+		"ID":          "id",
+		"BOOKID":      "bookId",
+		"bookid":      "bookId",
+		"book_id":     "bookId",
+		"autor":       "author",
+		"authorNme":   "authorName",
+		"x":           "",
+		"publisherId": "",
+	}
+	for name, want := range tests {
+		got := ""
+		if v := closestVariable(defs, name); v != nil {
+			got = v.Variable
+		}
+		if got != want {
+			t.Errorf("closestVariable(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// runCheckVariables runs checkVariables for query and vars with the given
+// flags and returns the warnings it printed and its error.
+func runCheckVariables(t *testing.T, query, operationName string, vars map[string]interface{}, args ...string) (string, error) {
+	t.Helper()
+	var checkErr error
+	app := &cli.App{Name: "t", Commands: []*cli.Command{{
+		Name:  "q",
+		Flags: varFlags(),
+		Action: func(c *cli.Context) error {
+			checkErr = checkVariables(c, vars, query, operationName)
+			return nil
+		},
+	}}}
+	_, stderr := captureOutput(t, func() {
+		if err := app.Run(append([]string{"t", "q"}, args...)); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return stderr, checkErr
+}
+
+func TestCheckVariables(t *testing.T) {
+	const query = `query Book($id: ID!) { book(id: $id) { title } }
+query Books($first: Int) { books(first: $first) { title } }`
+	typo := map[string]interface{}{"Id": "b1"}
+
+	tests := []struct {
+		name         string
+		query        string // default: query
+		vars         map[string]interface{}
+		operation    string
+		args         []string
+		wantErr      string
+		wantWarnings []string
+	}{
+		{name: "matching variables", vars: map[string]interface{}{"id": "b1"}},
+		{
+			name: "warnings by default",
+			vars: typo,
+			wantWarnings: []string{
+				`warning: variable "Id" is not declared by the operation and will be ignored; did you mean $id (ID!)?`,
+				"warning: missing required variable $id: ID!",
+			},
+		},
+		{
+			name:    "strict",
+			vars:    typo,
+			args:    []string{"--strict-variables"},
+			wantErr: "variables don't match the operation (--strict-variables):\n  variable \"Id\" is not declared",
+		},
+		{name: "no check", vars: typo, args: []string{"--no-variable-check", "--strict-variables"}},
+		{
+			name:         "the named operation's declarations",
+			vars:         map[string]interface{}{"id": "b1"},
+			operation:    "Books",
+			wantWarnings: []string{`warning: variable "id" is not declared by the operation and will be ignored`},
+		},
+		{name: "unparseable query", query: "{ books {", vars: typo, args: []string{"--strict-variables"}},
+	}
+	for _, tt := range tests {
+		q := tt.query
+		if q == "" {
+			q = query
+		}
+		stderr, err := runCheckVariables(t, q, tt.operation, tt.vars, tt.args...)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr != "" && stderr != "" {
+			t.Errorf("%s: warnings printed alongside the error:\n%s", tt.name, stderr)
+		}
+		if got := strings.Count(stderr, "warning: "); got != len(tt.wantWarnings) {
+			t.Errorf("%s: got %d warnings, want %d:\n%s", tt.name, got, len(tt.wantWarnings), stderr)
+		}
+		assertContains(t, stderr, tt.wantWarnings...)
+	}
+}
+
+// TestCheckVariablesBeforeSending checks the operation commands compare
+// variables before anything is sent, so --strict-variables sends nothing.
+func TestCheckVariablesBeforeSending(t *testing.T) {
+	ts := newTestServer(t)
+	const query = `query($id: ID!) { book(id: $id) { title } }`
+
+	_, stderr, err := runApp(t, httpApp(t, ts.URL), "query", "--variables", `{"ID": "b1"}`, query)
+	if err == nil {
+		t.Fatal("expected the server to reject the missing $id")
+	}
+	assertContains(t, stderr, `warning: variable "ID" is not declared by the operation and will be ignored; did you mean $id (ID!)?`)
+
+	// The endpoint check may introspect first, so count books rather than
+	// requests to see that the mutation was not sent.
+	before := bookCount(t, ts)
+	_, _, err = runApp(t, httpApp(t, ts.URL), "mutation", "--strict-variables", "--variables", `{"Input": {"title": "T", "authorName": "A"}}`,
+		`mutation($input: AddBookInput!) { addBook(input: $input) { id } }`)
+	if err == nil || !strings.Contains(err.Error(), "did you mean $input (AddBookInput!)?") {
+		t.Fatalf("got %v, want a strict variables error", err)
+	}
+	if got := bookCount(t, ts); got != before {
+		t.Errorf("the mutation was sent despite --strict-variables: %d books, want %d", got, before)
+	}
+
+	app, _ := inlineApp(t)
+	_, stderr, _ = runApp(t, app, "query", "--variables", `{"ID": "b1"}`, query)
+	assertContains(t, stderr, "did you mean $id (ID!)?")
+}
@@ -0,0 +1,222 @@
+package gqlcli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+)
+
+// GetWarmCommand returns the warm command, which pre-populates the schema cache.
+func (b *CLIBuilder) GetWarmCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "warm",
+		Usage: "Populate the on-disk schema cache for offline use",
+		Description: "Introspects the endpoint and caches the schema, root type names, and scalar examples. " +
+			"Use --types or --all-types to also cache describe entries. The cache is read back to verify it; " +
+			"the command exits non-zero if any entry is missing. --offline only validates an existing cache.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "url",
+				Aliases: []string{"u"},
+				Usage:   "GraphQL endpoint URL (env: GRAPHQL_URL)",
+				Value:   b.config.URL,
+				EnvVars: []string{"GRAPHQL_URL"},
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				Aliases: []string{"d"},
+				Usage:   "Enable debug mode (logs HTTP requests/responses)",
+				Value:   b.config.Debug,
+			},
+			&cli.StringFlag{
+				Name:    "cache-dir",
+				Usage:   "Cache directory (default: ~/.gqlcli/cache)",
+				Value:   b.config.CacheDir,
+				EnvVars: []string{"GQLCLI_CACHE_DIR"},
+			},
+			&cli.StringSliceFlag{
+				Name:  "types",
+				Usage: "Type names to pre-fetch describe entries for (repeatable or comma-separated)",
+			},
+			&cli.BoolFlag{
+				Name:  "all-types",
+				Usage: "Pre-fetch describe entries for every OBJECT and INPUT_OBJECT type",
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "Maximum concurrent describe requests",
+				Value: 4,
+			},
+			&cli.IntFlag{
+				Name:  "retries",
+				Usage: "Attempts per request for transient failures",
+				Value: 3,
+			},
+			&cli.BoolFlag{
+				Name:  "offline",
+				Usage: "Validate the existing cache without contacting the endpoint",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			b.config.URL = c.String("url")
+			b.config.Debug = c.Bool("debug")
+			b.config.CacheDir = c.String("cache-dir")
+			httpClient := NewHTTPClient(b.config)
+			b.client = httpClient
+
+			ctx := context.Background()
+			url := b.config.URL
+			cache := NewSchemaCache(b.config.CacheDir)
+			policy := RetryPolicy{Attempts: c.Int("retries")}
+
+			if !c.Bool("offline") {
+				var result map[string]interface{}
+				err := retryWithBackoff(ctx, policy, func() error {
+					var err error
+					result, err = httpClient.Introspect(ctx)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("introspection failed: %w", err)
+				}
+				if err := cache.SaveSchema(url, result); err != nil {
+					return err
+				}
+			}
+
+			result, err := cache.LoadSchema(url)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("cache incomplete: %v", err), 1)
+			}
+			typeNames := warmTypeNames(result, c.StringSlice("types"), c.Bool("all-types"))
+
+			var problems []string
+			if !c.Bool("offline") && len(typeNames) > 0 {
+				problems = append(problems, b.warmTypes(ctx, httpClient, cache, typeNames, c.Int("concurrency"), policy)...)
+			}
+
+			// Read every entry back so a corrupt or partial cache is caught here, not later.
+			if _, err := cache.LoadRoots(url); err != nil {
+				problems = append(problems, err.Error())
+			}
+			if _, err := cache.LoadScalarExamples(url); err != nil {
+				problems = append(problems, err.Error())
+			}
+			for _, name := range typeNames {
+				if _, err := cache.LoadType(url, name); err != nil {
+					problems = append(problems, err.Error())
+				}
+			}
+
+			entries, err := cache.Entries(url)
+			if err != nil {
+				return err
+			}
+			var total int64
+			for _, e := range entries {
+				total += e.Size
+			}
+			fmt.Printf("cache: %s\n", cache.EndpointDir(url))
+			fmt.Printf("entries: %d (%d describe entries)\n", len(entries), len(typeNames))
+			fmt.Printf("total size: %s\n", formatBytes(total))
+
+			if len(problems) > 0 {
+				sort.Strings(problems)
+				return cli.Exit("cache incomplete:\n  "+strings.Join(problems, "\n  "), 1)
+			}
+			return nil
+		},
+	}
+}
+
+// warmTypes fetches describe entries from the endpoint, replacing any cached
+// ones, with bounded concurrency.
+// It returns one message per type that could not be cached.
+func (b *CLIBuilder) warmTypes(ctx context.Context, client *HTTPClient, cache *SchemaCache, names []string, concurrency int, policy RetryPolicy) []string {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	d := client.getDescriber()
+	sem := make(chan struct{}, concurrency)
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		problems []string
+	)
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var typeInfo map[string]interface{}
+			err := retryWithBackoff(ctx, policy, func() error {
+				var err error
+				typeInfo, err = d.fetchRemote(ctx, name)
+				return err
+			})
+			if err == nil {
+				err = cache.SaveType(client.config.URL, name, typeInfo)
+			}
+			if err != nil {
+				mu.Lock()
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return problems
+}
+
+// warmTypeNames returns the types to pre-fetch: the explicit list, plus every
+// non-builtin OBJECT and INPUT_OBJECT when all is set.
+func warmTypeNames(result map[string]interface{}, explicit []string, all bool) []string {
+	seen := map[string]bool{}
+	var names []string
+	add := func(n string) {
+		if n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	for _, n := range explicit {
+		add(strings.TrimSpace(n))
+	}
+	if all {
+		schema, _ := unwrapSchema(result).(map[string]interface{})
+		types, _ := schema["types"].([]interface{})
+		for _, t := range types {
+			tm, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := tm["name"].(string)
+			kind, _ := tm["kind"].(string)
+			if strings.HasPrefix(name, "__") || (kind != "OBJECT" && kind != "INPUT_OBJECT") {
+				continue
+			}
+			add(name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
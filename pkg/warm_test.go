@@ -0,0 +1,302 @@
+package gqlcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestWarmThenOffline(t *testing.T) {
+	ts := newTestServer(t)
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: ts.URL, Format: "json", CacheDir: t.TempDir()}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).RegisterCommands(app)
+
+	stdout, _, err := runApp(t, app, "warm", "--all-types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, stdout, "describe entries")
+	ts.Close()
+
+	// With --cached every schema lookup below is served from the cache.
+	for _, args := range [][]string{
+		{"introspect", "--cached", "--format", "sdl"},
+		{"types", "--cached", "--kind", "ENUM"},
+		{"queries", "--cached", "--args", "--format", "json"},
+		{"mutations", "--cached", "--filter", "addBook", "--format", "json"},
+	} {
+		stdout, _, err := runApp(t, app, args...)
+		if err != nil {
+			t.Fatalf("%v offline: %v", args, err)
+		}
+		if stdout == "" {
+			t.Errorf("%v offline printed nothing", args)
+		}
+	}
+
+	stdout, _, _ = runApp(t, app, "queries", "--cached", "--args", "--format", "json")
+	assertContains(t, stdout, `"name":"books"`, `"name":"genre"`)
+	stdout, _, _ = runApp(t, app, "mutations", "--cached", "--filter", "addBook", "--format", "json")
+	assertContains(t, stdout, `"name":"addBook"`)
+
+	d := NewDescriberFromHTTPClient(NewHTTPClient(&Config{URL: ts.URL, CacheDir: cfg.CacheDir}))
+	sdl, err := d.Describe(context.Background(), "AddBookInput")
+	if err != nil {
+		t.Fatalf("describe offline: %v", err)
+	}
+	assertContains(t, sdl, "input AddBookInput", "authorName: String!")
+	if _, err := d.Describe(context.Background(), "Missing"); err == nil {
+		t.Error("expected an error for a type that is neither cached nor reachable")
+	}
+}
+
+func TestDescriberFillsDiskCache(t *testing.T) {
+	ts := newTestServer(t)
+	cfg := &Config{URL: ts.URL, CacheDir: t.TempDir()}
+	if _, err := NewDescriberFromHTTPClient(NewHTTPClient(cfg)).Describe(context.Background(), "Author"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSchemaCache(cfg.CacheDir).LoadType(ts.URL, "Author"); err != nil {
+		t.Fatalf("fetched type was not cached: %v", err)
+	}
+
+	before := ts.Requests()
+	if _, err := NewDescriberFromHTTPClient(NewHTTPClient(cfg)).Describe(context.Background(), "Author"); err != nil {
+		t.Fatal(err)
+	}
+	if ts.Requests() != before {
+		t.Error("a new Describer re-fetched a type the disk cache holds")
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"unexpected EOF", fmt.Errorf("request failed: %w", io.ErrUnexpectedEOF), true},
+		{"502", &httpStatusError{StatusCode: 502, Status: "502 Bad Gateway"}, true},
+		{"503 wrapped", fmt.Errorf("introspection failed: %w", &httpStatusError{StatusCode: 503}), true},
+		{"429", &httpStatusError{StatusCode: 429}, true},
+		{"400", &httpStatusError{StatusCode: 400}, false},
+		{"404", &httpStatusError{StatusCode: 404}, false},
+		{"graphql error", &GraphQLResponseError{Response: map[string]interface{}{}}, false},
+		{"parse error", errors.New("failed to parse response: invalid character"), false},
+		{"canceled", fmt.Errorf("request failed: %w", context.Canceled), false},
+		{"deadline", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("%s: isTransient = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWarmRetriesOnlyTransientStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		wantErr  bool
+		wantReqs int32
+	}{
+		{http.StatusBadGateway, false, 3},
+		{http.StatusTooManyRequests, false, 3},
+		{http.StatusBadRequest, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			backend := newTestServer(t)
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The first two requests fail; the rest reach the schema.
+				if atomic.AddInt32(&requests, 1) <= 2 {
+					http.Error(w, "upstream unavailable", tt.status)
+					return
+				}
+				resp, err := http.Post(backend.URL, "application/json", r.Body)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				defer resp.Body.Close()
+				w.Header().Set("Content-Type", "application/json")
+				io.Copy(w, resp.Body)
+			}))
+			defer srv.Close()
+
+			app := httpApp(t, srv.URL)
+			_, _, err := runApp(t, app, "warm", "--retries", "3")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantReqs {
+				t.Errorf("got %d requests, want %d", got, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestSchemaCommandsAreLiveByDefault(t *testing.T) {
+	ts := newTestServer(t)
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{URL: ts.URL, Format: "json", CacheDir: t.TempDir()}
+	app := &cli.App{Name: "gqlcli"}
+	NewCLIBuilder(cfg).RegisterCommands(app)
+
+	if _, _, err := runApp(t, app, "types"); err != nil {
+		t.Fatal(err)
+	}
+	// Plant a type only the cache has: --cached finds it, a live run doesn't.
+	cache := NewSchemaCache(cfg.CacheDir)
+	result, err := cache.LoadSchema(ts.URL)
+	if err != nil {
+		t.Fatalf("types did not fill the cache: %v", err)
+	}
+	schema := unwrapSchema(result).(map[string]interface{})
+	schema["types"] = append(schema["types"].([]interface{}), map[string]interface{}{"kind": "OBJECT", "name": "Shelf"})
+	if err := cache.SaveSchema(ts.URL, result); err != nil {
+		t.Fatal(err)
+	}
+
+	before := ts.Requests()
+	stdout, _, _ := runApp(t, app, "types", "--cached")
+	assertContains(t, stdout, "Shelf")
+	if ts.Requests() != before {
+		t.Error("--cached introspected although the cache holds the schema")
+	}
+	for _, cmd := range []string{"introspect", "types", "queries", "mutations"} {
+		before := ts.Requests()
+		stdout, _, err := runApp(t, app, cmd)
+		if err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+		if ts.Requests() == before {
+			t.Errorf("%s read the cache without --cached", cmd)
+		}
+		if cmd == "types" && strings.Contains(stdout, "Shelf") {
+			t.Error("types printed a type only the cache has")
+		}
+	}
+}
+
+func TestSchemaCommandsWarnWhenCacheIsUnwritable(t *testing.T) {
+	ts := newTestServer(t)
+	blocker := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocker, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	app := httpApp(t, ts.URL)
+	stdout, stderr, err := runApp(t, app, "queries", "--cache-dir", blocker)
+	if err != nil {
+		t.Fatalf("an unwritable cache failed the command: %v", err)
+	}
+	assertContains(t, stdout, "books")
+	assertContains(t, stderr, "warning: failed to cache schema")
+}
+
+func TestDescriberCacheTTL(t *testing.T) {
+	ts := newTestServer(t)
+	cfg := &Config{URL: ts.URL, CacheDir: t.TempDir()}
+	cache := NewSchemaCache(cfg.CacheDir)
+	stale := map[string]interface{}{"kind": "OBJECT", "name": "Author", "fields": []interface{}{
+		map[string]interface{}{"name": "removedField", "type": map[string]interface{}{"kind": "SCALAR", "name": "String"}},
+	}}
+	if err := cache.SaveType(ts.URL, "Author", stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh entry is trusted.
+	sdl, err := NewDescriberFromHTTPClient(NewHTTPClient(cfg)).Describe(context.Background(), "Author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, sdl, "removedField")
+
+	// One older than the TTL is fetched again.
+	entry := filepath.Join(cache.EndpointDir(ts.URL), "types", "Author.json")
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(entry, old, old); err != nil {
+		t.Fatal(err)
+	}
+	cfg.CacheTTL = time.Hour
+	sdl, err = NewDescriberFromHTTPClient(NewHTTPClient(cfg)).Describe(context.Background(), "Author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sdl, "removedField") {
+		t.Errorf("an expired entry was used:\n%s", sdl)
+	}
+
+	// A negative TTL ignores the cache.
+	if err := cache.SaveType(ts.URL, "Author", stale); err != nil {
+		t.Fatal(err)
+	}
+	cfg.CacheTTL = -1
+	sdl, _ = NewDescriberFromHTTPClient(NewHTTPClient(cfg)).Describe(context.Background(), "Author")
+	if strings.Contains(sdl, "removedField") {
+		t.Error("a negative CacheTTL still read the cache")
+	}
+}
+
+func TestDescriberReadsCachedSchemaOnce(t *testing.T) {
+	ts := newTestServer(t)
+	cfg := &Config{URL: ts.URL, CacheDir: t.TempDir()}
+	result, err := NewHTTPClient(cfg).Introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewSchemaCache(cfg.CacheDir)
+	if err := cache.SaveSchema(ts.URL, result); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDescriberFromHTTPClient(NewHTTPClient(cfg))
+	if _, err := d.Describe(context.Background(), "Book"); err != nil {
+		t.Fatal(err)
+	}
+	// The index built on the first miss serves later ones.
+	if err := os.Remove(filepath.Join(cache.EndpointDir(ts.URL), "schema.json")); err != nil {
+		t.Fatal(err)
+	}
+	before := ts.Requests()
+	if _, err := d.Describe(context.Background(), "Author"); err != nil {
+		t.Fatal(err)
+	}
+	if ts.Requests() != before {
+		t.Error("Author was fetched although the cached schema holds it")
+	}
+}
+
+func TestCacheRejectsInvalidTypeNames(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewSchemaCache(filepath.Join(dir, "cache"))
+	for _, name := range []string{"../../escape", "a/b", "", "1Book", "Book.json"} {
+		if err := cache.SaveType("http://localhost/graphql", name, map[string]interface{}{}); err == nil {
+			t.Errorf("SaveType(%q) succeeded", name)
+		}
+		if _, err := cache.LoadType("http://localhost/graphql", name); err == nil {
+			t.Errorf("LoadType(%q) succeeded", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.json")); err == nil {
+		t.Error("a type name escaped the cache directory")
+	}
+	if err := cache.SaveType("http://localhost/graphql", "_Book2", map[string]interface{}{}); err != nil {
+		t.Errorf("a valid name was refused: %v", err)
+	}
+}